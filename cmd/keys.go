@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the SSH host keys.",
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the fingerprints of the host keys.",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		configuration, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		// Listeners may share keys, only show each once.
+		seen := make(map[string]bool)
+		for _, listenerConfig := range configuration.ListenerConfigurations() {
+			hostKeys, err := listenerConfig.HostKeys()
+			if err != nil {
+				return err
+			}
+			for _, hostKey := range hostKeys {
+				if seen[hostKey.Name] {
+					continue
+				}
+				seen[hostKey.Name] = true
+				printHostKey(cmd.OutOrStdout(), hostKey)
+			}
+		}
+		return nil
+	},
+}
+
+var keysRotateCmd = &cobra.Command{
+	Use:   "rotate [TYPE...]",
+	Short: "Replace host keys with newly generated ones.",
+	Long: fmt.Sprintf(`Replace host keys with newly generated ones.
+
+By default all key types are rotated, valid types are: %s.
+The honeypot must be restarted to use the new keys.`, strings.Join(config.HostKeyTypes, ", ")),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		configuration, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		keyTypes := args
+		if len(keyTypes) == 0 {
+			keyTypes = config.HostKeyTypes
+		}
+
+		for _, keyType := range keyTypes {
+			hostKey, err := configuration.RotateHostKey(keyType)
+			if err != nil {
+				return err
+			}
+			printHostKey(cmd.OutOrStdout(), *hostKey)
+		}
+		return nil
+	},
+}
+
+// printHostKey prints the key in the same format as ssh-keygen -l.
+func printHostKey(w io.Writer, hostKey config.HostKey) {
+	publicKey := hostKey.Signer.PublicKey()
+
+	keyType := strings.TrimPrefix(publicKey.Type(), "ssh-")
+	keyType = strings.ToUpper(strings.SplitN(keyType, "-", 2)[0])
+
+	fmt.Fprintf(w, "%d %s %s (%s)\n",
+		config.HostKeyBits(publicKey),
+		gossh.FingerprintSHA256(publicKey),
+		hostKey.Name,
+		keyType)
+}
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+	keysCmd.AddCommand(keysListCmd)
+	keysCmd.AddCommand(keysRotateCmd)
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/josephlewis42/honeyssh/core"
 	"github.com/spf13/cobra"
@@ -44,12 +43,29 @@ var serveCmd = &cobra.Command{
 		sigs := make(chan os.Signal, 1)
 
 		log.Println("- Starting interrupt handler")
-		signal.Notify(sigs, os.Interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL)
-		sig := <-sigs
-		log.Printf("Got signal %q, terminating...", sig)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL, syscall.SIGHUP)
+		for sig := range sigs {
+			if sig != syscall.SIGHUP {
+				log.Printf("Got signal %q, terminating...", sig)
+				break
+			}
+
+			log.Printf("Got signal %q, reloading configuration...", sig)
+			if err := honeypot.Reload(loadConfig); err != nil {
+				log.Printf("Couldn't reload configuration: %v", err)
+			} else {
+				log.Print("Configuration reloaded")
+			}
+		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		// Sessions are given time to drain, a second signal stops waiting.
+		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
+		go func() {
+			sig := <-sigs
+			log.Printf("Got signal %q, closing sessions...", sig)
+			cancel()
+		}()
 
 		if err := honeypot.Shutdown(ctx); err != nil {
 			log.Fatalf("Server shutdown failed: %s", err)
@@ -21,23 +21,54 @@ func Free(virtOS vos.VOS) int {
 
 	return cmd.Run(virtOS, func() int {
 		w := virtOS.Stdout()
+		mem := virtOS.MemInfo()
 
+		format := func(kb int64) string {
+			return fmt.Sprintf("%d", kb)
+		}
 		if *humanSize {
-			fmt.Fprintln(w,
-				`              total        used        free      shared  buff/cache   available
-Mem:           7.2G        4.1G        1.8G        623M        1.3G        2.2G
-Swap:           23G        4.1G         19G`)
-
-		} else {
-			fmt.Fprintln(w,
-				`              total        used        free      shared  buff/cache   available
-Mem:        7596572     4387812     1215572      730612     1993188     2171992
-Swap:      24587768     4301240    20286528`)
+			format = freeHumanSize
 		}
+
+		buffCache := mem.Buffers + mem.Cached + mem.SReclaimable
+		used := mem.MemTotal - mem.MemFree - buffCache
+		fmt.Fprintln(w, "              total        used        free      shared  buff/cache   available")
+		fmt.Fprintf(w, "Mem:    %11s %11s %11s %11s %11s %11s\n",
+			format(mem.MemTotal),
+			format(used),
+			format(mem.MemFree),
+			format(mem.Shmem),
+			format(buffCache),
+			format(mem.MemAvailable))
+		fmt.Fprintf(w, "Swap:   %11s %11s %11s\n",
+			format(mem.SwapTotal),
+			format(mem.SwapTotal-mem.SwapFree),
+			format(mem.SwapFree))
 		return 0
 	})
 }
 
+// freeHumanSize formats a size in kB like procps, using the smallest unit
+// that fits in four characters.
+func freeHumanSize(kb int64) string {
+	bytes := float64(kb) * 1024
+	if out := fmt.Sprintf("%dB", int64(bytes)); len(out) <= 4 {
+		return out
+	}
+
+	scaled := bytes
+	for _, unit := range "KMGTPE" {
+		scaled /= 1024
+		if out := fmt.Sprintf("%.1f%c", scaled, unit); len(out) <= 4 {
+			return out
+		}
+		if out := fmt.Sprintf("%d%c", int64(scaled), unit); len(out) <= 4 {
+			return out
+		}
+	}
+	return fmt.Sprintf("%dE", int64(scaled))
+}
+
 var _ vos.ProcessFunc = Free
 
 func init() {
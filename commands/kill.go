@@ -0,0 +1,269 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/josephlewis42/honeyssh/core/vos"
+)
+
+const killUsage = "kill [-s sigspec | -n signum | -sigspec] pid | jobspec ... or kill -l [sigspec]"
+
+// Kill implements a kill command like the bash builtin.
+func Kill(virtOS vos.VOS) int {
+	w := virtOS.Stdout()
+	errW := virtOS.Stderr()
+	args := virtOS.Args()[1:]
+
+	if len(args) == 0 || args[0] == "--help" {
+		fmt.Fprintf(errW, "kill: usage: %s\n", killUsage)
+		return 2
+	}
+
+	sig := vos.SIGTERM
+	switch arg := args[0]; {
+	case arg == "-l" || arg == "-L":
+		return killList(w, errW, args[1:])
+	case arg == "-s" || arg == "-n":
+		if len(args) < 2 {
+			fmt.Fprintf(errW, "kill: %s: option requires an argument\n", arg)
+			return 2
+		}
+		parsed, err := vos.ParseSignal(args[1])
+		if err != nil {
+			fmt.Fprintf(errW, "kill: %v\n", err)
+			return 1
+		}
+		sig, args = parsed, args[2:]
+	case arg == "--":
+		args = args[1:]
+	case strings.HasPrefix(arg, "-"):
+		parsed, err := vos.ParseSignal(arg[1:])
+		if err != nil {
+			fmt.Fprintf(errW, "kill: %v\n", err)
+			return 1
+		}
+		sig, args = parsed, args[1:]
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(errW, "kill: usage: %s\n", killUsage)
+		return 2
+	}
+
+	status := 0
+	for _, arg := range args {
+		pid, err := strconv.Atoi(arg)
+		if err != nil {
+			fmt.Fprintf(errW, "kill: %s: arguments must be process or job IDs\n", arg)
+			status = 1
+			continue
+		}
+		if err := virtOS.Kill(pid, sig); err != nil {
+			fmt.Fprintf(errW, "kill: (%d) - %s\n", pid, killError(err))
+			status = 1
+		}
+	}
+	return status
+}
+
+// killList implements "kill -l", listing signals or converting between
+// signal names and numbers.
+func killList(w, errW io.Writer, args []string) int {
+	if len(args) == 0 {
+		for i, sig := range vos.Signals() {
+			fmt.Fprintf(w, "%2d) SIG%s", int(sig), sig)
+			if (i+1)%5 == 0 || i == len(vos.Signals())-1 {
+				fmt.Fprintln(w)
+			} else {
+				fmt.Fprint(w, "\t")
+			}
+		}
+		return 0
+	}
+
+	status := 0
+	for _, arg := range args {
+		sig, err := vos.ParseSignal(arg)
+		if err != nil {
+			fmt.Fprintf(errW, "kill: %v\n", err)
+			status = 1
+			continue
+		}
+		if _, numeric := strconv.Atoi(arg); numeric == nil {
+			fmt.Fprintln(w, sig)
+		} else {
+			fmt.Fprintln(w, int(sig))
+		}
+	}
+	return status
+}
+
+// killError describes errors from signaling a process like strerror.
+func killError(err error) string {
+	switch {
+	case errors.Is(err, syscall.ESRCH):
+		return "No such process"
+	case errors.Is(err, syscall.EPERM):
+		return "Operation not permitted"
+	default:
+		return err.Error()
+	}
+}
+
+// parseSignalOption parses leading options naming a signal like "-9",
+// "-KILL", "-s KILL" or "--signal KILL", it returns the remaining args.
+func parseSignalOption(args []string) (vos.Signal, []string, error) {
+	if len(args) == 0 {
+		return vos.SIGTERM, args, nil
+	}
+
+	switch arg := args[0]; {
+	case arg == "-s" || arg == "--signal":
+		if len(args) < 2 {
+			return 0, nil, fmt.Errorf("option '%s' requires an argument", arg)
+		}
+		sig, err := vos.ParseSignal(args[1])
+		return sig, args[2:], err
+	case strings.HasPrefix(arg, "--signal="):
+		sig, err := vos.ParseSignal(strings.TrimPrefix(arg, "--signal="))
+		return sig, args[1:], err
+	case len(arg) > 1 && strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--"):
+		if sig, err := vos.ParseSignal(arg[1:]); err == nil {
+			return sig, args[1:], nil
+		}
+	}
+	return vos.SIGTERM, args, nil
+}
+
+// signalMatching sends the signal to every process whose name matches except
+// the caller. It returns the number of processes matched.
+func signalMatching(virtOS vos.VOS, sig vos.Signal, match func(vos.ProcessInfo) bool, onError func(vos.ProcessInfo, error)) int {
+	matched := 0
+	for _, process := range virtOS.Processes() {
+		if process.PID == virtOS.Getpid() || !match(process) {
+			continue
+		}
+		matched++
+		if err := virtOS.Kill(process.PID, sig); err != nil {
+			onError(process, err)
+		}
+	}
+	return matched
+}
+
+// Pkill implements a pkill command.
+func Pkill(virtOS vos.VOS) int {
+	sig, args, err := parseSignalOption(virtOS.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(virtOS.Stderr(), "pkill: %v\n", err)
+		return 2
+	}
+
+	cmd := &SimpleCommand{
+		Use:   "pkill [OPTION]... PATTERN",
+		Short: "Signal processes by name.",
+	}
+	full := cmd.Flags().BoolLong("full", 'f', "match against the full command line")
+	exact := cmd.Flags().BoolLong("exact", 'x', "match the whole name exactly")
+
+	// Parse the remaining options as if the signal wasn't given.
+	return cmd.Run(&argsOverride{VOS: virtOS, args: append([]string{"pkill"}, args...)}, func() int {
+		patterns := cmd.Flags().Args()
+		if len(patterns) != 1 {
+			fmt.Fprintln(virtOS.Stderr(), "pkill: no matching criteria specified")
+			fmt.Fprintln(virtOS.Stderr(), "Try `pkill --help' for more information.")
+			return 2
+		}
+
+		pattern := patterns[0]
+		if *exact {
+			pattern = "^(" + pattern + ")$"
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fmt.Fprintf(virtOS.Stderr(), "pkill: cannot compile regular expression '%s': %v\n", patterns[0], err)
+			return 2
+		}
+
+		matched := signalMatching(virtOS, sig, func(process vos.ProcessInfo) bool {
+			if *full && !process.KernelThread() {
+				return re.MatchString(psCommand(process))
+			}
+			return re.MatchString(process.Name())
+		}, func(process vos.ProcessInfo, err error) {
+			fmt.Fprintf(virtOS.Stderr(), "pkill: killing pid %d failed: %s\n", process.PID, killError(err))
+		})
+		if matched == 0 {
+			return 1
+		}
+		return 0
+	})
+}
+
+// Killall implements a killall command.
+func Killall(virtOS vos.VOS) int {
+	sig, args, err := parseSignalOption(virtOS.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(virtOS.Stderr(), "killall: %v\n", err)
+		return 1
+	}
+
+	cmd := &SimpleCommand{
+		Use:   "killall [OPTION]... [--] NAME...",
+		Short: "Kill processes by name.",
+	}
+	quiet := cmd.Flags().BoolLong("quiet", 'q', "don't print complaints")
+
+	return cmd.Run(&argsOverride{VOS: virtOS, args: append([]string{"killall"}, args...)}, func() int {
+		names := cmd.Flags().Args()
+		if len(names) == 0 {
+			cmd.PrintHelp(virtOS.Stdout())
+			return 1
+		}
+
+		status := 0
+		for _, name := range names {
+			matched := signalMatching(virtOS, sig, func(process vos.ProcessInfo) bool {
+				return process.Name() == name
+			}, func(process vos.ProcessInfo, err error) {
+				if !*quiet {
+					fmt.Fprintf(virtOS.Stderr(), "%s(%d): %s\n", name, process.PID, killError(err))
+				}
+				status = 1
+			})
+			if matched == 0 {
+				if !*quiet {
+					fmt.Fprintf(virtOS.Stderr(), "%s: no process found\n", name)
+				}
+				status = 1
+			}
+		}
+		return status
+	})
+}
+
+// argsOverride replaces the arguments of a VOS.
+type argsOverride struct {
+	vos.VOS
+	args []string
+}
+
+func (a *argsOverride) Args() []string {
+	return a.args
+}
+
+var _ vos.ProcessFunc = Kill
+var _ vos.ProcessFunc = Pkill
+var _ vos.ProcessFunc = Killall
+
+func init() {
+	mustAddBinCmd("kill", Kill)
+	mustAddBinCmd("killall", Killall)
+	mustAddBinCmd("pkill", Pkill)
+}
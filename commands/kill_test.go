@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"testing"
+)
+
+func TestKill(t *testing.T) {
+	cases := goldenTestSuite{
+		"no-arg":      {[]string{"kill"}},
+		"list":        {[]string{"kill", "-l"}},
+		"list-number": {[]string{"kill", "-l", "9", "TERM"}},
+		"missing":     {[]string{"kill", "-9", "12345"}},
+		"bad-signal":  {[]string{"kill", "-FOO", "1"}},
+		"bad-pid":     {[]string{"kill", "-s", "KILL", "init"}},
+	}
+
+	cases.Run(t, Kill)
+}
+
+func TestKillall(t *testing.T) {
+	cases := goldenTestSuite{
+		"no-process": {[]string{"killall", "-9", "xmrig"}},
+	}
+
+	cases.Run(t, Killall)
+}
+
+func TestNohup(t *testing.T) {
+	cases := goldenTestSuite{
+		"no-arg": {[]string{"nohup"}},
+		"echo":   {[]string{"nohup", "/bin/echo", "hello"}},
+	}
+
+	cases.Run(t, Nohup)
+}
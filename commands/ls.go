@@ -139,13 +139,19 @@ func Ls(virtOS vos.VOS) int {
 					modTime = f.ModTime().Format("Jan _2 15:04")
 				}
 
+				// Devices show their major and minor numbers instead of a size.
+				size := sizeFmt(f.Size())
+				if device, ok := f.Sys().(*vos.Device); ok {
+					size = fmt.Sprintf("%d, %d", device.Major, device.Minor)
+				}
+
 				uid, gid := getUIDGID(f)
 				fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
-					f.Mode().String(),
+					lsMode(f.Mode()),
 					hardLinks,
 					uid2name(uid),
 					gid2name(gid),
-					sizeFmt(f.Size()),
+					size,
 					modTime,
 					color.Sprintf(Dircolor(f), "%s", f.Name()))
 			}
@@ -186,6 +192,45 @@ func Ls(virtOS vos.VOS) int {
 	return exitCode
 }
 
+// lsMode formats the mode like ls, Go's format differs for special files
+// e.g. "Dcrw-rw-rw-" rather than "crw-rw-rw-".
+func lsMode(mode fs.FileMode) string {
+	var fileType byte
+	switch {
+	case mode.IsDir():
+		fileType = 'd'
+	case mode&fs.ModeSymlink != 0:
+		fileType = 'l'
+	case mode&fs.ModeCharDevice != 0:
+		fileType = 'c'
+	case mode&fs.ModeDevice != 0:
+		fileType = 'b'
+	case mode&fs.ModeNamedPipe != 0:
+		fileType = 'p'
+	case mode&fs.ModeSocket != 0:
+		fileType = 's'
+	default:
+		fileType = '-'
+	}
+
+	out := []byte{fileType}
+	out = append(out, mode.Perm().String()[1:]...)
+	setExec := func(i int, set bool, lower, upper byte) {
+		if !set {
+			return
+		}
+		if out[i] == 'x' {
+			out[i] = lower
+		} else {
+			out[i] = upper
+		}
+	}
+	setExec(3, mode&fs.ModeSetuid != 0, 's', 'S')
+	setExec(6, mode&fs.ModeSetgid != 0, 's', 'S')
+	setExec(9, mode&fs.ModeSticky != 0, 't', 'T')
+	return string(out)
+}
+
 type LsColorTest struct {
 	color *fcolor.Color
 	test  func(fileInfo os.FileInfo) bool
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/josephlewis42/honeyssh/core/vos"
+)
+
+// Lscpu implements the lscpu command.
+func Lscpu(virtOS vos.VOS) int {
+	cmd := &SimpleCommand{
+		Use:   "lscpu [OPTION...]",
+		Short: "Display information about the CPU architecture.",
+
+		// Never bail, even if args are bad.
+		NeverBail: true,
+	}
+
+	return cmd.Run(virtOS, func() int {
+		w := virtOS.Stdout()
+		hardware := virtOS.Hardware()
+		field := func(name string, value interface{}) {
+			fmt.Fprintf(w, "%-25s%v\n", name, value)
+		}
+
+		field("Architecture:", virtOS.Uname().Machine)
+		field("  CPU op-mode(s):", "32-bit, 64-bit")
+		field("  Address sizes:", "46 bits physical, 48 bits virtual")
+		field("  Byte Order:", "Little Endian")
+		field("CPU(s):", hardware.CPUs)
+		field("  On-line CPU(s) list:", hardware.CPUList())
+		field("Vendor ID:", "GenuineIntel")
+		field("  Model name:", "unknown")
+		field("    CPU family:", 6)
+		field("    Model:", 45)
+		field("    Thread(s) per core:", 1)
+		field("    Core(s) per socket:", hardware.CPUs)
+		field("    Socket(s):", 1)
+		field("    Stepping:", "unknown")
+		field("    BogoMIPS:", "1234.59")
+		for i, line := range wrapWords(hardware.CPUFlags, 90) {
+			if i == 0 {
+				field("    Flags:", line)
+			} else {
+				field("", line)
+			}
+		}
+		if hardware.Hypervisor != "" {
+			field("Virtualization features:", "")
+			field("  Hypervisor vendor:", hardware.Hypervisor)
+			field("  Virtualization type:", "full")
+		}
+		field("Caches (sum of all):", "")
+		l1 := fmt.Sprintf("%d KiB (%d instance)", 32*hardware.CPUs, hardware.CPUs)
+		if hardware.CPUs > 1 {
+			l1 = fmt.Sprintf("%d KiB (%d instances)", 32*hardware.CPUs, hardware.CPUs)
+		}
+		field("  L1d:", l1)
+		field("  L1i:", l1)
+		field("  L2:", "4 MiB (1 instance)")
+		field("NUMA:", "")
+		field("  NUMA node(s):", 1)
+		field("  NUMA node0 CPU(s):", hardware.CPUList())
+		return 0
+	})
+}
+
+// wrapWords joins words into lines no longer than width unless a single
+// word is longer.
+func wrapWords(words []string, width int) []string {
+	var lines []string
+	var line []string
+	lineLen := 0
+	for _, word := range words {
+		if len(line) > 0 && lineLen+1+len(word) > width {
+			lines = append(lines, strings.Join(line, " "))
+			line, lineLen = nil, 0
+		}
+		if len(line) > 0 {
+			lineLen++
+		}
+		line = append(line, word)
+		lineLen += len(word)
+	}
+	if len(line) > 0 {
+		lines = append(lines, strings.Join(line, " "))
+	}
+	return lines
+}
+
+var _ vos.ProcessFunc = Lscpu
+
+func init() {
+	mustAddBinCmd("lscpu", Lscpu)
+}
@@ -0,0 +1,13 @@
+package commands
+
+import (
+	"testing"
+)
+
+func TestLscpu(t *testing.T) {
+	cases := goldenTestSuite{
+		"no-arg": {[]string{"lscpu"}},
+	}
+
+	cases.Run(t, Lscpu)
+}
@@ -2,16 +2,16 @@ package commands
 
 import (
 	"fmt"
+	"net"
 	"strings"
 
 	"github.com/josephlewis42/honeyssh/core/vos"
 )
 
-var (
-	// The commands here have been modified to be roughly consistent with respect
-	// to IP and MAC addresses.
-	ifconfigText = strings.TrimSpace(`
-lo: flags=73<UP,LOOPBACK,RUNNING>  mtu 65536
+// The commands here are generated from the configured hardware to be
+// consistent with respect to IP and MAC addresses.
+
+const ifconfigLoopback = `lo: flags=73<UP,LOOPBACK,RUNNING>  mtu 65536
         inet 127.0.0.1  netmask 255.0.0.0
         inet6 ::1  prefixlen 128  scopeid 0x10<host>
         loop  txqueuelen 1000  (Local Loopback)
@@ -19,44 +19,135 @@ lo: flags=73<UP,LOOPBACK,RUNNING>  mtu 65536
         RX errors 0  dropped 0  overruns 0  frame 0
         TX packets 687175  bytes 67648738 (67.6 MB)
         TX errors 0  dropped 0 overruns 0  carrier 0  collisions 0
+`
 
-ens4: flags=4163<BROADCAST,MULTICAST,UP,LOWER_UP>  mtu 1500
-        inet 10.128.0.2   netmask 255.255.255.0  broadcast 10.128.0.2
-        inet6 fe80::4001:aff:fe80:2  prefixlen 64  scopeid 0x20<link>
-        ether 42:01:0a:80:00:02  txqueuelen 1000  (Ethernet)
+const ifconfigEthernet = `
+%[1]s: flags=4163<BROADCAST,MULTICAST,UP,LOWER_UP>  mtu 1500
+        inet %[2]s   netmask 255.255.255.0  broadcast %[2]s
+        inet6 %[3]s  prefixlen 64  scopeid 0x20<link>
+        ether %[4]s  txqueuelen 1000  (Ethernet)
         RX packets 44923709  bytes 57490779806 (57.4 GB)
         RX errors 0  dropped 0  overruns 0  frame 0
         TX packets 12923339  bytes 2665088356 (2.6 GB)
         TX errors 0  dropped 0 overruns 0  carrier 0  collisions 0
-`)
+`
+
+func ifconfigText(hardware vos.Hardware) string {
+	out := &strings.Builder{}
+	out.WriteString(ifconfigLoopback)
+	for _, iface := range hardware.NetworkInterfaces {
+		fmt.Fprintf(out, ifconfigEthernet, iface.Name, iface.IP, iface.LinkLocal(), iface.MAC)
+	}
+	return strings.TrimSpace(out.String())
+}
 
-	ipAddress = strings.TrimSpace(`
-1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN group default qlen 1000
+func ipAddress(hardware vos.Hardware) string {
+	out := &strings.Builder{}
+	out.WriteString(`1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN group default qlen 1000
     link/loopback 00:00:00:00:00:00 brd 00:00:00:00:00:00
     inet 127.0.0.1/8 scope host lo
        valid_lft forever preferred_lft forever
     inet6 ::1/128 scope host
        valid_lft forever preferred_lft forever
-2: ens4: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1460 qdisc mq state UP group default qlen 1000
-    link/ether 42:01:0a:80:00:02 brd ff:ff:ff:ff:ff:ff
-    inet 10.128.0.2/32 brd 10.128.0.2 scope global dynamic ens4
+`)
+	for i, iface := range hardware.NetworkInterfaces {
+		fmt.Fprintf(out, `%[1]d: %[2]s: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1460 qdisc mq state UP group default qlen 1000
+    link/ether %[3]s brd ff:ff:ff:ff:ff:ff
+    inet %[4]s/32 brd %[4]s scope global dynamic %[2]s
        valid_lft 86099sec preferred_lft 86099sec
-    inet6 fe80::4001:aff:fe80:2/64 scope link
+    inet6 %[5]s/64 scope link
        valid_lft forever preferred_lft forever
-`)
+`, i+2, iface.Name, iface.MAC, iface.IP, iface.LinkLocal())
+	}
+	return strings.TrimSpace(out.String())
+}
 
-	ipLink = strings.TrimSpace(`
-1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN mode DEFAULT group default qlen 1000
+func ipLink(hardware vos.Hardware) string {
+	out := &strings.Builder{}
+	out.WriteString(`1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN mode DEFAULT group default qlen 1000
     link/loopback 00:00:00:00:00:00 brd 00:00:00:00:00:00
-2: ens4: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1460 qdisc mq state UP mode DEFAULT group default qlen 1000
-    link/ether 42:01:0a:80:00:02 brd ff:ff:ff:ff:ff:ff
 `)
+	for i, iface := range hardware.NetworkInterfaces {
+		fmt.Fprintf(out, `%d: %s: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1460 qdisc mq state UP mode DEFAULT group default qlen 1000
+    link/ether %s brd ff:ff:ff:ff:ff:ff
+`, i+2, iface.Name, iface.MAC)
+	}
+	return strings.TrimSpace(out.String())
+}
 
-	ipRoute = strings.TrimSpace(`
-default via 10.128.0.1 dev ens4
-10.128.0.1 dev ens4 scope link
-`)
+// ipRoute routes through the first address of the first interface's network.
+func ipRoute(hardware vos.Hardware) string {
+	if len(hardware.NetworkInterfaces) == 0 {
+		return ""
+	}
+	iface := hardware.NetworkInterfaces[0]
+	gateway := iface.IP.Mask(net.CIDRMask(24, 32))
+	gateway[3] = 1
+	return fmt.Sprintf("default via %[1]s dev %[2]s\n%[1]s dev %[2]s scope link", gateway, iface.Name)
+}
 
+// ipNtable lists the neighbor table of every interface.
+func ipNtable(hardware vos.Hardware) string {
+	out := &strings.Builder{}
+	for _, family := range []struct {
+		name     string
+		cache    string
+		locktime int
+	}{
+		{"inet", "arp_cache", 1000},
+		{"inet6", "ndisc_cache", 0},
+	} {
+		fmt.Fprintf(out, `%s %s
+    thresh1 128 thresh2 512 thresh3 1024 gc_int 30000
+    refcnt 1 reachable 31176 base_reachable 30000 retrans 1000
+    gc_stale 60000 delay_probe 5000 queue 101
+    app_probes 0 ucast_probes 3 mcast_probes 3
+    anycast_delay 1000 proxy_delay 800 proxy_queue 64 locktime %d
+
+`, family.name, family.cache, family.locktime)
+
+		var names []string
+		for _, iface := range hardware.NetworkInterfaces {
+			names = append(names, iface.Name)
+		}
+		for _, name := range append(names, "lo") {
+			fmt.Fprintf(out, `%s %s
+    dev %s
+    refcnt 2 reachable 41140 base_reachable 30000 retrans 1000
+    gc_stale 60000 delay_probe 5000 queue 101
+    app_probes 0 ucast_probes 3 mcast_probes 3
+    anycast_delay 1000 proxy_delay 800 proxy_queue 64 locktime %d
+
+`, family.name, family.cache, name, family.locktime)
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// ipNetconf lists the configuration of every interface.
+func ipNetconf(hardware vos.Hardware) string {
+	out := &strings.Builder{}
+	for _, family := range []string{"inet", "inet6"} {
+		names := []string{"lo"}
+		for _, iface := range hardware.NetworkInterfaces {
+			names = append(names, iface.Name)
+		}
+		for _, name := range append(names, "all", "default") {
+			fmt.Fprintf(out, "%s %s forwarding off", family, name)
+			if family == "inet" {
+				if name == "lo" {
+					out.WriteString(" rp_filter off")
+				} else {
+					out.WriteString(" rp_filter strict")
+				}
+			}
+			out.WriteString(" mc_forwarding off proxy_neigh off ignore_routes_with_linkdown off\n")
+		}
+	}
+	return strings.TrimRight(out.String(), "\n")
+}
+
+var (
 	ipRule = strings.TrimSpace(`
 0:      from all lookup local
 32766:  from all lookup main
@@ -76,61 +167,6 @@ prefix fc00::/7 label 5
 prefix ::/0 label 1
 `)
 
-	ipNtable = strings.TrimSpace(`
-inet arp_cache
-    thresh1 128 thresh2 512 thresh3 1024 gc_int 30000
-    refcnt 1 reachable 31176 base_reachable 30000 retrans 1000
-    gc_stale 60000 delay_probe 5000 queue 101
-    app_probes 0 ucast_probes 3 mcast_probes 3
-    anycast_delay 1000 proxy_delay 800 proxy_queue 64 locktime 1000
-
-inet arp_cache
-    dev ens4
-    refcnt 2 reachable 41140 base_reachable 30000 retrans 1000
-    gc_stale 60000 delay_probe 5000 queue 101
-    app_probes 0 ucast_probes 3 mcast_probes 3
-    anycast_delay 1000 proxy_delay 800 proxy_queue 64 locktime 1000
-
-inet arp_cache
-    dev lo
-    refcnt 2 reachable 35048 base_reachable 30000 retrans 1000
-    gc_stale 60000 delay_probe 5000 queue 101
-    app_probes 0 ucast_probes 3 mcast_probes 3
-    anycast_delay 1000 proxy_delay 800 proxy_queue 64 locktime 1000
-
-inet6 ndisc_cache
-    thresh1 128 thresh2 512 thresh3 1024 gc_int 30000
-    refcnt 1 reachable 35552 base_reachable 30000 retrans 1000
-    gc_stale 60000 delay_probe 5000 queue 101
-    app_probes 0 ucast_probes 3 mcast_probes 3
-    anycast_delay 1000 proxy_delay 800 proxy_queue 64 locktime 0
-
-inet6 ndisc_cache
-    dev ens4
-    refcnt 4 reachable 39848 base_reachable 30000 retrans 1000
-    gc_stale 60000 delay_probe 5000 queue 101
-    app_probes 0 ucast_probes 3 mcast_probes 3
-    anycast_delay 1000 proxy_delay 800 proxy_queue 64 locktime 0
-
-inet6 ndisc_cache
-    dev lo
-    refcnt 2 reachable 23036 base_reachable 30000 retrans 1000
-    gc_stale 60000 delay_probe 5000 queue 101
-    app_probes 0 ucast_probes 3 mcast_probes 3
-    anycast_delay 1000 proxy_delay 800 proxy_queue 64 locktime 0
-`)
-
-	ipNetconf = strings.TrimSpace(`
-    inet lo forwarding off rp_filter off mc_forwarding off proxy_neigh off ignore_routes_with_linkdown off
-    inet ens4 forwarding off rp_filter strict mc_forwarding off proxy_neigh off ignore_routes_with_linkdown off
-    inet all forwarding off rp_filter strict mc_forwarding off proxy_neigh off ignore_routes_with_linkdown off
-    inet default forwarding off rp_filter strict mc_forwarding off proxy_neigh off ignore_routes_with_linkdown off
-    inet6 lo forwarding off mc_forwarding off proxy_neigh off ignore_routes_with_linkdown off
-    inet6 ens4 forwarding off mc_forwarding off proxy_neigh off ignore_routes_with_linkdown off
-    inet6 all forwarding off mc_forwarding off proxy_neigh off ignore_routes_with_linkdown off
-    inet6 default forwarding off mc_forwarding off proxy_neigh off ignore_routes_with_linkdown off
-`)
-
 	ipTunnel = ""
 )
 
@@ -145,7 +181,7 @@ func Ifconfig(virtOS vos.VOS) int {
 	}
 
 	return cmd.Run(virtOS, func() int {
-		fmt.Fprintln(virtOS.Stdout(), ifconfigText)
+		fmt.Fprintln(virtOS.Stdout(), ifconfigText(virtOS.Hardware()))
 		return 0
 	})
 }
@@ -153,7 +189,7 @@ func Ifconfig(virtOS vos.VOS) int {
 // Ip implements the ip command (newer replacemnet for ifconfig)
 func Ip(virtOS vos.VOS) int {
 	cmd := &SimpleCommand{
-		Use:   "ip [ OPTIONS ] (link | address | addrlabel | route | rule | ntable | tunnel)",
+		Use:   "ip [ OPTIONS ] (link | address | addrlabel | route | rule | netconf | ntable | tunnel)",
 		Short: "configure routing, devices, interfaces, and tunnels",
 
 		// Never bail, even if args are bad.
@@ -166,24 +202,27 @@ func Ip(virtOS vos.VOS) int {
 			opt = args[0]
 		}
 
+		hardware := virtOS.Hardware()
 		toDisplay := ""
 		switch opt {
 		case "link":
-			toDisplay = ipLink
+			toDisplay = ipLink(hardware)
 		case "route":
-			toDisplay = ipRoute
+			toDisplay = ipRoute(hardware)
 		case "rule":
 			toDisplay = ipRule
 		case "tunnel":
 			toDisplay = ""
 		case "addrlabel":
 			toDisplay = ipAddrlabel
+		case "netconf":
+			toDisplay = ipNetconf(hardware)
 		case "ntable":
-			toDisplay = ipNtable
+			toDisplay = ipNtable(hardware)
 		case "address", "":
 			fallthrough
 		default:
-			toDisplay = ipAddress
+			toDisplay = ipAddress(hardware)
 		}
 
 		fmt.Fprintln(virtOS.Stdout(), toDisplay)
@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"testing"
+)
+
+func TestIfconfig(t *testing.T) {
+	cases := goldenTestSuite{
+		"no-arg": {[]string{"ifconfig"}},
+	}
+
+	cases.Run(t, Ifconfig)
+}
+
+func TestIp(t *testing.T) {
+	cases := goldenTestSuite{
+		"no-arg":  {[]string{"ip"}},
+		"link":    {[]string{"ip", "link"}},
+		"route":   {[]string{"ip", "route"}},
+		"ntable":  {[]string{"ip", "ntable"}},
+		"netconf": {[]string{"ip", "netconf"}},
+	}
+
+	cases.Run(t, Ip)
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/josephlewis42/honeyssh/core/vos"
+)
+
+// Nohup implements a fake POSIX nohup command.
+//
+// https://pubs.opengroup.org/onlinepubs/9699919799.2018edition/utilities/nohup.html
+func Nohup(virtOS vos.VOS) int {
+	cmd := &SimpleCommand{
+		Use:   "nohup COMMAND [ARG]...",
+		Short: "Run COMMAND, ignoring hangup signals.",
+	}
+
+	return cmd.Run(virtOS, func() int {
+		args := cmd.Flags().Args()
+
+		if len(args) == 0 {
+			fmt.Fprintln(virtOS.Stderr(), "nohup: missing operand")
+			fmt.Fprintln(virtOS.Stderr(), "Try 'nohup --help' for more information.")
+			return 125
+		}
+
+		if virtOS.GetPTY().IsPTY {
+			fmt.Fprintln(virtOS.Stderr(), "nohup: ignoring input")
+		}
+
+		// The command can't read from the terminal.
+		proc, err := virtOS.StartProcess(args[0], args, &vos.ProcAttr{
+			Files: vos.NewVIOAdapter(nil, virtOS.Stdout(), virtOS.Stderr()),
+		})
+		if err != nil {
+			fmt.Fprintf(virtOS.Stderr(), "nohup: failed to run command '%s': %v\n", args[0], err)
+			return 127
+		}
+
+		return proc.Run()
+	})
+}
+
+var _ vos.ProcessFunc = Nohup
+
+func init() {
+	mustAddBinCmd("nohup", Nohup)
+}
@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"testing"
+)
+
+func TestPs(t *testing.T) {
+	cases := goldenTestSuite{
+		"no-arg": {[]string{"ps"}},
+		"aux":    {[]string{"ps", "aux"}},
+		"help":   {[]string{"ps", "--help"}},
+	}
+
+	cases.Run(t, Ps)
+}
+
+func TestTop(t *testing.T) {
+	cases := goldenTestSuite{
+		"batch": {[]string{"top", "-b", "-n", "1"}},
+	}
+
+	cases.Run(t, Top)
+}
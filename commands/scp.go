@@ -2,31 +2,111 @@ package commands
 
 import (
 	"archive/tar"
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	goscp "github.com/bramvdbogaerde/go-scp"
 	"github.com/josephlewis42/honeyssh/core/vos"
 )
 
-func scpUpload(vos vos.VOS, path string) (err error) {
-	// Start upload in VOS
-	uploadFd, err := vos.DownloadPath(fmt.Sprintf("scp_upload://%s", path))
+var errSomeFilesFailed = errors.New("some files could not be transferred")
+
+// scpTimes holds the times sent in a T directive.
+type scpTimes struct {
+	mtime time.Time
+	atime time.Time
+}
+
+// scpDir is a directory being received in sink mode.
+type scpDir struct {
+	path    string
+	tarPath string
+	times   *scpTimes
+}
+
+// scpSink receives files from the client, it's started with scp -t.
+type scpSink struct {
+	virtOS    vos.VOS
+	in        *bufio.Reader
+	tarWriter *tar.Writer
+
+	target    string
+	recursive bool
+	dirs      []scpDir
+}
+
+// scpWarn sends a non-fatal error to the other side of the connection.
+func scpWarn(w io.Writer, err error) {
+	fmt.Fprintf(w, "\x01scp: %s\n", err)
+}
+
+// parseScpFileLine parses the body of a C or D directive e.g.
+// "0644 123 name".
+func parseScpFileLine(line string) (mode os.FileMode, size int64, name string, err error) {
+	parts := strings.SplitN(line, " ", 3)
+	if len(parts) != 3 {
+		return 0, 0, "", fmt.Errorf("bad directive %q", line)
+	}
+
+	perm, err := strconv.ParseUint(parts[0], 8, 32)
 	if err != nil {
-		fmt.Fprintln(vos.Stderr(), "Error", err)
-		return err
+		return 0, 0, "", fmt.Errorf("bad mode %q", parts[0])
 	}
-	defer uploadFd.Close()
-	tarWriter := tar.NewWriter(uploadFd)
-	defer tarWriter.Close()
+	size, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || size < 0 {
+		return 0, 0, "", fmt.Errorf("bad size %q", parts[1])
+	}
+
+	name = parts[2]
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return 0, 0, "", fmt.Errorf("error: unexpected filename: %s", name)
+	}
+
+	return os.FileMode(perm).Perm(), size, name, nil
+}
+
+// parseScpTimes parses the body of a T directive e.g. "1234 0 1234 0".
+func parseScpTimes(line string) (*scpTimes, error) {
+	var mtime, mtimeUsec, atime, atimeUsec int64
+	if _, err := fmt.Sscanf(line, "%d %d %d %d", &mtime, &mtimeUsec, &atime, &atimeUsec); err != nil {
+		return nil, fmt.Errorf("mtime.sec not delimited")
+	}
+	return &scpTimes{
+		mtime: time.Unix(mtime, mtimeUsec*1000),
+		atime: time.Unix(atime, atimeUsec*1000),
+	}, nil
+}
+
+// destination returns the VFS and tar paths a received entry is written to.
+func (s *scpSink) destination(name string) (string, string) {
+	if len(s.dirs) > 0 {
+		parent := s.dirs[len(s.dirs)-1]
+		return path.Join(parent.path, name), path.Join(parent.tarPath, name)
+	}
+
+	if stat, err := s.virtOS.Stat(s.target); err == nil && stat.IsDir() {
+		return path.Join(s.target, name), name
+	}
+	return s.target, name
+}
+
+func (s *scpSink) run() error {
+	out := s.virtOS.Stdout()
+	var times *scpTimes
 
 	// Start the session by sending an ACK
-	goscp.Ack(vos.Stdout())
+	goscp.Ack(out)
 
 	for {
-		resp, err := goscp.ParseResponse(vos.Stdin())
+		directive, err := s.in.ReadByte()
 		switch {
 		case err == io.EOF:
 			return nil
@@ -34,79 +114,382 @@ func scpUpload(vos vos.VOS, path string) (err error) {
 			return err
 		}
 
-		fmt.Printf("Receiving message: %d %q\n", resp.Type, resp.Message)
+		// OK is a lone byte, everything else is followed by a line.
+		if directive == 0x00 {
+			continue
+		}
+		line, err := s.in.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
 
-		switch resp.Type {
-		// OK or non-fatal error:
-		case 0x00, 0x01:
-			goscp.Ack(vos.Stdout())
+		switch directive {
+		case 0x01: // Non-fatal error
 			continue
 
-		case 0x02:
-			return fmt.Errorf("fatal error")
-		case 'E': // exit
-			return nil
+		case 0x02: // Fatal error
+			return fmt.Errorf("fatal error: %s", line)
 
-		case 'C': // File transfer
-			fileInfo, err := resp.ParseFileInfos()
-			if err != nil {
+		case 'T': // Set timestamps for next file or directory
+			if times, err = parseScpTimes(line); err != nil {
 				return err
 			}
-			mode, err := strconv.ParseInt(fileInfo.Permissions, 8, 64)
-			if err != nil {
-				return fmt.Errorf("bad mode %q", fileInfo.Permissions)
+			goscp.Ack(out)
+
+		case 'E': // End of directory
+			if len(s.dirs) == 0 {
+				return errors.New("unexpected end of directory")
+			}
+			dir := s.dirs[len(s.dirs)-1]
+			s.dirs = s.dirs[:len(s.dirs)-1]
+			if dir.times != nil {
+				s.virtOS.Chtimes(dir.path, dir.times.atime, dir.times.mtime)
 			}
+			goscp.Ack(out)
 
-			if err := tarWriter.WriteHeader(&tar.Header{
-				Name: fileInfo.Filename,
-				Mode: mode,
-				Size: fileInfo.Size,
-			}); err != nil {
+		case 'D': // Start of directory
+			mode, _, name, err := parseScpFileLine(line)
+			if err != nil {
 				return err
 			}
-			goscp.Ack(vos.Stdout())
+			if !s.recursive {
+				return errors.New("received directory without -r")
+			}
+			dest, tarPath := s.destination(name)
+			if err := s.receiveDir(dest, tarPath, mode, times); err != nil {
+				return err
+			}
+			times = nil
+			goscp.Ack(out)
 
-			if _, err := io.CopyN(tarWriter, vos.Stdin(), fileInfo.Size); err != nil {
-				if err != io.EOF {
+		case 'C': // File transfer
+			mode, size, name, err := parseScpFileLine(line)
+			if err != nil {
+				return err
+			}
+			dest, tarPath := s.destination(name)
+			if err := s.receiveFile(dest, tarPath, mode, size, times); err != nil {
+				if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
 					return err
 				}
+				scpWarn(out, err)
+			} else {
+				goscp.Ack(out)
 			}
-			goscp.Ack(vos.Stdout())
+			times = nil
 
-		case 'T', 'D': // Set timestamps for next file; directory
-			goscp.Ack(vos.Stdout())
 		default:
 			return errors.New("unknown directive")
 		}
 	}
 }
 
-// Scp implements an SCP command that only uploads.
+func (s *scpSink) receiveDir(dest, tarPath string, mode os.FileMode, times *scpTimes) error {
+	modTime := s.virtOS.Now()
+	if times != nil {
+		modTime = times.mtime
+	}
+
+	if err := s.tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     tarPath + "/",
+		Mode:     int64(mode),
+		ModTime:  modTime,
+	}); err != nil {
+		return err
+	}
+
+	if stat, err := s.virtOS.Stat(dest); err == nil {
+		if !stat.IsDir() {
+			return fmt.Errorf("%s: Not a directory", dest)
+		}
+	} else if err := s.virtOS.Mkdir(dest, mode|0700); err != nil {
+		return fmt.Errorf("%s: %v", dest, err)
+	}
+
+	s.dirs = append(s.dirs, scpDir{
+		path:    dest,
+		tarPath: tarPath,
+		times:   times,
+	})
+	return nil
+}
+
+func (s *scpSink) receiveFile(dest, tarPath string, mode os.FileMode, size int64, times *scpTimes) error {
+	out := s.virtOS.Stdout()
+
+	modTime := s.virtOS.Now()
+	if times != nil {
+		modTime = times.mtime
+	}
+
+	if err := s.tarWriter.WriteHeader(&tar.Header{
+		Name:    tarPath,
+		Mode:    int64(mode),
+		Size:    size,
+		ModTime: modTime,
+	}); err != nil {
+		return err
+	}
+
+	// Always capture the payload, even if it can't be written to the VFS.
+	var fileWriter io.Writer = io.Discard
+	fd, openErr := s.virtOS.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if openErr == nil {
+		fileWriter = fd
+	}
+
+	goscp.Ack(out)
+
+	_, err := io.CopyN(io.MultiWriter(s.tarWriter, fileWriter), s.in, size)
+	if openErr == nil {
+		// Close before setting times, flushing the file updates them.
+		if closeErr := fd.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// The source signals the end of the file with an OK.
+	if status, err := s.in.ReadByte(); err != nil {
+		return err
+	} else if status != 0x00 {
+		return errors.New("transfer aborted")
+	}
+
+	if openErr != nil {
+		return fmt.Errorf("%s: %v", dest, openErr)
+	}
+	if times != nil {
+		s.virtOS.Chtimes(dest, times.atime, times.mtime)
+	}
+	return nil
+}
+
+func scpUpload(virtOS vos.VOS, target string, recursive, targetIsDir bool) (err error) {
+	// scp -d is used when copying multiple files, it fails before the
+	// transfer starts if the target isn't a directory.
+	if targetIsDir {
+		if stat, err := virtOS.Stat(target); err != nil {
+			err = fmt.Errorf("%s: No such file or directory", target)
+			scpWarn(virtOS.Stdout(), err)
+			return err
+		} else if !stat.IsDir() {
+			err = fmt.Errorf("%s: Not a directory", target)
+			scpWarn(virtOS.Stdout(), err)
+			return err
+		}
+	}
+
+	// Start upload in VOS
+	uploadFd, err := virtOS.DownloadPath(fmt.Sprintf("scp_upload://%s", target))
+	if err != nil {
+		fmt.Fprintln(virtOS.Stderr(), "Error", err)
+		return err
+	}
+	defer uploadFd.Close()
+	tarWriter := tar.NewWriter(uploadFd)
+	defer tarWriter.Close()
+
+	sink := &scpSink{
+		virtOS:    virtOS,
+		in:        bufio.NewReader(virtOS.Stdin()),
+		tarWriter: tarWriter,
+		target:    target,
+		recursive: recursive,
+	}
+	if err := sink.run(); err != nil {
+		if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			scpWarn(virtOS.Stdout(), err)
+		}
+		return err
+	}
+	return nil
+}
+
+// scpSource sends files to the client, it's started with scp -f.
+type scpSource struct {
+	virtOS        vos.VOS
+	in            *bufio.Reader
+	recursive     bool
+	preserveTimes bool
+
+	// anyFailed is set if any file couldn't be sent.
+	anyFailed bool
+}
+
+// awaitAck reads the client's response to the last directive.
+func (s *scpSource) awaitAck() error {
+	status, err := s.in.ReadByte()
+	if err != nil {
+		return err
+	}
+	if status == 0x00 {
+		return nil
+	}
+
+	message, _ := s.in.ReadString('\n')
+	if status == 0x01 {
+		s.anyFailed = true
+		return nil
+	}
+	return fmt.Errorf("fatal error: %s", strings.TrimSuffix(message, "\n"))
+}
+
+func (s *scpSource) warn(err error) {
+	s.anyFailed = true
+	scpWarn(s.virtOS.Stdout(), err)
+}
+
+// send transmits a single path, recursing into directories.
+func (s *scpSource) send(name string) error {
+	out := s.virtOS.Stdout()
+
+	stat, err := s.virtOS.Stat(name)
+	if err != nil {
+		s.warn(fmt.Errorf("%s: No such file or directory", name))
+		return nil
+	}
+
+	if stat.IsDir() && !s.recursive {
+		s.warn(fmt.Errorf("%s: not a regular file", name))
+		return nil
+	}
+
+	if s.preserveTimes {
+		mtime := stat.ModTime().Unix()
+		fmt.Fprintf(out, "T%d 0 %d 0\n", mtime, mtime)
+		if err := s.awaitAck(); err != nil {
+			return err
+		}
+	}
+
+	if stat.IsDir() {
+		return s.sendDir(name, stat)
+	}
+
+	fd, err := s.virtOS.Open(name)
+	if err != nil {
+		s.warn(fmt.Errorf("%s: %v", name, err))
+		return nil
+	}
+	defer fd.Close()
+
+	fmt.Fprintf(out, "C%04o %d %s\n", stat.Mode().Perm(), stat.Size(), path.Base(name))
+	if err := s.awaitAck(); err != nil {
+		return err
+	}
+
+	// The size was already sent so the stream can't recover from a short
+	// read.
+	if _, err := io.CopyN(out, fd, stat.Size()); err != nil {
+		return err
+	}
+	goscp.Ack(out)
+	return s.awaitAck()
+}
+
+func (s *scpSource) sendDir(name string, stat os.FileInfo) error {
+	out := s.virtOS.Stdout()
+
+	fd, err := s.virtOS.Open(name)
+	if err != nil {
+		s.warn(fmt.Errorf("%s: %v", name, err))
+		return nil
+	}
+	entries, err := fd.Readdir(-1)
+	fd.Close()
+	if err != nil {
+		s.warn(fmt.Errorf("%s: %v", name, err))
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	fmt.Fprintf(out, "D%04o 0 %s\n", stat.Mode().Perm(), path.Base(name))
+	if err := s.awaitAck(); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := s.send(path.Join(name, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "E\n")
+	return s.awaitAck()
+}
+
+func scpDownload(virtOS vos.VOS, files []string, recursive, preserveTimes bool) error {
+	source := &scpSource{
+		virtOS:        virtOS,
+		in:            bufio.NewReader(virtOS.Stdin()),
+		recursive:     recursive,
+		preserveTimes: preserveTimes,
+	}
+
+	// Wait for the sink to signal that it's ready.
+	if err := source.awaitAck(); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := source.send(file); err != nil {
+			return err
+		}
+	}
+
+	if source.anyFailed {
+		return errSomeFilesFailed
+	}
+	return nil
+}
+
+// Scp implements the remote side of an SCP transfer.
 func Scp(virtOS vos.VOS) int {
 	cmd := &SimpleCommand{
-		Use:   "scp -t TOFILE",
+		Use:   "scp [-dprv] -t|-f FILE...",
 		Short: "Secure copy.",
 
 		// Never bail, even if args are bad.
 		NeverBail: true,
 	}
 
-	to := cmd.Flags().String('t', "", "Start scp in upload mode")
+	to := cmd.Flags().Bool('t', "Start scp in sink (upload) mode")
+	from := cmd.Flags().Bool('f', "Start scp in source (download) mode")
 	_ = cmd.Flags().Bool('v', "Start scp in verbose mode")
-	_ = cmd.Flags().Bool('r', "Start scp in recursive mode")
+	targetIsDir := cmd.Flags().Bool('d', "Require the target to be a directory")
+	recursive := cmd.Flags().Bool('r', "Start scp in recursive mode")
+	preserveTimes := cmd.Flags().Bool('p', "Preserve modification times")
 
-	return cmd.RunE(virtOS, func() error {
+	// Errors are sent in-band rather than to stderr which may be the same
+	// stream as the transfer.
+	return cmd.Run(virtOS, func() int {
+		args := cmd.Flags().Args()
+		var err error
 		switch {
-		case *to != "":
-			err := scpUpload(virtOS, *to)
-			if err != nil {
-				fmt.Fprintln(virtOS.Stderr(), err.Error())
-				fmt.Println(err.Error())
-				return err
-			}
-			return nil
+		case *to && len(args) == 1:
+			err = scpUpload(virtOS, args[0], *recursive, *targetIsDir)
+		case *from && len(args) > 0:
+			err = scpDownload(virtOS, args, *recursive, *preserveTimes)
+		default:
+			fmt.Fprintln(virtOS.Stderr(), "couldn't connect")
+			return 1
+		}
+
+		switch {
+		case err == errSomeFilesFailed:
+			return 1
+		case err != nil:
+			virtOS.LogInvalidInvocation(err)
+			return 1
 		default:
-			return errors.New("couldn't connect")
+			return 0
 		}
 	})
 }
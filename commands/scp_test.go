@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/vos"
+	"github.com/josephlewis42/honeyssh/core/vos/vostest"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScpSink(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	cmd := vostest.Command(Scp, "scp")
+	virtOS, err := cmd.VOS.StartProcess("scp", nil, &vos.ProcAttr{
+		Files: vos.NewVIOAdapter(nil, stdout, stdout),
+	})
+	require.Nil(t, err)
+	require.Nil(t, virtOS.Mkdir("/tmp", 0777))
+
+	stream := strings.Join([]string{
+		"T1000000000 0 1000000000 0\n",
+		"D0755 0 payload\n",
+		"C0755 7 a.sh\n", "echo a\n", "\x00",
+		"D0700 0 sub\n",
+		"C0644 4 b.txt\n", "bbb\n", "\x00",
+		"E\n",
+		"E\n",
+	}, "")
+
+	tarBuf := &bytes.Buffer{}
+	tarWriter := tar.NewWriter(tarBuf)
+	sink := &scpSink{
+		virtOS:    virtOS,
+		in:        bufio.NewReader(strings.NewReader(stream)),
+		tarWriter: tarWriter,
+		target:    "/tmp",
+		recursive: true,
+	}
+	require.Nil(t, sink.run())
+	require.Nil(t, tarWriter.Close())
+
+	// One ACK to start, one for every directive and one after file contents.
+	assert.Equal(t, strings.Repeat("\x00", 10), stdout.String())
+
+	contents, err := afero.ReadFile(virtOS, "/tmp/payload/sub/b.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, "bbb\n", string(contents))
+
+	stat, err := virtOS.Stat("/tmp/payload/a.sh")
+	require.Nil(t, err)
+	assert.Equal(t, "-rwxr-xr-x", stat.Mode().String())
+
+	stat, err = virtOS.Stat("/tmp/payload")
+	require.Nil(t, err)
+	assert.True(t, stat.IsDir())
+	assert.Equal(t, time.Unix(1000000000, 0).Unix(), stat.ModTime().Unix())
+
+	var tarNames []string
+	tarReader := tar.NewReader(tarBuf)
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err)
+		tarNames = append(tarNames, hdr.Name)
+	}
+	assert.Equal(t, []string{"payload/", "payload/a.sh", "payload/sub/", "payload/sub/b.txt"}, tarNames)
+}
+
+func TestScpSink_badFilename(t *testing.T) {
+	for _, name := range []string{"..", "../x", "a/b", ""} {
+		_, _, _, err := parseScpFileLine("0644 1 " + name)
+		assert.NotNil(t, err, "name %q", name)
+	}
+}
+
+func TestScp_targetIsDir(t *testing.T) {
+	for target, want := range map[string]string{
+		"/tmp/file":    "\x01scp: /tmp/file: Not a directory\n",
+		"/tmp/missing": "\x01scp: /tmp/missing: No such file or directory\n",
+	} {
+		cmd := vostest.Command(Scp, "scp", "-d", "-t", target)
+		cmd.Setup = func(virtOS vos.VOS) error {
+			if err := virtOS.MkdirAll("/tmp", 0777); err != nil {
+				return err
+			}
+			return afero.WriteFile(virtOS, "/tmp/file", []byte("x"), 0644)
+		}
+		cmd.Stdin = strings.NewReader("C0644 1 a\nx\x00")
+		out, err := cmd.CombinedOutput()
+		require.Nil(t, err)
+
+		assert.Equal(t, want, string(out), "the transfer doesn't start")
+		assert.Equal(t, 1, cmd.ExitStatus)
+	}
+}
+
+func TestScpSource(t *testing.T) {
+	cmd := vostest.Command(Scp, "scp", "-r", "-p", "-f", "/tmp/payload", "/missing")
+	cmd.Setup = func(virtOS vos.VOS) error {
+		if err := virtOS.MkdirAll("/tmp/payload", 0755); err != nil {
+			return err
+		}
+		if err := afero.WriteFile(virtOS, "/tmp/payload/a.sh", []byte("echo a\n"), 0755); err != nil {
+			return err
+		}
+		mtime := time.Unix(1000000000, 0)
+		if err := virtOS.Chtimes("/tmp/payload", mtime, mtime); err != nil {
+			return err
+		}
+		return virtOS.Chtimes("/tmp/payload/a.sh", mtime, mtime)
+	}
+	cmd.Stdin = strings.NewReader(strings.Repeat("\x00", 10))
+	out, err := cmd.CombinedOutput()
+	require.Nil(t, err)
+
+	assert.Equal(t, strings.Join([]string{
+		"T1000000000 0 1000000000 0\n",
+		"D0755 0 payload\n",
+		"T1000000000 0 1000000000 0\n",
+		"C0755 7 a.sh\n", "echo a\n", "\x00",
+		"E\n",
+		"\x01scp: /missing: No such file or directory\n",
+	}, ""), string(out))
+	assert.Equal(t, 1, cmd.ExitStatus)
+}
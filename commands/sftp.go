@@ -0,0 +1,371 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/josephlewis42/honeyssh/core/vos"
+	"github.com/pkg/sftp"
+	"github.com/spf13/afero"
+)
+
+// sftpHandler implements the pkg/sftp request handlers on top of the virtual
+// OS, logging every request the client makes.
+type sftpHandler struct {
+	virtOS vos.VOS
+}
+
+var _ sftp.FileReader = (*sftpHandler)(nil)
+var _ sftp.FileWriter = (*sftpHandler)(nil)
+var _ sftp.FileCmder = (*sftpHandler)(nil)
+var _ sftp.FileLister = (*sftpHandler)(nil)
+
+// Handlers returns the handler set for use in an SFTP request server.
+func (h *sftpHandler) Handlers() sftp.Handlers {
+	return sftp.Handlers{
+		FileGet:  h,
+		FilePut:  h,
+		FileCmd:  h,
+		FileList: h,
+	}
+}
+
+// log records the request and converts the error into one the client will
+// understand.
+func (h *sftpHandler) log(entry *logger.SFTPRequest, err error) error {
+	if err == nil {
+		entry.Result = logger.OperationResult_SUCCESS
+		h.virtOS.LogSFTPRequest(entry)
+		return nil
+	}
+
+	entry.Result = logger.OperationResult_FAILURE
+	entry.Error = err.Error()
+	h.virtOS.LogSFTPRequest(entry)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return sftp.ErrSSHFxNoSuchFile
+	case errors.Is(err, fs.ErrPermission):
+		return sftp.ErrSSHFxPermissionDenied
+	default:
+		return err
+	}
+}
+
+// Fileread implements sftp.FileReader.
+func (h *sftpHandler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	fd, err := h.virtOS.Open(r.Filepath)
+	if err := h.log(&logger.SFTPRequest{
+		Method:    r.Method,
+		Path:      r.Filepath,
+		OpenFlags: sftpOpenFlags(r.Pflags()),
+	}, err); err != nil {
+		return nil, err
+	}
+	return fd, nil
+}
+
+// Filewrite implements sftp.FileWriter.
+func (h *sftpHandler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	pflags := r.Pflags()
+	flags := os.O_WRONLY
+	if pflags.Read {
+		flags = os.O_RDWR
+	}
+	if pflags.Append {
+		flags |= os.O_APPEND
+	}
+	if pflags.Creat {
+		flags |= os.O_CREATE
+	}
+	if pflags.Trunc {
+		flags |= os.O_TRUNC
+	}
+	if pflags.Excl {
+		flags |= os.O_EXCL
+	}
+
+	var perm os.FileMode = 0644
+	if r.AttrFlags().Permissions {
+		perm = r.Attributes().FileMode().Perm()
+	}
+
+	entry := &logger.SFTPRequest{
+		Method:    r.Method,
+		Path:      r.Filepath,
+		OpenFlags: sftpOpenFlags(pflags),
+		Mode:      uint32(perm),
+	}
+
+	fd, err := h.virtOS.OpenFile(r.Filepath, flags, perm)
+	if err != nil {
+		return nil, h.log(entry, err)
+	}
+
+	// Keep a copy of everything uploaded, the attacker may remove the file
+	// from the virtual filesystem before the session ends.
+	download, err := h.virtOS.DownloadPath(fmt.Sprintf("sftp://%s", r.Filepath))
+	if err != nil {
+		fd.Close()
+		return nil, h.log(entry, err)
+	}
+
+	return &sftpUpload{
+		File:     fd,
+		download: download,
+		onClose: func(written int64, err error) error {
+			entry.BytesWritten = written
+			return h.log(entry, err)
+		},
+	}, nil
+}
+
+// Filecmd implements sftp.FileCmder.
+func (h *sftpHandler) Filecmd(r *sftp.Request) error {
+	entry := &logger.SFTPRequest{
+		Method: r.Method,
+		Path:   r.Filepath,
+		Target: r.Target,
+	}
+
+	var err error
+	switch r.Method {
+	case "Setstat":
+		err = h.setstat(r, entry)
+	case "Rename", "PosixRename":
+		err = h.virtOS.Rename(r.Filepath, r.Target)
+	case "Rmdir":
+		err = h.removeIf(r.Filepath, true)
+	case "Remove":
+		err = h.removeIf(r.Filepath, false)
+	case "Mkdir":
+		err = h.virtOS.Mkdir(r.Filepath, 0755)
+	default:
+		// Symlink, Link and any extensions.
+		err = sftp.ErrSSHFxOpUnsupported
+	}
+
+	return h.log(entry, err)
+}
+
+func (h *sftpHandler) setstat(r *sftp.Request, entry *logger.SFTPRequest) error {
+	attrFlags := r.AttrFlags()
+	attrs := r.Attributes()
+
+	if attrFlags.Size {
+		fd, err := h.virtOS.OpenFile(r.Filepath, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		if err := fd.Truncate(int64(attrs.Size)); err != nil {
+			return err
+		}
+	}
+
+	if attrFlags.UidGid {
+		if err := h.virtOS.Chown(r.Filepath, int(attrs.UID), int(attrs.GID)); err != nil {
+			return err
+		}
+	}
+
+	if attrFlags.Permissions {
+		mode := attrs.FileMode().Perm()
+		entry.Mode = uint32(mode)
+		if err := h.virtOS.Chmod(r.Filepath, mode); err != nil {
+			return err
+		}
+	}
+
+	if attrFlags.Acmodtime {
+		if err := h.virtOS.Chtimes(r.Filepath, time.Unix(int64(attrs.Atime), 0), time.Unix(int64(attrs.Mtime), 0)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeIf removes the path if its type matches what the client asked for.
+func (h *sftpHandler) removeIf(path string, wantDir bool) error {
+	stat, err := h.virtOS.Stat(path)
+	switch {
+	case err != nil:
+		return err
+	case stat.IsDir() && !wantDir:
+		return fmt.Errorf("%s: Is a directory", path)
+	case !stat.IsDir() && wantDir:
+		return fmt.Errorf("%s: Not a directory", path)
+	}
+	return h.virtOS.Remove(path)
+}
+
+// Filelist implements sftp.FileLister.
+func (h *sftpHandler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	var (
+		out sftpListerAt
+		err error
+	)
+
+	switch r.Method {
+	case "List":
+		out, err = h.readDir(r.Filepath)
+	case "Stat", "Lstat":
+		var stat os.FileInfo
+		stat, err = h.virtOS.Stat(r.Filepath)
+		if err == nil {
+			out = sftpListerAt{stat}
+		}
+	default:
+		// Readlink isn't supported by the VFS.
+		err = sftp.ErrSSHFxOpUnsupported
+	}
+
+	// Clients stat constantly and it doesn't modify anything, so only
+	// directory listings and failures are logged.
+	if r.Method == "List" || err != nil {
+		err = h.log(&logger.SFTPRequest{
+			Method: r.Method,
+			Path:   r.Filepath,
+		}, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (h *sftpHandler) readDir(path string) (sftpListerAt, error) {
+	fd, err := h.virtOS.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	entries, err := fd.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return sftpListerAt(entries), nil
+}
+
+// sftpListerAt implements sftp.ListerAt over a static list of files.
+type sftpListerAt []os.FileInfo
+
+func (l sftpListerAt) ListAt(out []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+
+	n := copy(out, l[offset:])
+	if n+int(offset) == len(l) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// sftpUpload writes to the virtual filesystem and the downloads folder.
+type sftpUpload struct {
+	afero.File
+
+	download afero.File
+	written  int64
+	onClose  func(written int64, err error) error
+}
+
+func (u *sftpUpload) WriteAt(b []byte, off int64) (int, error) {
+	if _, err := u.download.WriteAt(b, off); err != nil {
+		return 0, err
+	}
+	n, err := u.File.WriteAt(b, off)
+	u.written += int64(n)
+	return n, err
+}
+
+func (u *sftpUpload) Close() error {
+	downloadErr := u.download.Close()
+	err := u.File.Close()
+	if err == nil {
+		err = downloadErr
+	}
+	return u.onClose(u.written, err)
+}
+
+func sftpOpenFlags(pflags sftp.FileOpenFlags) (out []string) {
+	for _, flag := range []struct {
+		set  bool
+		name string
+	}{
+		{pflags.Read, "read"},
+		{pflags.Write, "write"},
+		{pflags.Append, "append"},
+		{pflags.Creat, "create"},
+		{pflags.Trunc, "truncate"},
+		{pflags.Excl, "exclusive"},
+	} {
+		if flag.set {
+			out = append(out, flag.name)
+		}
+	}
+	return
+}
+
+// sftpConn adapts the process's standard streams to the connection the SFTP
+// server expects.
+type sftpConn struct {
+	io.Reader
+	io.Writer
+}
+
+func (sftpConn) Close() error {
+	// The session owns the streams and closes them on exit.
+	return nil
+}
+
+// SftpServer implements an SFTP server backed by the virtual filesystem.
+func SftpServer(virtOS vos.VOS) int {
+	cmd := &SimpleCommand{
+		Use:   "sftp-server [-ehR]",
+		Short: "SFTP server subsystem.",
+
+		// Never bail, even if args are bad.
+		NeverBail: true,
+	}
+
+	_ = cmd.Flags().Bool('e', "Write log messages to stderr")
+	_ = cmd.Flags().Bool('R', "Place the server in read-only mode")
+
+	return cmd.RunE(virtOS, func() error {
+		handler := &sftpHandler{virtOS: virtOS}
+		server := sftp.NewRequestServer(
+			sftpConn{virtOS.Stdin(), virtOS.Stdout()},
+			handler.Handlers(),
+			sftp.WithStartDirectory(virtOS.Getwd()),
+		)
+		defer server.Close()
+
+		if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	})
+}
+
+var _ vos.ProcessFunc = SftpServer
+
+func init() {
+	if err := allCommands.AddCommand(SftpServer, "/usr/lib/openssh/sftp-server", "/usr/lib/sftp-server"); err != nil {
+		panic(err)
+	}
+}
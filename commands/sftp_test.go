@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/josephlewis42/honeyssh/core/vos"
+	"github.com/josephlewis42/honeyssh/core/vos/vostest"
+	"github.com/josephlewis42/honeyssh/third_party/memmapfs"
+	"github.com/pkg/sftp"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSftpServer(t *testing.T) {
+	clientRead, serverWrite := io.Pipe()
+	serverRead, clientWrite := io.Pipe()
+
+	cmd := vostest.Command(SftpServer, "/usr/lib/openssh/sftp-server")
+	cmd.Stdin = serverRead
+	cmd.Stdout = serverWrite
+	cmd.Stderr = io.Discard
+
+	done := make(chan error)
+	go func() {
+		err := cmd.Run()
+		serverWrite.Close()
+		done <- err
+	}()
+
+	client, err := sftp.NewClientPipe(clientRead, clientWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, client.Mkdir("/a"))
+	assert.Nil(t, client.Rename("/a", "/b"))
+
+	stat, err := client.Stat("/b")
+	require.Nil(t, err)
+	assert.True(t, stat.IsDir())
+
+	_, err = client.Stat("/a")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	entries, err := client.ReadDir("/")
+	assert.Nil(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Contains(t, names, "b")
+
+	assert.Nil(t, client.RemoveDirectory("/b"))
+
+	client.Close()
+	clientWrite.Close()
+	assert.Nil(t, <-done)
+	assert.Equal(t, 0, cmd.ExitStatus)
+}
+
+// sftpEventRecorder keeps the SFTP requests a session logs.
+type sftpEventRecorder struct {
+	vostest.NopEventRecorder
+
+	mu       sync.Mutex
+	requests []*logger.SFTPRequest
+}
+
+func (r *sftpEventRecorder) Record(event logger.LogType) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req, ok := event.(*logger.LogEntry_SftpRequest); ok {
+		r.requests = append(r.requests, req.SftpRequest)
+	}
+	return nil
+}
+
+func TestSftpServer_upload(t *testing.T) {
+	configDir := t.TempDir()
+	_, err := config.Initialize(configDir, log.New(ioutil.Discard, "", 0))
+	require.Nil(t, err)
+	cfg, err := config.Load(configDir)
+	require.Nil(t, err)
+
+	timeSource := func() time.Time {
+		return time.Date(2006, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	sharedOS := vos.NewSharedOS(memmapfs.NewMemMapFs(timeSource), func(string) vos.ProcessFunc {
+		return SftpServer
+	}, cfg, timeSource)
+	recorder := &sftpEventRecorder{}
+	tenantOS := vos.NewTenantOS(sharedOS, recorder, &vostest.FakeSSHSession{})
+	virtOS := tenantOS.LoginProc()
+	require.Nil(t, virtOS.Mkdir("/tmp", 0777))
+
+	clientRead, serverWrite := io.Pipe()
+	serverRead, clientWrite := io.Pipe()
+	proc, err := virtOS.StartProcess("/usr/lib/openssh/sftp-server", nil, &vos.ProcAttr{
+		Files: vos.NewVIOAdapter(serverRead, serverWrite, io.Discard),
+	})
+	require.Nil(t, err)
+
+	done := make(chan int)
+	go func() {
+		status := proc.Run()
+		serverWrite.Close()
+		done <- status
+	}()
+
+	client, err := sftp.NewClientPipe(clientRead, clientWrite)
+	require.Nil(t, err)
+
+	fd, err := client.Create("/tmp/payload.sh")
+	require.Nil(t, err)
+	_, err = fd.Write([]byte("echo pwned\n"))
+	require.Nil(t, err)
+	require.Nil(t, fd.Close())
+	require.Nil(t, client.Chmod("/tmp/payload.sh", 0755))
+	require.Nil(t, client.Rename("/tmp/payload.sh", "/tmp/.x"))
+
+	contents, err := afero.ReadFile(virtOS, "/tmp/.x")
+	assert.Nil(t, err)
+	assert.Equal(t, "echo pwned\n", string(contents))
+	stat, err := virtOS.Stat("/tmp/.x")
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0755), stat.Mode().Perm())
+
+	require.Nil(t, client.Remove("/tmp/.x"))
+	_, err = virtOS.Stat("/tmp/.x")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	client.Close()
+	clientWrite.Close()
+	assert.Equal(t, 0, <-done)
+
+	// The upload is kept even though it was removed.
+	downloads, err := filepath.Glob(filepath.Join(configDir, config.DownloadDirName, "*.download"))
+	require.Nil(t, err)
+	require.Len(t, downloads, 1)
+	captured, err := os.ReadFile(downloads[0])
+	require.Nil(t, err)
+	assert.Equal(t, "echo pwned\n", string(captured))
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	var methods []string
+	for _, req := range recorder.requests {
+		assert.Equal(t, logger.OperationResult_SUCCESS, req.Result, req.Method)
+		methods = append(methods, req.Method)
+	}
+	assert.Equal(t, []string{"Put", "Setstat", "Rename", "Remove"}, methods)
+
+	put := recorder.requests[0]
+	assert.Equal(t, "/tmp/payload.sh", put.Path)
+	assert.Equal(t, []string{"read", "write", "create", "truncate"}, put.OpenFlags)
+	assert.Equal(t, int64(len("echo pwned\n")), put.BytesWritten)
+	assert.Equal(t, uint32(0755), recorder.requests[1].Mode)
+	assert.Equal(t, "/tmp/.x", recorder.requests[2].Target)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"os"
 	"regexp"
 	"strings"
 
@@ -36,6 +37,13 @@ type Shell struct {
 	lastRet int
 	history []string
 
+	// interactive is set when the shell reads commands from the terminal.
+	interactive bool
+	// jobs counts the commands started in the background.
+	jobs int
+	// lastBackgroundPID is the PID of the last background command.
+	lastBackgroundPID int
+
 	// Set to true to quit the shell
 	Quit bool
 }
@@ -165,10 +173,24 @@ func (s *Shell) executeFile(file *syntax.File, rawStmt string) error {
 		if err := s.executeStatement(ec, stmt); err != nil {
 			return err
 		}
+		if s.killed() {
+			s.Quit = true
+			return nil
+		}
 	}
 	return nil
 }
 
+// killed returns whether a signal terminated the shell.
+func (s *Shell) killed() bool {
+	select {
+	case <-s.VirtualOS.Killed():
+		return true
+	default:
+		return false
+	}
+}
+
 type execContext struct {
 	stdin  io.Reader
 	stdout io.Writer
@@ -190,9 +212,15 @@ type execContext struct {
 }
 
 func (s *Shell) executeStatement(ec execContext, stmt *syntax.Stmt) error {
+	// Files opened for redirects, background commands close them on exit.
+	var files []io.Closer
+	defer func() {
+		closeAll(files)
+	}()
+
 	for _, redirect := range stmt.Redirs {
-		// Only support output indirection (>)
-		if redirect.Op != syntax.RdrOut && redirect.Op != syntax.DplOut {
+		// Only support output indirection (>, >> and >&)
+		if redirect.Op != syntax.RdrOut && redirect.Op != syntax.AppOut && redirect.Op != syntax.DplOut {
 			return s.logSyntaxError(ec, redirect)
 		}
 
@@ -225,12 +253,19 @@ func (s *Shell) executeStatement(ec execContext, stmt *syntax.Stmt) error {
 			*fromWriter = ec.stdout
 		case redirect.Op == syntax.DplOut && to == "2":
 			*fromWriter = ec.stderr
+		case redirect.Op == syntax.AppOut:
+			fd, err := s.VirtualOS.OpenFile(to, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			files = append(files, fd)
+			*fromWriter = fd
 		default:
 			fd, err := s.VirtualOS.Create(to)
 			if err != nil {
 				return err
 			}
-			defer fd.Close()
+			files = append(files, fd)
 			*fromWriter = fd
 		}
 	}
@@ -251,7 +286,12 @@ func (s *Shell) executeStatement(ec execContext, stmt *syntax.Stmt) error {
 			}
 			ec.args = append(ec.args, argStr)
 		}
-		s.executeProgramOrBuiltin(ec)
+		if stmt.Background {
+			s.executeBackground(ec, files)
+			files = nil
+		} else {
+			s.executeProgramOrBuiltin(ec)
+		}
 	case *syntax.BinaryCmd:
 		switch cmd.Op {
 		case syntax.AndStmt:
@@ -376,6 +416,9 @@ func (s *Shell) evalWordPart(ec execContext, part syntax.WordPart) (string, erro
 }
 
 func (s *Shell) runInteractive() int {
+	s.interactive = true
+	// Like bash, interactive shells can only be killed with SIGKILL.
+	s.VirtualOS.IgnoreSignals(vos.SIGTERM, vos.SIGQUIT)
 	for !s.Quit {
 		s.Readline.SetPrompt(s.prompt())
 		line, err := s.Readline.Readline()
@@ -424,6 +467,9 @@ func (s *Shell) cmdEnv() vos.VEnv {
 	// Shell only arguments
 	mapEnv.Setenv("$", fmt.Sprintf("%d", s.VirtualOS.Getpid()))
 	mapEnv.Setenv("?", fmt.Sprintf("%d", uint8(s.lastRet)))
+	if s.lastBackgroundPID != 0 {
+		mapEnv.Setenv("!", fmt.Sprintf("%d", s.lastBackgroundPID))
+	}
 	mapEnv.Setenv("WIDTH", fmt.Sprintf("%d", s.VirtualOS.GetPTY().Width))
 	mapEnv.Setenv("HEIGHT", fmt.Sprintf("%d", s.VirtualOS.GetPTY().Height))
 
@@ -457,6 +503,54 @@ func (s *Shell) executeProgramOrBuiltin(ec execContext) {
 	s.lastRet = proc.Run()
 }
 
+// executeBackground starts a program without waiting for it to finish like
+// "cmd &", it's given ownership of the redirected files. Builtins run in the
+// foreground.
+func (s *Shell) executeBackground(ec execContext, files []io.Closer) {
+	foreground := len(ec.args) == 0
+	if !foreground {
+		_, foreground = AllBuiltins[ec.args[0]]
+	}
+	if foreground {
+		s.executeProgramOrBuiltin(ec)
+		closeAll(files)
+		return
+	}
+
+	// Background commands can't read from the terminal.
+	proc, err := s.VirtualOS.StartProcess(ec.args[0], ec.args, &vos.ProcAttr{
+		Env:   append(s.VirtualOS.Environ(), ec.assignments...),
+		Files: vos.NewVIOAdapter(nil, ec.stdout, ec.stderr),
+	})
+	if err != nil {
+		fmt.Fprintf(s.Readline, "sh: %s\n", err)
+		closeAll(files)
+		return
+	}
+	if err := proc.Start(); err != nil {
+		fmt.Fprintf(s.Readline, "sh: %s\n", err)
+		closeAll(files)
+		return
+	}
+	go func() {
+		proc.Wait()
+		closeAll(files)
+	}()
+
+	s.jobs++
+	s.lastBackgroundPID = proc.Getpid()
+	s.lastRet = 0
+	if s.interactive {
+		fmt.Fprintf(s.Readline, "[%d] %d\n", s.jobs, proc.Getpid())
+	}
+}
+
+func closeAll(files []io.Closer) {
+	for _, fd := range files {
+		fd.Close()
+	}
+}
+
 func init() {
 	mustAddBinCmd("sh", RunShell)
 }
@@ -21,12 +21,19 @@ func TestRunShell(t *testing.T) {
 		"redir-dev-null":      {[]string{"sh", "-c", `/bin/echo "hello" > /null`}},
 		"redir-out-err-file":  {[]string{"sh", "-c", `/bin/echo "hello" 1>&2 2>tmp; /bin/cat tmp`}},
 		"redir-invalid-file":  {[]string{"sh", "-c", `/bin/echo "hello" >/does/not/exist`}},
+		"redir-append":        {[]string{"sh", "-c", `/bin/echo "hello" > tmp; /bin/echo "world" >> tmp; /bin/cat tmp`}},
 
 		// Pipes
 		"pipe-shell": {[]string{"sh", "-c", `/bin/echo "/bin/w" | /bin/sh`}},
 
+		// Processes
+		"ps":          {[]string{"sh", "-c", `/bin/ps`}},
+		"kill-check":  {[]string{"sh", "-c", `/bin/kill -0 $$ && /bin/echo running`}},
+		"kill-ignore": {[]string{"sh", "-c", `/bin/kill -CONT $$; /bin/echo running`}},
+		"kill-self":   {[]string{"sh", "-c", `/bin/kill -9 $$; /bin/echo unreachable`}},
+
 		// Syntax errors
-		"err-redir-all":  {[]string{"sh", "-c", `/bin/env >>1`}},
+		"err-redir-all":  {[]string{"sh", "-c", `/bin/env &>1`}},
 		"err-bad-from":   {[]string{"sh", "-c", `/bin/env 3>&1`}},
 		"err-blank-dest": {[]string{"sh", "-c", `/bin/env >''`}},
 	}
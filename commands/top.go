@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/josephlewis42/honeyssh/core/vos"
+)
+
+// Top implements a fake top command that prints a single batch mode frame.
+func Top(virtOS vos.VOS) int {
+	cmd := &SimpleCommand{
+		Use:   "top [options]",
+		Short: "Display Linux processes.",
+
+		// Never bail, even if args are bad.
+		NeverBail: true,
+	}
+
+	_ = cmd.Flags().Bool('b', "batch mode")
+	_ = cmd.Flags().Int('n', 1, "number of iterations")
+
+	return cmd.Run(virtOS, func() int {
+		w := virtOS.Stdout()
+		processes := virtOS.Processes()
+		lookupUser := UidResolver(virtOS)
+		mem := virtOS.MemInfo()
+
+		running := 0
+		for _, process := range processes {
+			if strings.HasPrefix(process.Stat, "R") || process.PID == virtOS.Getpid() {
+				running++
+			}
+		}
+
+		mib := func(kb int64) float64 {
+			return float64(kb) / 1024
+		}
+		buffCache := mem.Buffers + mem.Cached + mem.SReclaimable
+
+		fmt.Fprintf(w, "top - %s\n", formatUptime(virtOS))
+		fmt.Fprintf(w, "Tasks: %3d total, %3d running, %3d sleeping,   0 stopped,   0 zombie\n",
+			len(processes), running, len(processes)-running)
+		fmt.Fprintln(w, "%Cpu(s):  0.0 us,  0.0 sy,  0.0 ni,100.0 id,  0.0 wa,  0.0 hi,  0.0 si,  0.0 st")
+		fmt.Fprintf(w, "MiB Mem : %8.1f total, %8.1f free, %8.1f used, %8.1f buff/cache\n",
+			mib(mem.MemTotal), mib(mem.MemFree), mib(mem.MemTotal-mem.MemFree-buffCache), mib(buffCache))
+		fmt.Fprintf(w, "MiB Swap: %8.1f total, %8.1f free, %8.1f used. %8.1f avail Mem\n",
+			mib(mem.SwapTotal), mib(mem.SwapFree), mib(mem.SwapTotal-mem.SwapFree), mib(mem.MemAvailable))
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "    PID USER      PR  NI    VIRT    RES    SHR S  %CPU  %MEM     TIME+ COMMAND")
+
+		for _, process := range processes {
+			state := "S"
+			if process.Stat != "" {
+				state = process.Stat[:1]
+			}
+			if process.PID == virtOS.Getpid() {
+				state = "R"
+			}
+
+			priority, nice := "20", "0"
+			switch {
+			case strings.Contains(process.Stat, "<"):
+				priority, nice = "0", "-20"
+			case strings.Contains(process.Stat, "N"):
+				priority, nice = "39", "19"
+			}
+
+			fmt.Fprintf(w, "%7d %-8s  %2s %3s %7d %6d %6d %s %5.1f %5.1f %9s %s\n",
+				process.PID,
+				psUser(lookupUser(process.UID)),
+				priority,
+				nice,
+				process.VSZ,
+				process.RSS,
+				process.RSS*2/3,
+				state,
+				0.0,
+				memPercent(process.RSS, mem.MemTotal),
+				"0:00.00",
+				process.Name())
+		}
+		return 0
+	})
+}
+
+var _ vos.ProcessFunc = Top
+
+func init() {
+	mustAddBinCmd("top", Top)
+}
@@ -17,11 +17,12 @@ func Uptime(virtOS vos.VOS) int {
 type uptimeable interface {
 	Now() time.Time
 	BootTime() time.Time
+	LoadAverage() [3]float64
 }
 
 func formatUptime(virtOS uptimeable) string {
 	now := virtOS.Now()
-	uptime := virtOS.BootTime().Sub(now)
+	uptime := now.Sub(virtOS.BootTime())
 	day := (24 * time.Hour)
 	uptimeDays := uptime / day
 	uptime -= uptimeDays * day
@@ -29,12 +30,15 @@ func formatUptime(virtOS uptimeable) string {
 	uptime -= uptimeHours * time.Hour
 	uptimeMins := uptime / time.Minute
 
+	load := virtOS.LoadAverage()
+
 	return fmt.Sprintf(
-		"%s up %d days,  %02d:%02d,  1 user,  load average: 0.08, 0.02, 0.01",
+		"%s up %d days,  %02d:%02d,  1 user,  load average: %.2f, %.2f, %.2f",
 		now.Format("15:04:05"),
 		uptimeDays,
 		uptimeHours,
 		uptimeMins,
+		load[0], load[1], load[2],
 	)
 }
 
@@ -2,10 +2,16 @@ package config
 
 import (
 	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/afero"
@@ -24,26 +30,70 @@ const (
 	ConfigurationName = "config.yaml"
 	DownloadDirName   = "downloads"
 	LogsDirName       = "session_logs"
-	PrivateKeyName    = "private_key"
+	PrivateKeyName    = "private_key" // Host key created by older versions.
 	RootFSName        = "root_fs.tar.gz"
 	AppLogName        = "app.log"
+
+	PersistentFilesystemsDirName = "persistent_filesystems"
 )
 
 type Configuration struct {
 	configFs afero.Fs
 
+	// Set on the configurations of named listeners.
+	listenerName string
+	rootFSName   string
+	privateKey   string
+
 	Motd             string `json:"motd"`
 	SSHPort          int    `json:"ssh_port" validate:"gte=0,lte=65535"`
 	SSHBanner        string `json:"ssh_banner"`
 	AllowAnyPassword bool   `json:"allow_any_password"`
 
+	// LastLoginFrom is the address shown as a user's previous login before
+	// they've logged in to the honeypot.
+	LastLoginFrom string `json:"last_login_from" validate:"omitempty,ip"`
+
+	// Telnet configures the optional telnet server.
+	Telnet Telnet `json:"telnet"`
+
+	// ProxyProtocol requires connections to start with a PROXY protocol
+	// header and uses the client address from it.
+	ProxyProtocol bool `json:"proxy_protocol"`
+
+	// SSHProfile is the name of the profile in SSHProfiles the server mimics.
+	SSHProfile  string       `json:"ssh_profile"`
+	SSHProfiles []SSHProfile `json:"ssh_profiles" validate:"unique=Name,dive"`
+
 	GlobalPasswords []string `json:"global_passwords"`
 
+	CredentialPolicy CredentialPolicy `json:"credential_policy"`
+
+	KeyboardInteractivePrompts []KeyboardInteractivePrompt `json:"keyboard_interactive_prompts" validate:"dive"`
+
+	PublicKeyAuth PublicKeyAuth `json:"public_key_auth"`
+
 	OS OS `json:"os"`
 
+	FilesystemLog FilesystemLog `json:"filesystem_log"`
+
+	PersistentFilesystems PersistentFilesystems `json:"persistent_filesystems"`
+
+	PortForward PortForward `json:"port_forward"`
+
+	Limits Limits `json:"limits"`
+
+	// ShutdownDrainPeriod is how long to wait for sessions to end after
+	// announcing a shutdown before closing them.
+	ShutdownDrainPeriod Duration `json:"shutdown_drain_period" validate:"gte=0"`
+
 	Users []User `json:"users" validate:"unique=Username"`
 
 	Uname Uname `json:"uname"`
+
+	Hardware Hardware `json:"hardware"`
+
+	Listeners []Listener `json:"listeners" validate:"unique=Name,dive"`
 }
 
 // Validate the configuration for basic semantic errors.
@@ -54,7 +104,166 @@ func (c *Configuration) Validate() error {
 		return name
 	})
 
-	return validate.Struct(c)
+	if err := validate.Struct(c); err != nil {
+		return err
+	}
+
+	ports := make(map[int]string)
+	for _, listenerConfig := range c.ListenerConfigurations() {
+		name := listenerConfig.ListenerName()
+		listenerPorts := []int{listenerConfig.SSHPort}
+		if listenerConfig.Telnet.Port != 0 {
+			listenerPorts = append(listenerPorts, listenerConfig.Telnet.Port)
+		}
+		for _, port := range listenerPorts {
+			other, ok := ports[port]
+			switch {
+			case ok && other == name:
+				return fmt.Errorf("port %d is used for both SSH and telnet", port)
+			case ok:
+				return fmt.Errorf("listeners %q and %q both use port %d", other, name, port)
+			}
+			ports[port] = name
+		}
+	}
+
+	for _, listenerConfig := range c.ListenerConfigurations() {
+		if listenerConfig.SSHProfile != "" && listenerConfig.Profile() == nil {
+			return fmt.Errorf("unknown ssh_profile %q", listenerConfig.SSHProfile)
+		}
+		if !listenerConfig.HasAuthMethod() {
+			// The SSH server lets everyone in if it has no auth methods.
+			return fmt.Errorf("ssh_profile %q leaves no usable auth methods", listenerConfig.SSHProfile)
+		}
+	}
+	return nil
+}
+
+// SSHProfile mimics the identification and algorithm preferences of a
+// specific SSH server build. Empty lists use the library defaults.
+type SSHProfile struct {
+	Name string `json:"name" validate:"required"`
+	// Version is the software version sent after "SSH-2.0-".
+	Version           string   `json:"version" validate:"required"`
+	KeyExchanges      []string `json:"kex_algorithms"`
+	HostKeyAlgorithms []string `json:"host_key_algorithms"`
+	Ciphers           []string `json:"ciphers"`
+	MACs              []string `json:"macs"`
+	// AuthMethods advertised to the client, all supported methods are
+	// advertised if empty.
+	AuthMethods []string `json:"auth_methods" validate:"dive,oneof=publickey password keyboard-interactive"`
+}
+
+// AllowsAuth returns whether the authentication method is advertised.
+func (p *SSHProfile) AllowsAuth(method string) bool {
+	if len(p.AuthMethods) == 0 {
+		return true
+	}
+	for _, allowed := range p.AuthMethods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesAuth returns whether the SSH server offers the authentication method.
+// It must be advertised by the profile, and keyboard-interactive also needs
+// prompts to ask.
+func (c *Configuration) UsesAuth(method string) bool {
+	if profile := c.Profile(); profile != nil && !profile.AllowsAuth(method) {
+		return false
+	}
+	if method == "keyboard-interactive" {
+		return len(c.KeyboardInteractivePrompts) > 0
+	}
+	return true
+}
+
+// HasAuthMethod returns whether the SSH server offers any authentication
+// method.
+func (c *Configuration) HasAuthMethod() bool {
+	for _, method := range []string{"publickey", "password", "keyboard-interactive"} {
+		if c.UsesAuth(method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Profile returns the selected SSH profile or nil if none is selected.
+func (c *Configuration) Profile() *SSHProfile {
+	for i, profile := range c.SSHProfiles {
+		if profile.Name == c.SSHProfile {
+			return &c.SSHProfiles[i]
+		}
+	}
+	return nil
+}
+
+// Listener is an SSH server with its own personality. Unset fields are
+// inherited from the top level configuration.
+type Listener struct {
+	// Name is added to every log entry from the listener.
+	Name       string  `json:"name" validate:"required"`
+	SSHPort    int     `json:"ssh_port" validate:"gte=0,lte=65535"`
+	SSHBanner  *string `json:"ssh_banner"`
+	SSHProfile string  `json:"ssh_profile"`
+	Motd       *string `json:"motd"`
+	Uname      *Uname  `json:"uname"`
+	Users      []User  `json:"users" validate:"unique=Username,dive"`
+	// RootFS is the name of the root filesystem archive in the config
+	// directory.
+	RootFS string `json:"root_fs"`
+	// PrivateKey is the name of the host key in the config directory, it's
+	// used instead of the ssh_host_*_key files.
+	PrivateKey string `json:"private_key"`
+	// TelnetPort is the port the listener accepts telnet connections on, 0
+	// to disable telnet.
+	TelnetPort int `json:"telnet_port" validate:"gte=0,lte=65535"`
+}
+
+// ListenerConfigurations returns the configuration of each listener with its
+// overrides applied. If there are no listeners the top level configuration is
+// returned as the only, unnamed, listener.
+func (c *Configuration) ListenerConfigurations() []*Configuration {
+	if len(c.Listeners) == 0 {
+		return []*Configuration{c}
+	}
+
+	var out []*Configuration
+	for _, listener := range c.Listeners {
+		derived := *c
+		derived.Listeners = nil
+		derived.listenerName = listener.Name
+		derived.rootFSName = listener.RootFS
+		derived.privateKey = listener.PrivateKey
+		derived.SSHPort = listener.SSHPort
+		derived.Telnet.Port = listener.TelnetPort
+		if listener.SSHBanner != nil {
+			derived.SSHBanner = *listener.SSHBanner
+		}
+		if listener.Motd != nil {
+			derived.Motd = *listener.Motd
+		}
+		if listener.Uname != nil {
+			derived.Uname = *listener.Uname
+		}
+		if listener.Users != nil {
+			derived.Users = listener.Users
+		}
+		if listener.SSHProfile != "" {
+			derived.SSHProfile = listener.SSHProfile
+		}
+		out = append(out, &derived)
+	}
+	return out
+}
+
+// ListenerName returns the name of the listener the configuration is for, or
+// blank for the top level configuration.
+func (c *Configuration) ListenerName() string {
+	return c.listenerName
 }
 
 type User struct {
@@ -64,11 +273,267 @@ type User struct {
 	Home      string   `json:"home" validate:"required"`
 	Shell     string   `json:"shell" validate:"required"`
 	Passwords []string `json:"passwords" validate:"unique"`
+	// AuthorizedKeys are public keys in authorized_keys format that allow the
+	// user to log in.
+	AuthorizedKeys []string `json:"authorized_keys"`
+}
+
+// Telnet configures the telnet server. Telnet users log in with the same
+// credentials as SSH users and get the same shell.
+type Telnet struct {
+	// Port to accept telnet connections on, 0 to disable telnet.
+	Port int `json:"port" validate:"gte=0,lte=65535"`
+	// Banner is shown before the login prompt like /etc/issue.net.
+	Banner string `json:"banner"`
+}
+
+// PublicKeyAuth configures which public keys are accepted in addition to the
+// ones in the users' config.
+type PublicKeyAuth struct {
+	// AuthorizedKeysFile is read from the virtual filesystem relative to the
+	// user's home directory, like sshd's AuthorizedKeysFile. Blank to disable.
+	AuthorizedKeysFile string `json:"authorized_keys_file"`
+	// RememberAddedKeys accepts keys added to the AuthorizedKeysFile during a
+	// session on later connections.
+	RememberAddedKeys bool `json:"remember_added_keys"`
+}
+
+// Credential policy modes.
+const (
+	CredentialPolicyStatic     = "static"
+	CredentialPolicyNthAttempt = "nth_attempt"
+	CredentialPolicyDictionary = "dictionary"
+)
+
+// CredentialPolicy decides which passwords are accepted in addition to the
+// configured ones.
+type CredentialPolicy struct {
+	// Mode is static to only accept configured passwords, nth_attempt to accept
+	// the NthAttempt password tried from an IP or dictionary to accept passwords
+	// in the Dictionary. Blank is the same as static.
+	Mode       string             `json:"mode" validate:"omitempty,oneof=static nth_attempt dictionary"`
+	NthAttempt int                `json:"nth_attempt" validate:"required_if=Mode nth_attempt,gte=0"`
+	Dictionary []WeightedPassword `json:"dictionary" validate:"unique=Password,dive"`
+	// ConsistentPerIP remembers the first password accepted for a user from an
+	// IP and rejects other passwords for that user from the IP.
+	ConsistentPerIP bool `json:"consistent_per_ip"`
+	// Retention is how long the attempts and passwords of an IP are kept after
+	// its last attempt, 0 to keep them forever.
+	Retention Duration `json:"retention" validate:"gte=0"`
+	// MaxIPs is the most IPs state is kept for, the least recently seen are
+	// forgotten first. 0 for no limit.
+	MaxIPs int `json:"max_ips" validate:"gte=0"`
+}
+
+// Expired returns whether the state of an IP last seen at lastSeen should no
+// longer be kept.
+func (p *CredentialPolicy) Expired(lastSeen, now time.Time) bool {
+	return p.Retention > 0 && now.Sub(lastSeen) > time.Duration(p.Retention)
+}
+
+// WeightedPassword is a password in the credential policy dictionary.
+type WeightedPassword struct {
+	Password string `json:"password" validate:"required"`
+	// Weight is the probability between 0 and 1 that an attempt with the
+	// password is accepted.
+	Weight float64 `json:"weight" validate:"gte=0,lte=1"`
+}
+
+// KeyboardInteractivePrompt is a single question asked during
+// keyboard-interactive authentication.
+type KeyboardInteractivePrompt struct {
+	Prompt string `json:"prompt" validate:"required"`
+	Echo   bool   `json:"echo"`
 }
 
 type OS struct {
 	DefaultShell string `json:"default_shell" validate:"required"`
 	DefaultPath  string `json:"default_path" validate:"required"`
+	// SFTPServer is the program run for the SFTP subsystem, it's only blank
+	// in configurations from before it was configurable.
+	SFTPServer string `json:"sftp_server"`
+	// Processes are the system's daemons and kernel threads, every session
+	// sees them alongside its own processes.
+	Processes []Process `json:"processes" validate:"unique=PID,dive"`
+}
+
+// defaultSFTPServer is used by configurations from before the SFTP server was
+// configurable.
+const defaultSFTPServer = "/usr/lib/openssh/sftp-server"
+
+// SFTPServerPath returns the program run for the SFTP subsystem.
+func (o *OS) SFTPServerPath() string {
+	if o.SFTPServer == "" {
+		return defaultSFTPServer
+	}
+	return o.SFTPServer
+}
+
+// Process is a system process that's running when a session starts.
+type Process struct {
+	PID  int `json:"pid" validate:"gte=1"`
+	PPID int `json:"ppid" validate:"gte=0"`
+	UID  int `json:"uid" validate:"gte=0"`
+	// TTY is the controlling terminal e.g. "tty1", blank if there's none.
+	TTY string `json:"tty"`
+	// Stat is the state shown by ps e.g. "Ss".
+	Stat string `json:"stat" validate:"required"`
+	// VSZ and RSS are the virtual and resident memory sizes in KiB.
+	VSZ int64 `json:"vsz" validate:"gte=0"`
+	RSS int64 `json:"rss" validate:"gte=0"`
+	// Command is the command line, kernel threads are named in brackets.
+	Command string `json:"command" validate:"required"`
+}
+
+// FilesystemLog configures the logging of files opened and changed by
+// processes in a session.
+type FilesystemLog struct {
+	// SkipPaths are files and directories that aren't logged e.g. /proc.
+	SkipPaths []string `json:"skip_paths" validate:"dive,startswith=/"`
+}
+
+// Skip returns whether operations on the absolute path aren't logged.
+func (f *FilesystemLog) Skip(name string) bool {
+	for _, skip := range f.SkipPaths {
+		skip = strings.TrimSuffix(skip, "/")
+		if name == skip || strings.HasPrefix(name, skip+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Persistent filesystem keys.
+const (
+	PersistentFilesystemKeyIP              = "ip"
+	PersistentFilesystemKeyCredential      = "credential"
+	PersistentFilesystemKeyIPAndCredential = "ip_and_credential"
+)
+
+// PersistentFilesystems keeps the files attackers change between sessions.
+type PersistentFilesystems struct {
+	// Key identifies an attacker by their ip, credential (username and password
+	// or key) or ip_and_credential. Blank disables persistent filesystems.
+	Key string `json:"key" validate:"omitempty,oneof=ip credential ip_and_credential"`
+	// Retention is how long a filesystem is kept after its last session, 0 to
+	// keep them forever.
+	Retention Duration `json:"retention" validate:"gte=0"`
+	// MaxSizeBytes is the largest total size of the files in a filesystem that
+	// will be saved, 0 for no limit. Filesystems that are too large aren't
+	// saved.
+	MaxSizeBytes int64 `json:"max_size_bytes" validate:"gte=0"`
+	// MaxFilesystems is the most filesystems kept, the least recently used are
+	// removed first. 0 for no limit.
+	MaxFilesystems int `json:"max_filesystems" validate:"gte=0"`
+}
+
+// Expired returns whether a filesystem last used at lastUsed should no longer
+// be kept.
+func (p *PersistentFilesystems) Expired(lastUsed, now time.Time) bool {
+	return p.Retention > 0 && now.Sub(lastUsed) > time.Duration(p.Retention)
+}
+
+// PortForward configures how port forwarding requests are answered.
+type PortForward struct {
+	// CaptureBytes is the maximum number of bytes logged from each channel.
+	CaptureBytes int `json:"capture_bytes" validate:"gte=0"`
+	// Responders hold canned responses for forwarded connections.
+	Responders []PortForwardResponder `json:"responders" validate:"dive"`
+	// IdleTimeout is how long a forwarded channel may go without data before
+	// it's closed, it's only blank in configurations from before it was
+	// configurable.
+	IdleTimeout Duration `json:"idle_timeout" validate:"gte=0"`
+}
+
+// defaultPortForwardIdleTimeout is used by configurations from before the
+// forwarded channel idle timeout was configurable.
+const defaultPortForwardIdleTimeout = time.Minute
+
+// ChannelIdleTimeout returns how long a forwarded channel may go without data.
+func (p *PortForward) ChannelIdleTimeout() time.Duration {
+	if p.IdleTimeout == 0 {
+		return defaultPortForwardIdleTimeout
+	}
+	return time.Duration(p.IdleTimeout)
+}
+
+// PortForwardResponder fakes a service on the given ports.
+type PortForwardResponder struct {
+	Ports []uint32 `json:"ports" validate:"required"`
+	// Banner is sent as soon as the channel opens e.g. an SMTP greeting.
+	Banner string `json:"banner"`
+	// Response is sent after the client's first data, then the channel is
+	// closed e.g. an HTTP response.
+	Response string `json:"response"`
+}
+
+// Responder returns the responder for the port or nil if none is set.
+func (p *PortForward) Responder(port uint32) *PortForwardResponder {
+	for i, responder := range p.Responders {
+		for _, responderPort := range responder.Ports {
+			if responderPort == port {
+				return &p.Responders[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Limits protect the honeypot from noisy clients, zero values are unlimited.
+type Limits struct {
+	// MaxConnections is the maximum number of concurrent connections.
+	MaxConnections int `json:"max_connections" validate:"gte=0"`
+	// MaxConnectionsPerIP is the maximum number of concurrent connections from
+	// a single IP address.
+	MaxConnectionsPerIP int `json:"max_connections_per_ip" validate:"gte=0"`
+	// AuthAttemptsPerMinute is the maximum number of password attempts per
+	// minute from a single IP address.
+	AuthAttemptsPerMinute int `json:"auth_attempts_per_minute" validate:"gte=0"`
+	// FailedLoginDelay is how long to wait before rejecting a password.
+	FailedLoginDelay Duration `json:"failed_login_delay" validate:"gte=0"`
+	// IdleTimeout is how long a session may go without input before it's
+	// logged out.
+	IdleTimeout Duration `json:"idle_timeout" validate:"gte=0"`
+	// MaxSessionDuration is the maximum lifetime of a session.
+	MaxSessionDuration Duration `json:"max_session_duration" validate:"gte=0"`
+	// MaxFilesystemBytes is the total size of the files a session may create
+	// or modify, writes past it fail with ENOSPC.
+	MaxFilesystemBytes int64 `json:"max_filesystem_bytes" validate:"gte=0"`
+}
+
+// defaultMaxFilesystemBytes is used by configurations from before the
+// filesystem size was configurable.
+const defaultMaxFilesystemBytes = 256 << 20
+
+// FilesystemSizeLimit returns the total size of the files a session may
+// create or modify.
+func (l *Limits) FilesystemSizeLimit() int64 {
+	if l.MaxFilesystemBytes == 0 {
+		return defaultMaxFilesystemBytes
+	}
+	return l.MaxFilesystemBytes
+}
+
+// Duration is a time.Duration that's configured as a string e.g. "1m30s".
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
 }
 
 type Uname struct {
@@ -80,6 +545,28 @@ type Uname struct {
 	Domainname       string `json:"domainname" validate:""`                        // NIS or YP domain name.
 }
 
+// Hardware describes the machine shown by lscpu, ifconfig, ip, /proc and /sys.
+type Hardware struct {
+	// CPUs is the number of processors, it's only 0 in configurations from
+	// before the hardware was configurable.
+	CPUs int `json:"cpus" validate:"gte=0"`
+	// SysVendor and ProductName are the DMI vendor and product e.g. "Google"
+	// and "Google Compute Engine".
+	SysVendor   string `json:"sys_vendor"`
+	ProductName string `json:"product_name"`
+	// Hypervisor is the hypervisor vendor e.g. "KVM", blank for bare metal.
+	Hypervisor string `json:"hypervisor"`
+	// NetworkInterfaces are the interfaces other than loopback.
+	NetworkInterfaces []NetworkInterface `json:"network_interfaces" validate:"unique=Name,dive"`
+}
+
+// NetworkInterface is an Ethernet interface.
+type NetworkInterface struct {
+	Name string `json:"name" validate:"required"`
+	MAC  string `json:"mac" validate:"required,mac"`
+	IP   string `json:"ip" validate:"required,ipv4"`
+}
+
 func (c *Configuration) fs() afero.Fs {
 	return c.configFs
 }
@@ -95,9 +582,40 @@ func (c *Configuration) CreateSessionLog(name string) (afero.File, error) {
 	return c.fs().Create(toCreate)
 }
 
-// PrivateKeyPem returns the bytes of the private key.
-func (c *Configuration) PrivateKeyPem() ([]byte, error) {
-	return afero.ReadFile(c.fs(), PrivateKeyName)
+// OpenPersistentFilesystem opens a saved filesystem.
+func (c *Configuration) OpenPersistentFilesystem(name string) (afero.File, error) {
+	return c.fs().Open(filepath.Join(PersistentFilesystemsDirName, name))
+}
+
+// WritePersistentFilesystem saves a filesystem, replacing any existing one
+// with the same name once write returns.
+func (c *Configuration) WritePersistentFilesystem(name string, write func(io.Writer) error) error {
+	if err := c.fs().MkdirAll(PersistentFilesystemsDirName, 0700); err != nil {
+		return err
+	}
+
+	// Each save gets its own temporary file so concurrent saves can't
+	// corrupt each other.
+	fd, err := afero.TempFile(c.fs(), PersistentFilesystemsDirName, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := fd.Name()
+	if err := write(fd); err != nil {
+		fd.Close()
+		c.fs().Remove(tmpName)
+		return err
+	}
+	if err := fd.Close(); err != nil {
+		c.fs().Remove(tmpName)
+		return err
+	}
+	return c.fs().Rename(tmpName, filepath.Join(PersistentFilesystemsDirName, name))
+}
+
+// RemovePersistentFilesystem deletes a saved filesystem.
+func (c *Configuration) RemovePersistentFilesystem(name string) error {
+	return c.fs().Remove(filepath.Join(PersistentFilesystemsDirName, name))
 }
 
 // OpenAppLog opens the application log in an append only state.
@@ -111,6 +629,9 @@ func (c *Configuration) ReadAppLog() (afero.File, error) {
 
 // OpenFilesystemTarGz opens the backing filesystem .tar.gz file.
 func (c *Configuration) OpenFilesystemTarGz() (afero.File, error) {
+	if c.rootFSName != "" {
+		return c.fs().Open(c.rootFSName)
+	}
 	return c.fs().Open(RootFSName)
 }
 
@@ -127,6 +648,45 @@ func (c *Configuration) GetPasswords(username string) []string {
 	return out
 }
 
+// GetAuthorizedKeys returns the authorized_keys lines configured for the user.
+func (c *Configuration) GetAuthorizedKeys(username string) []string {
+	var out []string
+	for _, v := range c.Users {
+		if v.Username == username {
+			out = append(out, v.AuthorizedKeys...)
+		}
+	}
+	return out
+}
+
+// ReadState loads JSON state saved by WriteState into v, v is unchanged if
+// nothing was saved.
+func (c *Configuration) ReadState(name string, v interface{}) error {
+	data, err := afero.ReadFile(c.fs(), name)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteState saves v as JSON in the config directory, replacing any existing
+// state with the same name.
+func (c *Configuration) WriteState(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpName := name + ".tmp"
+	if err := afero.WriteFile(c.fs(), tmpName, data, 0600); err != nil {
+		return err
+	}
+	return c.fs().Rename(tmpName, name)
+}
+
 func defaultConfig() *Configuration {
 	var out Configuration
 	if err := yaml.UnmarshalStrict(defaultConfigData, &out); err != nil {
@@ -3,10 +3,13 @@ package config
 import (
 	"bytes"
 	"compress/gzip"
+	"io"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v2"
 )
@@ -57,3 +60,184 @@ func TestFs(t *testing.T) {
 	_, gzipErr := gzip.NewReader(fsReader)
 	assert.Nil(t, gzipErr, "not a valid gzip")
 }
+
+func TestPortForward_Responder(t *testing.T) {
+	pf := &PortForward{
+		Responders: []PortForwardResponder{
+			{Ports: []uint32{80, 8080}, Response: "http"},
+			{Ports: []uint32{25}, Banner: "smtp"},
+		},
+	}
+
+	assert.Equal(t, "http", pf.Responder(8080).Response)
+	assert.Equal(t, "smtp", pf.Responder(25).Banner)
+	assert.Nil(t, pf.Responder(22))
+}
+
+func TestConfiguration_ListenerConfigurations(t *testing.T) {
+	dc := defaultConfig()
+	assert.Equal(t, []*Configuration{dc}, dc.ListenerConfigurations())
+
+	banner := ""
+	dc.SSHBanner = "top level banner"
+	dc.Listeners = []Listener{
+		{Name: "ubuntu", SSHPort: 22},
+		{
+			Name:       "router",
+			SSHPort:    2222,
+			SSHBanner:  &banner,
+			Uname:      &Uname{Nodename: "router"},
+			Users:      []User{{Username: "admin"}},
+			RootFS:     "busybox.tar.gz",
+			TelnetPort: 23,
+		},
+	}
+
+	configs := dc.ListenerConfigurations()
+	assert.Len(t, configs, 2)
+
+	ubuntu := configs[0]
+	assert.Equal(t, "ubuntu", ubuntu.ListenerName())
+	assert.Equal(t, 22, ubuntu.SSHPort)
+	assert.Equal(t, "top level banner", ubuntu.SSHBanner)
+	assert.Equal(t, dc.Users, ubuntu.Users)
+	assert.Nil(t, ubuntu.Listeners)
+
+	router := configs[1]
+	assert.Equal(t, "router", router.ListenerName())
+	assert.Equal(t, "", router.SSHBanner)
+	assert.Equal(t, "router", router.Uname.Nodename)
+	assert.Equal(t, "admin", router.Users[0].Username)
+	assert.Equal(t, "busybox.tar.gz", router.rootFSName)
+	assert.Equal(t, 23, router.Telnet.Port)
+	assert.Equal(t, 0, ubuntu.Telnet.Port)
+}
+
+func TestConfiguration_Validate_listenerPorts(t *testing.T) {
+	dc := defaultConfig()
+	dc.Listeners = []Listener{
+		{Name: "a", SSHPort: 22},
+		{Name: "b", SSHPort: 22},
+	}
+	assert.NotNil(t, dc.Validate())
+
+	dc.Listeners = []Listener{
+		{Name: "a", SSHPort: 22, TelnetPort: 23},
+		{Name: "b", SSHPort: 2222},
+	}
+	assert.Nil(t, dc.Validate(), "telnet is disabled on b")
+
+	dc.Listeners[1].TelnetPort = 23
+	assert.NotNil(t, dc.Validate(), "telnet ports conflict")
+
+	dc.Listeners = nil
+	dc.Telnet.Port = dc.SSHPort
+	assert.NotNil(t, dc.Validate(), "SSH and telnet share a port")
+}
+
+func TestConfiguration_Profile(t *testing.T) {
+	dc := defaultConfig()
+	for _, profile := range dc.SSHProfiles {
+		dc.SSHProfile = profile.Name
+		assert.Equal(t, profile.Name, dc.Profile().Name)
+		assert.Nil(t, dc.Validate())
+	}
+
+	dc.SSHProfile = ""
+	assert.Nil(t, dc.Profile())
+
+	dc.SSHProfile = "missing"
+	assert.NotNil(t, dc.Validate())
+
+	dc.SSHProfile = ""
+	dc.Listeners = []Listener{{Name: "router", SSHPort: 22, SSHProfile: "missing"}}
+	assert.NotNil(t, dc.Validate())
+}
+
+func TestConfiguration_Validate_authMethods(t *testing.T) {
+	dc := defaultConfig()
+	dc.SSHProfiles = append(dc.SSHProfiles, SSHProfile{
+		Name:        "kbd-only",
+		Version:     "OpenSSH_7.6p1",
+		AuthMethods: []string{"keyboard-interactive"},
+	})
+	dc.SSHProfile = "kbd-only"
+	assert.Nil(t, dc.Validate())
+	assert.False(t, dc.UsesAuth("password"))
+	assert.True(t, dc.UsesAuth("keyboard-interactive"))
+
+	dc.KeyboardInteractivePrompts = nil
+	assert.False(t, dc.HasAuthMethod())
+	assert.NotNil(t, dc.Validate(), "keyboard-interactive without prompts")
+}
+
+func TestConfiguration_Validate_credentialPolicy(t *testing.T) {
+	dc := defaultConfig()
+	dc.CredentialPolicy = CredentialPolicy{}
+	assert.Nil(t, dc.Validate(), "missing policy is static")
+
+	dc.CredentialPolicy = CredentialPolicy{Mode: CredentialPolicyNthAttempt}
+	assert.NotNil(t, dc.Validate(), "nth_attempt requires nth_attempt")
+
+	dc.CredentialPolicy = CredentialPolicy{Mode: "unknown"}
+	assert.NotNil(t, dc.Validate())
+
+	dc.CredentialPolicy = CredentialPolicy{Dictionary: []WeightedPassword{{Password: "root", Weight: 2}}}
+	assert.NotNil(t, dc.Validate(), "weight above 1")
+
+	dc.CredentialPolicy = CredentialPolicy{MaxIPs: -1}
+	assert.NotNil(t, dc.Validate())
+}
+
+func TestFilesystemLog_Skip(t *testing.T) {
+	fl := FilesystemLog{SkipPaths: []string{"/proc", "/var/log/"}}
+
+	assert.True(t, fl.Skip("/proc"))
+	assert.True(t, fl.Skip("/proc/self/cmdline"))
+	assert.True(t, fl.Skip("/var/log/auth.log"))
+	assert.False(t, fl.Skip("/processes"))
+	assert.False(t, fl.Skip("/var"))
+}
+
+func TestLoad_baselineConfig(t *testing.T) {
+	// Configurations written before newer sections existed must keep loading.
+	cfg, err := Load(filepath.Join("testdata", "baseline"))
+	assert.Nil(t, err)
+	if assert.NotNil(t, cfg) {
+		assert.Equal(t, "/usr/lib/openssh/sftp-server", cfg.OS.SFTPServerPath())
+	}
+}
+
+func TestConfiguration_WritePersistentFilesystem_concurrent(t *testing.T) {
+	cfg := &Configuration{configFs: afero.NewMemMapFs()}
+
+	// The first save is still writing when the second finishes.
+	writing := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- cfg.WritePersistentFilesystem("a.tar.gz", func(w io.Writer) error {
+			io.WriteString(w, "first ")
+			close(writing)
+			<-done
+			_, err := io.WriteString(w, "save")
+			return err
+		})
+	}()
+	<-writing
+	done <- cfg.WritePersistentFilesystem("a.tar.gz", func(w io.Writer) error {
+		_, err := io.WriteString(w, "second save")
+		return err
+	})
+	assert.Nil(t, <-done)
+
+	fd, err := cfg.OpenPersistentFilesystem("a.tar.gz")
+	assert.Nil(t, err)
+	defer fd.Close()
+	contents, err := io.ReadAll(fd)
+	assert.Nil(t, err)
+	assert.Equal(t, "first save", string(contents), "saves don't share a temporary file")
+
+	names, err := afero.Glob(cfg.configFs, filepath.Join(PersistentFilesystemsDirName, "*"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(PersistentFilesystemsDirName, "a.tar.gz")}, names)
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/afero"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	// hostKeyPattern matches host key file names in the config directory.
+	hostKeyPattern = "ssh_host_*_key"
+
+	rsaHostKeyBits = 3072
+)
+
+// HostKeyTypes are the types of host keys generated by init, matching the
+// keys a default OpenSSH install has.
+var HostKeyTypes = []string{"ecdsa", "ed25519", "rsa"}
+
+// HostKeyName returns the file name of the host key with the given type.
+func HostKeyName(keyType string) string {
+	return fmt.Sprintf("ssh_host_%s_key", keyType)
+}
+
+// HostKey is a private key the SSH server identifies itself with.
+type HostKey struct {
+	// Name of the key file in the config directory.
+	Name   string
+	Signer gossh.Signer
+}
+
+// HostKeys loads every ssh_host_*_key in the config directory, falling back
+// to the private_key created by older versions if there are none. Listeners
+// with their own private key only use that key.
+func (c *Configuration) HostKeys() ([]HostKey, error) {
+	var names []string
+	switch {
+	case c.privateKey != "":
+		names = []string{c.privateKey}
+	default:
+		matches, err := afero.Glob(c.fs(), hostKeyPattern)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		names = matches
+
+		if len(names) == 0 {
+			if _, err := c.fs().Stat(PrivateKeyName); err == nil {
+				names = []string{PrivateKeyName}
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, errors.New("no host keys found, create them with `honeyssh keys rotate`")
+	}
+
+	var out []HostKey
+	for _, name := range names {
+		pemBytes, err := afero.ReadFile(c.fs(), name)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := gossh.ParsePrivateKey(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse host key %q: %v", name, err)
+		}
+		out = append(out, HostKey{Name: name, Signer: signer})
+	}
+	return out, nil
+}
+
+// RotateHostKey generates a new host key of the given type, replacing the
+// existing one.
+func (c *Configuration) RotateHostKey(keyType string) (*HostKey, error) {
+	return writeHostKey(c.fs(), keyType)
+}
+
+func writeHostKey(fs afero.Fs, keyType string) (*HostKey, error) {
+	key, err := generateHostKey(keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := gossh.MarshalPrivateKey(key, "")
+	if err != nil {
+		return nil, err
+	}
+	signer, err := gossh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	name := HostKeyName(keyType)
+	if err := afero.WriteFile(fs, name, pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, err
+	}
+	if err := afero.WriteFile(fs, name+".pub", gossh.MarshalAuthorizedKey(signer.PublicKey()), 0644); err != nil {
+		return nil, err
+	}
+
+	return &HostKey{Name: name, Signer: signer}, nil
+}
+
+func generateHostKey(keyType string) (crypto.PrivateKey, error) {
+	switch keyType {
+	case "ecdsa":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ed25519":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case "rsa":
+		return rsa.GenerateKey(rand.Reader, rsaHostKeyBits)
+	default:
+		return nil, fmt.Errorf("unknown host key type %q, expected one of %v", keyType, HostKeyTypes)
+	}
+}
+
+// HostKeyBits returns the size of the key in bits.
+func HostKeyBits(key gossh.PublicKey) int {
+	cryptoKey, ok := key.(gossh.CryptoPublicKey)
+	if !ok {
+		return 0
+	}
+
+	switch k := cryptoKey.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return 256
+	default:
+		return 0
+	}
+}
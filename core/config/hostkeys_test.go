@@ -0,0 +1,51 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestConfiguration_HostKeys(t *testing.T) {
+	cfg := &Configuration{configFs: afero.NewMemMapFs()}
+
+	_, err := cfg.HostKeys()
+	assert.NotNil(t, err, "expected error without keys")
+
+	// Keys from older versions are used if there are no others.
+	_, legacyKey, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	block, err := gossh.MarshalPrivateKey(legacyKey, "")
+	require.Nil(t, err)
+	require.Nil(t, afero.WriteFile(cfg.fs(), PrivateKeyName, pem.EncodeToMemory(block), 0600))
+
+	hostKeys, err := cfg.HostKeys()
+	require.Nil(t, err)
+	require.Len(t, hostKeys, 1)
+	assert.Equal(t, PrivateKeyName, hostKeys[0].Name)
+
+	// Rotating replaces the key and takes precedence over the old one.
+	first, err := cfg.RotateHostKey("ed25519")
+	require.Nil(t, err)
+	second, err := cfg.RotateHostKey("ed25519")
+	require.Nil(t, err)
+	assert.NotEqual(t,
+		gossh.FingerprintSHA256(first.Signer.PublicKey()),
+		gossh.FingerprintSHA256(second.Signer.PublicKey()))
+
+	hostKeys, err = cfg.HostKeys()
+	require.Nil(t, err)
+	require.Len(t, hostKeys, 1)
+	assert.Equal(t, "ssh_host_ed25519_key", hostKeys[0].Name)
+	assert.Equal(t, second.Signer.PublicKey().Marshal(), hostKeys[0].Signer.PublicKey().Marshal())
+	assert.Equal(t, 256, HostKeyBits(hostKeys[0].Signer.PublicKey()))
+
+	_, err = cfg.RotateHostKey("dsa")
+	assert.NotNil(t, err)
+}
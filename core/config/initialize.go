@@ -1,17 +1,11 @@
 package config
 
 import (
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
 	"errors"
 	"fmt"
 	"log"
-	"math/rand"
 	"os"
 	"path/filepath"
-	"sync"
-	"time"
 
 	"github.com/spf13/afero"
 )
@@ -37,12 +31,6 @@ func Initialize(path string, logger *log.Logger) (*Configuration, error) {
 	cfg := defaultConfig()
 	cfg.configFs = afero.NewBasePathFs(afero.NewOsFs(), full)
 
-	logger.Println("Generating private key...")
-	privateKey, err := generateRSAKey(logger)
-	if err != nil {
-		return nil, err
-	}
-
 	logger.Println("Creating configuration files...")
 	exists := func(path string) bool {
 		// This is hacky, but good enough.
@@ -55,7 +43,6 @@ func Initialize(path string, logger *log.Logger) (*Configuration, error) {
 		contents []byte
 	}{
 		{ConfigurationName, defaultConfigData},
-		{PrivateKeyName, privateKey},
 		{RootFSName, rootFsData},
 	}
 	for _, configFile := range configFiles {
@@ -69,6 +56,20 @@ func Initialize(path string, logger *log.Logger) (*Configuration, error) {
 		}
 	}
 
+	logger.Println("Generating host keys...")
+	for _, keyType := range HostKeyTypes {
+		name := HostKeyName(keyType)
+		if exists(name) {
+			logger.Println("  ", name, "(skipped, it already exists)")
+			continue
+		}
+
+		logger.Println("  ", name)
+		if _, err := writeHostKey(cfg.fs(), keyType); err != nil {
+			return nil, fmt.Errorf("couldn't create host key %q: %v", name, err)
+		}
+	}
+
 	// Make directories.
 	logger.Println("Making directories...")
 	for _, dir := range []string{
@@ -83,34 +84,3 @@ func Initialize(path string, logger *log.Logger) (*Configuration, error) {
 
 	return cfg, nil
 }
-
-func generateRSAKey(logger *log.Logger) ([]byte, error) {
-	logger.Println(
-		"WARNING: The generated RSA key SHOULD NOT be used for " +
-			"real security, it wasn't generated by a cryptographically secure RNG.")
-
-	key, err := rsa.GenerateKey(&insecureRandomReader{}, 2048)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't create private key: %v", err)
-	}
-
-	pemBlock := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(key),
-	})
-
-	return pemBlock, nil
-}
-
-type insecureRandomReader struct {
-	once sync.Once
-	rand *rand.Rand
-}
-
-func (irr *insecureRandomReader) Read(bytes []byte) (int, error) {
-	irr.once.Do(func() {
-		irr.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
-	})
-
-	return irr.rand.Read(bytes)
-}
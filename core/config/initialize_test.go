@@ -44,9 +44,14 @@ func TestInitialize(t *testing.T) {
 		fd.Close()
 	})
 
-	t.Run("PrivateKeyPem", func(t *testing.T) {
-		keyPem, err := cfg.PrivateKeyPem()
+	t.Run("HostKeys", func(t *testing.T) {
+		hostKeys, err := cfg.HostKeys()
 		assert.Nil(t, err)
-		assert.NotNil(t, keyPem)
+
+		var keyTypes []string
+		for _, hostKey := range hostKeys {
+			keyTypes = append(keyTypes, hostKey.Signer.PublicKey().Type())
+		}
+		assert.Equal(t, []string{"ecdsa-sha2-nistp256", "ssh-ed25519", "ssh-rsa"}, keyTypes)
 	})
 }
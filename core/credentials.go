@@ -0,0 +1,234 @@
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/josephlewis42/honeyssh/core/config"
+)
+
+// credentialPolicyName is the state file credential policy state is saved to.
+const credentialPolicyName = "credential_policy.json"
+
+// credentialPolicySaveDelay is how long changes to the credential policy state
+// are batched before they're written to the state file.
+const credentialPolicySaveDelay = 5 * time.Second
+
+// credentialPolicySweepInterval is how often expired IPs are swept from the
+// credential policy state.
+const credentialPolicySweepInterval = time.Minute
+
+// credentialPolicyState is the part of the credential policy that survives
+// restarts.
+type credentialPolicyState struct {
+	// IPs holds the state of each IP that tried a password.
+	IPs map[string]*credentialPolicyIP `json:"ips,omitempty"`
+}
+
+// credentialPolicyIP is the credential policy state of a single IP.
+type credentialPolicyIP struct {
+	// Attempts holds the number of passwords tried, up to the configured nth
+	// attempt.
+	Attempts int `json:"attempts,omitempty"`
+	// Passwords holds the first password accepted per username.
+	Passwords map[string]string `json:"passwords,omitempty"`
+	// LastSeen is the time of the IP's last attempt.
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// credentialPolicy decides whether passwords outside the configured ones are
+// accepted.
+type credentialPolicy struct {
+	mu    sync.Mutex
+	state credentialPolicyState
+	// randFloat returns a number in [0, 1), rand.Float64 if nil.
+	randFloat func() float64
+
+	// saveTimer writes the state once the save delay passes, it's nil if there
+	// are no unsaved changes.
+	saveTimer         *time.Timer
+	saveConfiguration *config.Configuration
+	// lastSweep is when expired IPs were last swept from the state.
+	lastSweep time.Time
+}
+
+// allow returns whether the password should be accepted from the IP given
+// whether it matched the configured passwords, and whether the state changed.
+func (p *credentialPolicy) allow(policy config.CredentialPolicy, ip, username, password string, configured bool, now time.Time) (allowed, changed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed = p.sweepLocked(policy, now)
+	entry := p.state.IPs[ip]
+	if entry != nil && policy.Expired(entry.LastSeen, now) {
+		delete(p.state.IPs, ip)
+		entry = nil
+		changed = true
+	}
+	isNew := entry == nil
+	if isNew {
+		entry = &credentialPolicyIP{}
+	}
+
+	if policy.ConsistentPerIP {
+		if first, ok := entry.Passwords[username]; ok {
+			entry.LastSeen = now
+			return 1 == subtle.ConstantTimeCompare([]byte(password), []byte(first)), true
+		}
+	}
+
+	allowed = configured
+	tracked := false
+	switch policy.Mode {
+	case config.CredentialPolicyNthAttempt:
+		// Stop counting at the nth attempt to keep the state small.
+		if entry.Attempts < policy.NthAttempt {
+			entry.Attempts++
+		}
+		allowed = allowed || entry.Attempts >= policy.NthAttempt
+		tracked = true
+
+	case config.CredentialPolicyDictionary:
+		for _, weighted := range policy.Dictionary {
+			if weighted.Password == password {
+				allowed = allowed || p.random() < weighted.Weight
+			}
+		}
+	}
+
+	if allowed && policy.ConsistentPerIP {
+		if entry.Passwords == nil {
+			entry.Passwords = make(map[string]string)
+		}
+		entry.Passwords[username] = password
+		tracked = true
+	}
+
+	if !tracked && isNew {
+		return allowed, changed
+	}
+
+	entry.LastSeen = now
+	if isNew {
+		if p.state.IPs == nil {
+			p.state.IPs = make(map[string]*credentialPolicyIP)
+		}
+		p.state.IPs[ip] = entry
+		p.evictLocked(policy)
+	}
+	return allowed, true
+}
+
+// sweepLocked forgets expired IPs, the state is walked at most once per sweep
+// interval. It returns whether any IPs were forgotten.
+func (p *credentialPolicy) sweepLocked(policy config.CredentialPolicy, now time.Time) (changed bool) {
+	if policy.Retention == 0 || now.Sub(p.lastSweep) < credentialPolicySweepInterval {
+		return false
+	}
+	p.lastSweep = now
+
+	for ip, entry := range p.state.IPs {
+		if policy.Expired(entry.LastSeen, now) {
+			delete(p.state.IPs, ip)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// evictLocked forgets the least recently seen IPs once there are more than
+// the policy allows. A tenth of the limit is evicted at a time so the state
+// is rarely sorted.
+func (p *credentialPolicy) evictLocked(policy config.CredentialPolicy) {
+	if policy.MaxIPs == 0 || len(p.state.IPs) <= policy.MaxIPs {
+		return
+	}
+
+	ips := make([]string, 0, len(p.state.IPs))
+	for ip := range p.state.IPs {
+		ips = append(ips, ip)
+	}
+	// Least recently seen first.
+	sort.Slice(ips, func(i, j int) bool {
+		return p.state.IPs[ips[i]].LastSeen.Before(p.state.IPs[ips[j]].LastSeen)
+	})
+
+	evict := len(ips) - policy.MaxIPs + policy.MaxIPs/10
+	for _, ip := range ips[:evict] {
+		delete(p.state.IPs, ip)
+	}
+}
+
+func (p *credentialPolicy) random() float64 {
+	if p.randFloat != nil {
+		return p.randFloat()
+	}
+	return rand.Float64()
+}
+
+// scheduleSave writes the policy state to the state file in the background
+// after the save delay, so a burst of attempts is only written once.
+func (p *credentialPolicy) scheduleSave(configuration *config.Configuration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.saveConfiguration = configuration
+	if p.saveTimer != nil {
+		return
+	}
+	p.saveTimer = time.AfterFunc(credentialPolicySaveDelay, func() {
+		if err := p.flush(); err != nil {
+			log.Printf("couldn't save credential policy: %v\n", err)
+		}
+	})
+}
+
+// flush writes the policy state to the state file if it has unsaved changes.
+func (p *credentialPolicy) flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.saveTimer == nil {
+		return nil
+	}
+	p.saveTimer.Stop()
+	p.saveTimer = nil
+	return p.saveConfiguration.WriteState(credentialPolicyName, p.state)
+}
+
+// authContext is the part of ssh.Context needed to authenticate a user, it's
+// also implemented by telnet logins.
+type authContext interface {
+	context.Context
+	User() string
+	RemoteAddr() net.Addr
+}
+
+var _ authContext = (ssh.Context)(nil)
+
+// checkPassword returns whether the password is allowed for the user logging
+// in.
+func (l *listener) checkPassword(ctx authContext, password string) bool {
+	username := ctx.User()
+
+	configured := l.configuration.AllowAnyPassword
+	for _, allowedPass := range l.configuration.GetPasswords(username) {
+		if 1 == subtle.ConstantTimeCompare([]byte(password), []byte(allowedPass)) {
+			configured = true
+		}
+	}
+
+	policy := &l.honeypot.credentialPolicy
+	allowed, changed := policy.allow(l.configuration.CredentialPolicy, remoteIP(ctx.RemoteAddr()), username, password, configured, time.Now())
+	if changed {
+		policy.scheduleSave(l.configuration)
+	}
+	return allowed
+}
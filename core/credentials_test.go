@@ -0,0 +1,175 @@
+package core
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialPolicy_static(t *testing.T) {
+	now := time.Date(2006, 1, 2, 3, 4, 5, 0, time.UTC)
+	var policy credentialPolicy
+	static := config.CredentialPolicy{Mode: config.CredentialPolicyStatic}
+
+	allowed, changed := policy.allow(static, "10.0.0.1", "root", "hunter2", false, now)
+	assert.False(t, allowed)
+	assert.False(t, changed)
+
+	allowed, _ = policy.allow(static, "10.0.0.1", "root", "hunter2", true, now)
+	assert.True(t, allowed)
+}
+
+func TestCredentialPolicy_nthAttempt(t *testing.T) {
+	now := time.Date(2006, 1, 2, 3, 4, 5, 0, time.UTC)
+	var policy credentialPolicy
+	nth := config.CredentialPolicy{Mode: config.CredentialPolicyNthAttempt, NthAttempt: 3}
+
+	for i, want := range []bool{false, false, true, true} {
+		allowed, _ := policy.allow(nth, "10.0.0.1", "root", "guess", false, now)
+		assert.Equal(t, want, allowed, "attempt %d", i+1)
+	}
+
+	allowed, _ := policy.allow(nth, "10.0.0.2", "root", "guess", false, now)
+	assert.False(t, allowed, "attempts are counted per IP")
+	assert.Equal(t, 3, policy.state.IPs["10.0.0.1"].Attempts, "counting stops at the nth attempt")
+}
+
+func TestCredentialPolicy_dictionary(t *testing.T) {
+	now := time.Date(2006, 1, 2, 3, 4, 5, 0, time.UTC)
+	roll := 0.4
+	policy := credentialPolicy{randFloat: func() float64 { return roll }}
+	dictionary := config.CredentialPolicy{
+		Mode: config.CredentialPolicyDictionary,
+		Dictionary: []config.WeightedPassword{
+			{Password: "123456", Weight: 0.5},
+			{Password: "admin", Weight: 0.3},
+		},
+	}
+
+	allowed, _ := policy.allow(dictionary, "10.0.0.1", "root", "123456", false, now)
+	assert.True(t, allowed)
+	allowed, _ = policy.allow(dictionary, "10.0.0.1", "root", "admin", false, now)
+	assert.False(t, allowed)
+	allowed, _ = policy.allow(dictionary, "10.0.0.1", "root", "not-listed", false, now)
+	assert.False(t, allowed)
+
+	roll = 0.1
+	allowed, _ = policy.allow(dictionary, "10.0.0.1", "root", "admin", false, now)
+	assert.True(t, allowed)
+}
+
+func TestCredentialPolicy_consistentPerIP(t *testing.T) {
+	now := time.Date(2006, 1, 2, 3, 4, 5, 0, time.UTC)
+	var policy credentialPolicy
+	consistent := config.CredentialPolicy{
+		Mode:            config.CredentialPolicyNthAttempt,
+		NthAttempt:      2,
+		ConsistentPerIP: true,
+	}
+
+	allowed, _ := policy.allow(consistent, "10.0.0.1", "root", "first", false, now)
+	assert.False(t, allowed)
+	allowed, changed := policy.allow(consistent, "10.0.0.1", "root", "second", false, now)
+	assert.True(t, allowed)
+	assert.True(t, changed)
+
+	// Only the accepted password works from then on, even configured ones.
+	allowed, _ = policy.allow(consistent, "10.0.0.1", "root", "second", false, now)
+	assert.True(t, allowed)
+	allowed, _ = policy.allow(consistent, "10.0.0.1", "root", "third", true, now)
+	assert.False(t, allowed)
+
+	// Other users and IPs aren't pinned.
+	allowed, _ = policy.allow(consistent, "10.0.0.1", "admin", "other", false, now)
+	assert.True(t, allowed)
+	allowed, _ = policy.allow(consistent, "10.0.0.2", "root", "third", true, now)
+	assert.True(t, allowed)
+}
+
+func TestCredentialPolicy_expiry(t *testing.T) {
+	now := time.Date(2006, 1, 2, 3, 4, 5, 0, time.UTC)
+	var policy credentialPolicy
+	nth := config.CredentialPolicy{
+		Mode:       config.CredentialPolicyNthAttempt,
+		NthAttempt: 2,
+		Retention:  config.Duration(time.Hour),
+		MaxIPs:     2,
+	}
+
+	policy.allow(nth, "10.0.0.1", "root", "guess", false, now)
+	allowed, _ := policy.allow(nth, "10.0.0.1", "root", "guess", false, now.Add(time.Hour))
+	assert.True(t, allowed)
+	allowed, changed := policy.allow(nth, "10.0.0.1", "root", "guess", false, now.Add(3*time.Hour))
+	assert.False(t, allowed, "attempts are forgotten after the retention period")
+	assert.True(t, changed)
+
+	// Expired IPs are swept even if they never come back.
+	later := now.Add(5 * time.Hour)
+	policy.allow(nth, "10.0.0.2", "root", "guess", false, later)
+	assert.Nil(t, policy.state.IPs["10.0.0.1"])
+	assert.NotNil(t, policy.state.IPs["10.0.0.2"])
+}
+
+func TestCredentialPolicy_eviction(t *testing.T) {
+	now := time.Date(2006, 1, 2, 3, 4, 5, 0, time.UTC)
+	var policy credentialPolicy
+	nth := config.CredentialPolicy{
+		Mode:       config.CredentialPolicyNthAttempt,
+		NthAttempt: 2,
+		MaxIPs:     20,
+	}
+
+	for i := 0; i <= nth.MaxIPs; i++ {
+		policy.allow(nth, fmt.Sprintf("10.0.0.%d", i), "root", "guess", false, now.Add(time.Duration(i)*time.Second))
+	}
+	assert.Len(t, policy.state.IPs, 18, "a tenth of the limit is evicted at once")
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, policy.state.IPs[fmt.Sprintf("10.0.0.%d", i)], "least recently seen IPs are forgotten first")
+	}
+}
+
+func TestCredentialPolicy_unchangedState(t *testing.T) {
+	now := time.Date(2006, 1, 2, 3, 4, 5, 0, time.UTC)
+	var policy credentialPolicy
+	dictionary := config.CredentialPolicy{Mode: config.CredentialPolicyDictionary}
+
+	_, changed := policy.allow(dictionary, "10.0.0.1", "root", "guess", false, now)
+	assert.False(t, changed)
+	assert.Empty(t, policy.state.IPs, "IPs are only tracked when the policy needs them")
+}
+
+func TestCredentialPolicy_flush(t *testing.T) {
+	tempDir := t.TempDir()
+	_, err := config.Initialize(tempDir, log.New(ioutil.Discard, "", 0))
+	require.Nil(t, err)
+	cfg, err := config.Load(tempDir)
+	require.Nil(t, err)
+
+	var policy credentialPolicy
+	nth := config.CredentialPolicy{Mode: config.CredentialPolicyNthAttempt, NthAttempt: 3}
+	_, changed := policy.allow(nth, "10.0.0.1", "root", "guess", false, time.Now())
+	require.True(t, changed)
+	policy.scheduleSave(cfg)
+
+	statePath := filepath.Join(tempDir, credentialPolicyName)
+	_, err = os.Stat(statePath)
+	assert.True(t, os.IsNotExist(err), "saves are batched")
+
+	require.Nil(t, policy.flush())
+	var saved credentialPolicyState
+	require.Nil(t, cfg.ReadState(credentialPolicyName, &saved))
+	assert.Equal(t, 1, saved.IPs["10.0.0.1"].Attempts)
+
+	require.Nil(t, os.Remove(statePath))
+	require.Nil(t, policy.flush())
+	_, err = os.Stat(statePath)
+	assert.True(t, os.IsNotExist(err), "nothing is written without changes")
+}
@@ -0,0 +1,189 @@
+// Package fingerprint identifies SSH clients from their version string and
+// the algorithms they offer during key exchange.
+package fingerprint
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+)
+
+const (
+	// msgKexInit is the SSH message number for SSH_MSG_KEXINIT.
+	msgKexInit = 20
+	// maxCapture is the maximum number of bytes buffered looking for the
+	// KEXINIT before giving up.
+	maxCapture = 64 * 1024
+)
+
+var errMalformed = errors.New("malformed KEXINIT")
+
+// Client holds identifying information the client sent before
+// authenticating.
+type Client struct {
+	// Version is the identification string e.g. "SSH-2.0-OpenSSH_8.2p1".
+	Version string
+
+	KexAlgorithms           []string
+	HostKeyAlgorithms       []string
+	CiphersClientServer     []string
+	CiphersServerClient     []string
+	MACsClientServer        []string
+	MACsServerClient        []string
+	CompressionClientServer []string
+	CompressionServerClient []string
+	LanguagesClientServer   []string
+	LanguagesServerClient   []string
+	FirstKexFollows         bool
+}
+
+// HASSHAlgorithms returns the string the HASSH fingerprint is computed over.
+//
+// See: https://github.com/salesforce/hassh
+func (c *Client) HASSHAlgorithms() string {
+	return strings.Join([]string{
+		strings.Join(c.KexAlgorithms, ","),
+		strings.Join(c.CiphersClientServer, ","),
+		strings.Join(c.MACsClientServer, ","),
+		strings.Join(c.CompressionClientServer, ","),
+	}, ";")
+}
+
+// HASSH returns the hex encoded HASSH fingerprint of the client.
+func (c *Client) HASSH() string {
+	sum := md5.Sum([]byte(c.HASSHAlgorithms()))
+	return hex.EncodeToString(sum[:])
+}
+
+// Conn wraps a server side net.Conn and captures the client's version string
+// and KEXINIT as they're read.
+type Conn struct {
+	net.Conn
+
+	mu     sync.Mutex
+	buf    []byte
+	done   bool
+	client *Client
+}
+
+// NewConn wraps the connection to capture the client fingerprint.
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{Conn: conn}
+}
+
+// Read implements io.Reader.
+func (c *Conn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.done && n > 0 {
+		c.buf = append(c.buf, b[:n]...)
+		client, ok, parseErr := parseClientHello(c.buf)
+		switch {
+		case ok:
+			c.client = client
+			c.done = true
+		case parseErr != nil || len(c.buf) > maxCapture:
+			c.done = true
+		}
+		if c.done {
+			c.buf = nil
+		}
+	}
+
+	return n, err
+}
+
+// Client returns the fingerprint of the client or nil if the KEXINIT hasn't
+// been seen (yet).
+func (c *Conn) Client() *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client
+}
+
+// parseClientHello parses the identification string and the KEXINIT packet
+// from the start of a client stream. It returns ok = false if more data is
+// needed.
+func parseClientHello(data []byte) (client *Client, ok bool, err error) {
+	newline := bytes.IndexByte(data, '\n')
+	if newline < 0 {
+		return nil, false, nil
+	}
+	version := strings.TrimRight(string(data[:newline]), "\r")
+	if !strings.HasPrefix(version, "SSH-") {
+		return nil, false, errors.New("missing SSH identification string")
+	}
+	data = data[newline+1:]
+
+	// Binary packet: uint32 length, byte padding length, payload, padding.
+	if len(data) < 5 {
+		return nil, false, nil
+	}
+	packetLen := binary.BigEndian.Uint32(data)
+	if packetLen > maxCapture {
+		return nil, false, errMalformed
+	}
+	if uint32(len(data)-4) < packetLen {
+		return nil, false, nil
+	}
+	paddingLen := uint32(data[4])
+	if paddingLen+1 > packetLen {
+		return nil, false, errMalformed
+	}
+	payload := data[5 : 4+packetLen-paddingLen]
+
+	client, err = parseKexInit(payload)
+	if err != nil {
+		return nil, false, err
+	}
+	client.Version = version
+	return client, true, nil
+}
+
+func parseKexInit(payload []byte) (*Client, error) {
+	// byte SSH_MSG_KEXINIT, byte[16] cookie
+	if len(payload) < 17 || payload[0] != msgKexInit {
+		return nil, errMalformed
+	}
+	payload = payload[17:]
+
+	client := &Client{}
+	for _, dest := range []*[]string{
+		&client.KexAlgorithms,
+		&client.HostKeyAlgorithms,
+		&client.CiphersClientServer,
+		&client.CiphersServerClient,
+		&client.MACsClientServer,
+		&client.MACsServerClient,
+		&client.CompressionClientServer,
+		&client.CompressionServerClient,
+		&client.LanguagesClientServer,
+		&client.LanguagesServerClient,
+	} {
+		if len(payload) < 4 {
+			return nil, errMalformed
+		}
+		listLen := binary.BigEndian.Uint32(payload)
+		payload = payload[4:]
+		if uint32(len(payload)) < listLen {
+			return nil, errMalformed
+		}
+		if listLen > 0 {
+			*dest = strings.Split(string(payload[:listLen]), ",")
+		}
+		payload = payload[listLen:]
+	}
+
+	if len(payload) > 0 {
+		client.FirstKexFollows = payload[0] != 0
+	}
+
+	return client, nil
+}
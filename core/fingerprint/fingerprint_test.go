@@ -0,0 +1,80 @@
+package fingerprint
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestConn(t *testing.T) {
+	serverSide, clientSide := net.Pipe()
+	defer serverSide.Close()
+	defer clientSide.Close()
+
+	go gossh.NewClientConn(clientSide, "pipe", &gossh.ClientConfig{
+		User:            "root",
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		ClientVersion:   "SSH-2.0-TestClient_1.0",
+		Config: gossh.Config{
+			KeyExchanges: []string{"curve25519-sha256"},
+			Ciphers:      []string{"aes128-ctr", "aes256-ctr"},
+			MACs:         []string{"hmac-sha2-256"},
+		},
+	})
+	go serverSide.Write([]byte("SSH-2.0-TestServer\r\n"))
+
+	conn := NewConn(serverSide)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 16)
+	for conn.Client() == nil {
+		if _, err := conn.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := conn.Client()
+	assert.Equal(t, "SSH-2.0-TestClient_1.0", client.Version)
+	assert.Equal(t, []string{"aes128-ctr", "aes256-ctr"}, client.CiphersClientServer)
+	assert.Equal(t, []string{"hmac-sha2-256"}, client.MACsClientServer)
+	assert.Equal(t, []string{"none"}, client.CompressionClientServer)
+	assert.Equal(t, "curve25519-sha256", client.KexAlgorithms[0])
+	assert.Len(t, client.HASSH(), 32)
+}
+
+func TestClient_HASSH(t *testing.T) {
+	client := &Client{
+		KexAlgorithms:           []string{"curve25519-sha256", "ecdh-sha2-nistp256"},
+		HostKeyAlgorithms:       []string{"ssh-ed25519"},
+		CiphersClientServer:     []string{"aes128-ctr", "aes256-ctr"},
+		CiphersServerClient:     []string{"aes128-ctr"},
+		MACsClientServer:        []string{"hmac-sha2-256"},
+		CompressionClientServer: []string{"none", "zlib@openssh.com"},
+	}
+
+	assert.Equal(t, "curve25519-sha256,ecdh-sha2-nistp256;aes128-ctr,aes256-ctr;hmac-sha2-256;none,zlib@openssh.com", client.HASSHAlgorithms())
+	assert.Equal(t, "492a1b12bc056237f805415b405d8616", client.HASSH())
+}
+
+func TestParseClientHello_partial(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"SSH-2.0-Partial",
+		"SSH-2.0-Partial\r\n",
+		"SSH-2.0-Partial\r\n\x00\x00\x01",
+		"SSH-2.0-Partial\r\n\x00\x00\x01\x00\x04\x14",
+	} {
+		client, ok, err := parseClientHello([]byte(data))
+		assert.Nil(t, client, "%q", data)
+		assert.False(t, ok, "%q", data)
+		assert.Nil(t, err, "%q", data)
+	}
+}
+
+func TestParseClientHello_invalid(t *testing.T) {
+	_, ok, err := parseClientHello([]byte("GET / HTTP/1.1\r\n"))
+	assert.False(t, ok)
+	assert.NotNil(t, err)
+}
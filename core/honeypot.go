@@ -2,17 +2,20 @@ package core
 
 import (
 	"context"
-	"crypto/subtle"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"runtime/debug"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/gliderlabs/ssh"
 	"github.com/josephlewis42/honeyssh/commands"
 	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/josephlewis42/honeyssh/core/fingerprint"
 	"github.com/josephlewis42/honeyssh/core/logger"
 	"github.com/josephlewis42/honeyssh/core/ttylog"
 	"github.com/josephlewis42/honeyssh/core/vos"
@@ -29,14 +32,91 @@ var (
 	ContextAuthPublicKey = sshContextKey{"auth-public-key"}
 	// ContextAuthPassword holds the password the client sent to the server.
 	ContextAuthPassword = sshContextKey{"auth-password"}
+	// ContextAuthMethod holds the name of the method the client authenticated
+	// with.
+	ContextAuthMethod = sshContextKey{"auth-method"}
+	// ContextAuthKeySource holds the logger.LoginAttempt_KeySource of the
+	// public key the client authenticated with.
+	ContextAuthKeySource = sshContextKey{"auth-key-source"}
+	// ContextAuthKeyboardInteractive holds the keyboard-interactive answers the
+	// client sent to the server.
+	ContextAuthKeyboardInteractive = sshContextKey{"auth-keyboard-interactive"}
+	// ContextFingerprintConn holds the *fingerprint.Conn that captures the
+	// client's identifying information.
+	ContextFingerprintConn = sshContextKey{"fingerprint-conn"}
+	// ContextConnectionID holds the ID logged with every event on the
+	// connection.
+	ContextConnectionID = sshContextKey{"connection-id"}
+	// ContextTelnetConn holds the *telnet.Conn of telnet connections.
+	ContextTelnetConn = sshContextKey{"telnet-conn"}
 )
 
+const (
+	authMethodPublicKey           = "publickey"
+	authMethodPassword            = "password"
+	authMethodKeyboardInteractive = "keyboard-interactive"
+)
+
+// filesystemChangesFileExt is the extension of the archives of files sessions
+// changed, they share the session log's name.
+const filesystemChangesFileExt = "fs.tar.gz"
+
+// keyboardInteractiveAnswer is a response to a keyboard-interactive prompt.
+type keyboardInteractiveAnswer struct {
+	Prompt string
+	Answer string
+}
+
 type Honeypot struct {
 	configuration *config.Configuration
-	sharedOS      *vos.SharedOS
 	toClose       listCloser
 	logger        *logger.Logger
-	sshServer     *ssh.Server
+	servers       []*server
+
+	sessions              sessionTracker
+	rememberedKeys        rememberedKeys
+	credentialPolicy      credentialPolicy
+	persistentFilesystems persistentFilesystems
+	connLimiter           connLimiter
+	authRateLimiter       authRateLimiter
+}
+
+// listener is the personality of an SSH server, it's replaced when the
+// configuration is reloaded.
+type listener struct {
+	honeypot      *Honeypot
+	configuration *config.Configuration
+	sharedOS      *vos.SharedOS
+	logger        *logger.Logger
+	motd          *template.Template
+
+	lastLog *lastLogTracker
+}
+
+// server is the SSH server for a listener and its telnet server, if enabled.
+// New connections and sessions are handled by the most recently loaded
+// listener.
+type server struct {
+	sshServer *ssh.Server
+	telnet    *telnetServer
+
+	mu       sync.RWMutex
+	listener *listener
+}
+
+// current returns the listener new sessions should use.
+func (s *server) current() *listener {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.listener
+}
+
+func (s *server) swap(l *listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listener = l
 }
 
 type HoneypotOpts struct {
@@ -53,12 +133,6 @@ func NewHoneypot(configuration *config.Configuration, stderr io.Writer) (*Honeyp
 		}
 	}()
 
-	// Set up the filesystem.
-	vfs, err := vos.NewVFSFromConfig(configuration)
-	if err != nil {
-		return nil, err
-	}
-
 	// Set up the app log
 	logFd, err := configuration.OpenAppLog()
 	if err != nil {
@@ -67,53 +141,176 @@ func NewHoneypot(configuration *config.Configuration, stderr io.Writer) (*Honeyp
 	log.Printf("- Writing app logs to %s\n", logFd.Name())
 	toClose = append(toClose, logFd)
 
-	sharedOS := vos.NewSharedOS(vfs, commands.BuiltinProcessResolver, configuration, time.Now)
-	sharedOS.SetPID(4507)
-
 	honeypot := &Honeypot{
 		configuration: configuration,
-		sharedOS:      sharedOS,
 		toClose:       toClose,
 		logger:        logger.NewJsonLinesLogRecorder(io.MultiWriter(logFd, stderr)),
 	}
 
-	honeypot.sshServer = &ssh.Server{
+	if err := configuration.ReadState(rememberedKeysName, &honeypot.rememberedKeys.keys); err != nil {
+		return nil, err
+	}
+	if err := configuration.ReadState(credentialPolicyName, &honeypot.credentialPolicy.state); err != nil {
+		return nil, err
+	}
+	if err := configuration.ReadState(persistentFilesystemsName, &honeypot.persistentFilesystems.saved); err != nil {
+		return nil, err
+	}
+
+	for _, listenerConfig := range configuration.ListenerConfigurations() {
+		l, err := honeypot.newListener(listenerConfig, nil)
+		if err != nil {
+			return nil, err
+		}
+		srv, err := newServer(l)
+		if err != nil {
+			return nil, err
+		}
+		honeypot.servers = append(honeypot.servers, srv)
+	}
+
+	initialized = true
+	return honeypot, nil
+}
+
+// newListener creates a listener for the configuration. If previous is set,
+// the new listener continues its simulated system.
+func (h *Honeypot) newListener(configuration *config.Configuration, previous *listener) (*listener, error) {
+	motd, err := parseMotd(configuration.Motd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up the filesystem.
+	vfs, err := vos.NewVFSFromConfig(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &listener{
+		honeypot:      h,
+		configuration: configuration,
+		logger:        h.logger,
+		motd:          motd,
+	}
+	if previous != nil {
+		l.sharedOS = previous.sharedOS.Reload(vfs, configuration)
+		l.lastLog = previous.lastLog
+	} else {
+		l.sharedOS = vos.NewSharedOS(vfs, commands.BuiltinProcessResolver, configuration, time.Now)
+		l.sharedOS.SetPID(4507)
+		l.lastLog = &lastLogTracker{}
+	}
+	if name := configuration.ListenerName(); name != "" {
+		l.logger = h.logger.WithListener(name)
+	}
+
+	return l, nil
+}
+
+// newServer creates the servers for the listener. The ports, SSH profile and
+// host keys are fixed when the server is created.
+func newServer(initial *listener) (*server, error) {
+	configuration := initial.configuration
+	srv := &server{
+		listener: initial,
+		telnet:   newTelnetServer(configuration.Telnet.Port),
+	}
+
+	profile := configuration.Profile()
+	version := defaultSSHVersion
+	if profile != nil {
+		version = profile.Version
+	}
+
+	srv.sshServer = &ssh.Server{
 		// Fake being an OpenSSH server
-		Version: "OpenSSH_8.2p1",
+		Version: version,
 		Addr:    fmt.Sprintf(":%d", configuration.SSHPort),
 		Handler: func(s ssh.Session) {
-			honeypot.HandleConnection(s)
+			srv.current().HandleConnection(s)
+		},
+		ChannelHandlers: map[string]ssh.ChannelHandler{
+			"session": ssh.DefaultSessionHandler,
+			forwardTypeDirectTCPIP: func(s *ssh.Server, conn *gossh.ServerConn, newChan gossh.NewChannel, ctx ssh.Context) {
+				srv.current().handleDirectTCPIP(s, conn, newChan, ctx)
+			},
 		},
+		RequestHandlers: map[string]ssh.RequestHandler{
+			forwardTypeTCPIPForward: func(ctx ssh.Context, s *ssh.Server, req *gossh.Request) (bool, []byte) {
+				return srv.current().handleTCPIPForward(ctx, s, req)
+			},
+			forwardTypeCancel: func(ctx ssh.Context, s *ssh.Server, req *gossh.Request) (bool, []byte) {
+				return srv.current().handleTCPIPForward(ctx, s, req)
+			},
+		},
+		SubsystemHandlers: map[string]ssh.SubsystemHandler{
+			"sftp": func(s ssh.Session) {
+				srv.current().HandleConnection(s)
+			},
+		},
+		ConnCallback: func(ctx ssh.Context, conn net.Conn) net.Conn {
+			l := srv.current()
+			ctx.SetValue(ContextConnectionID, logger.NewID())
+
+			if l.configuration.ProxyProtocol {
+				conn = l.readProxyHeader(ctx, conn)
+				if conn == nil {
+					return nil
+				}
+			}
+
+			conn = l.limitConnection(ctx, conn)
+			if conn == nil {
+				return nil
+			}
+
+			fpConn := fingerprint.NewConn(conn)
+			ctx.SetValue(ContextFingerprintConn, fpConn)
+			return fpConn
+		},
+		// Public keys aren't rate limited, clients offer each of their keys on
+		// every connection and keys can't be guessed like passwords.
 		PublicKeyHandler: func(ctx ssh.Context, key ssh.PublicKey) bool {
 			ctx.SetValue(ContextAuthPublicKey, key.Marshal())
-			return false
+
+			keySource := srv.current().checkPublicKey(ctx.User(), key)
+			if keySource == logger.LoginAttempt_UNKNOWN {
+				return false
+			}
+			ctx.SetValue(ContextAuthMethod, authMethodPublicKey)
+			ctx.SetValue(ContextAuthKeySource, keySource)
+			return true
 		},
 		PasswordHandler: func(ctx ssh.Context, password string) bool {
-			ctx.SetValue(ContextAuthPassword, password)
-
-			var successfulLogin bool
-			if configuration.AllowAnyPassword {
-				successfulLogin = true
-			} else {
-				passwords := configuration.GetPasswords(ctx.User())
-				for _, allowedPass := range passwords {
-					if 1 == subtle.ConstantTimeCompare([]byte(password), []byte(allowedPass)) {
-						successfulLogin = true
-					}
-				}
+			l := srv.current()
+			// Rate limited passwords are logged but never checked.
+			result := logger.OperationResult_RATE_LIMITED
+			successfulLogin := false
+			if l.allowAuthAttempt(ctx) {
+				ctx.SetValue(ContextAuthPassword, password)
+				ctx.SetValue(ContextAuthMethod, authMethodPassword)
+
+				successfulLogin = l.checkPassword(ctx, password)
+				result = logger.OperationResult_FAILURE
 			}
 
 			// Log the login
 			if !successfulLogin {
-				honeypot.logger.Sessionless().Record(&logger.LogEntry_LoginAttempt{
+				l.connectionLogger(ctx).Record(&logger.LogEntry_LoginAttempt{
 					LoginAttempt: &logger.LoginAttempt{
-						Result:     logger.OperationResult_FAILURE,
+						Result:     result,
 						Username:   ctx.User(),
 						PublicKey:  maybeBytes(ctx.Value(ContextAuthPublicKey)),
 						Password:   fmt.Sprintf("%v", password),
 						RemoteAddr: fmt.Sprintf("%v", ctx.RemoteAddr()),
+						AuthMethod: authMethodPassword,
+						Client:     sshClient(ctx),
 					},
 				})
+				if result == logger.OperationResult_FAILURE {
+					l.tarpit()
+				}
 			}
 
 			return successfulLogin
@@ -121,9 +318,14 @@ func NewHoneypot(configuration *config.Configuration, stderr io.Writer) (*Honeyp
 
 		ServerConfigCallback: func(ctx ssh.Context) *gossh.ServerConfig {
 			config := &gossh.ServerConfig{}
+			if profile != nil {
+				config.KeyExchanges = profile.KeyExchanges
+				config.Ciphers = profile.Ciphers
+				config.MACs = profile.MACs
+			}
 			config.BannerCallback = func(_ gossh.ConnMetadata) string {
-				if configuration.SSHBanner != "" {
-					return strings.TrimRight(configuration.SSHBanner, "\n") + "\n"
+				if banner := srv.current().configuration.SSHBanner; banner != "" {
+					return strings.TrimRight(banner, "\n") + "\n"
 				}
 				return ""
 			}
@@ -132,18 +334,105 @@ func NewHoneypot(configuration *config.Configuration, stderr io.Writer) (*Honeyp
 		},
 	}
 
-	keyData, err := configuration.PrivateKeyPem()
+	// Without any handlers the SSH server would accept clients without
+	// authentication.
+	if !configuration.HasAuthMethod() {
+		return nil, fmt.Errorf("ssh_profile %q leaves no usable auth methods", configuration.SSHProfile)
+	}
+	if configuration.UsesAuth(authMethodKeyboardInteractive) {
+		srv.sshServer.KeyboardInteractiveHandler = func(ctx ssh.Context, challenger gossh.KeyboardInteractiveChallenge) bool {
+			return srv.current().handleKeyboardInteractive(ctx, challenger)
+		}
+	}
+	if !configuration.UsesAuth(authMethodPassword) {
+		srv.sshServer.PasswordHandler = nil
+	}
+	if !configuration.UsesAuth(authMethodPublicKey) {
+		srv.sshServer.PublicKeyHandler = nil
+	}
+
+	hostKeys, err := configuration.HostKeys()
 	if err != nil {
-		toClose.Close()
 		return nil, err
 	}
-	honeypot.sshServer.SetOption(ssh.HostKeyPEM(keyData))
+	hostSigners, err := profileHostSigners(hostKeys, profile)
+	if err != nil {
+		return nil, err
+	}
+	if len(hostSigners) == 0 {
+		return nil, fmt.Errorf("ssh_profile %q offers none of the host key types", configuration.SSHProfile)
+	}
+	for _, signer := range hostSigners {
+		srv.sshServer.AddHostKey(signer)
+	}
 
-	initialized = true
-	return honeypot, nil
+	return srv, nil
+}
+
+// handleKeyboardInteractive asks the client the configured prompts, the
+// answer to the first prompt is checked as the password.
+func (l *listener) handleKeyboardInteractive(ctx ssh.Context, challenger gossh.KeyboardInteractiveChallenge) bool {
+	// Rate limited clients are still asked so their answers can be logged.
+	allowed := l.allowAuthAttempt(ctx)
+
+	prompts := l.configuration.KeyboardInteractivePrompts
+	questions := make([]string, len(prompts))
+	echos := make([]bool, len(prompts))
+	for i, prompt := range prompts {
+		questions[i] = prompt.Prompt
+		echos[i] = prompt.Echo
+	}
+
+	answers, err := challenger(ctx.User(), "", questions, echos)
+	if err != nil || len(answers) != len(questions) {
+		return false
+	}
+
+	var kiAnswers []keyboardInteractiveAnswer
+	for i, answer := range answers {
+		kiAnswers = append(kiAnswers, keyboardInteractiveAnswer{
+			Prompt: questions[i],
+			Answer: answer,
+		})
+	}
+
+	result := logger.OperationResult_RATE_LIMITED
+	if allowed {
+		result = logger.OperationResult_FAILURE
+	}
+	if allowed && l.checkPassword(ctx, answers[0]) {
+		// The login will be logged when the session starts.
+		ctx.SetValue(ContextAuthPassword, answers[0])
+		ctx.SetValue(ContextAuthMethod, authMethodKeyboardInteractive)
+		ctx.SetValue(ContextAuthKeyboardInteractive, kiAnswers)
+		return true
+	}
+
+	for _, kiAnswer := range kiAnswers {
+		l.connectionLogger(ctx).Record(&logger.LogEntry_LoginAttempt{
+			LoginAttempt: &logger.LoginAttempt{
+				Result:     result,
+				Username:   ctx.User(),
+				PublicKey:  maybeBytes(ctx.Value(ContextAuthPublicKey)),
+				Password:   kiAnswer.Answer,
+				RemoteAddr: fmt.Sprintf("%v", ctx.RemoteAddr()),
+				AuthMethod: authMethodKeyboardInteractive,
+				Prompt:     kiAnswer.Prompt,
+				Client:     sshClient(ctx),
+			},
+		})
+	}
+	if allowed {
+		l.tarpit()
+	}
+
+	return false
 }
 
 func (h *Honeypot) Close() error {
+	if err := h.credentialPolicy.flush(); err != nil {
+		log.Printf("couldn't save credential policy: %v\n", err)
+	}
 	return h.toClose.Close()
 }
 
@@ -161,10 +450,20 @@ type SessionInfo interface {
 
 var _ SessionInfo = (ssh.Session)(nil)
 
-func (h *Honeypot) HandleConnection(s SessionInfo) error {
+// connectionLogger returns a logger that tags events with the connection's
+// ID.
+func (l *listener) connectionLogger(ctx context.Context) *logger.SessionLogger {
+	connectionID, _ := ctx.Value(ContextConnectionID).(string)
+	return l.logger.NewConnection(connectionID)
+}
+
+func (l *listener) HandleConnection(s SessionInfo) error {
 	sessionStartTime := time.Now()
-	sessionID := fmt.Sprintf("%d", sessionStartTime.UnixNano())
-	sessionLogger := h.logger.NewSession(sessionID)
+	sessionLogger := l.connectionLogger(s.Context()).NewSession(logger.NewID())
+
+	// Track the session until everything is logged so shutdown can wait for it.
+	active := l.honeypot.sessions.add()
+	defer l.honeypot.sessions.remove(active)
 
 	// Log panics to prevent a single connection from bringing down the whole
 	// process.
@@ -180,20 +479,46 @@ func (h *Honeypot) HandleConnection(s SessionInfo) error {
 	}()
 
 	// Log the login
+	loginAttempt := &logger.LoginAttempt{
+		Result:               logger.OperationResult_SUCCESS,
+		Username:             s.User(),
+		PublicKey:            maybeBytes(s.Context().Value(ContextAuthPublicKey)),
+		Password:             maybeString(s.Context().Value(ContextAuthPassword)),
+		RemoteAddr:           fmt.Sprintf("%s", s.RemoteAddr()),
+		EnvironmentVariables: s.Environ(),
+		Command:              s.Command(),
+		RawCommand:           s.RawCommand(),
+		Subsystem:            s.Subsystem(),
+		AuthMethod:           maybeString(s.Context().Value(ContextAuthMethod)),
+		Client:               sshClient(s.Context()),
+		TelnetClient:         telnetClient(s.Context()),
+	}
+	if keySource, ok := s.Context().Value(ContextAuthKeySource).(logger.LoginAttempt_KeySource); ok {
+		loginAttempt.KeySource = keySource
+	}
+	kiAnswers, _ := s.Context().Value(ContextAuthKeyboardInteractive).([]keyboardInteractiveAnswer)
+	if len(kiAnswers) > 0 {
+		loginAttempt.Prompt = kiAnswers[0].Prompt
+	}
 	sessionLogger.Record(&logger.LogEntry_LoginAttempt{
-		LoginAttempt: &logger.LoginAttempt{
-			Result:               logger.OperationResult_SUCCESS,
-			Username:             s.User(),
-			PublicKey:            maybeBytes(s.Context().Value(ContextAuthPublicKey)),
-			Password:             fmt.Sprintf("%s", s.Context().Value(ContextAuthPassword)),
-			RemoteAddr:           fmt.Sprintf("%s", s.RemoteAddr()),
-			EnvironmentVariables: s.Environ(),
-			Command:              s.Command(),
-			RawCommand:           s.RawCommand(),
-			Subsystem:            s.Subsystem(),
-		},
+		LoginAttempt: loginAttempt,
 	})
 
+	// Log the remaining keyboard-interactive answers e.g. 2FA codes.
+	for i := 1; i < len(kiAnswers); i++ {
+		sessionLogger.Record(&logger.LogEntry_LoginAttempt{
+			LoginAttempt: &logger.LoginAttempt{
+				Result:     logger.OperationResult_SUCCESS,
+				Username:   s.User(),
+				Password:   kiAnswers[i].Answer,
+				RemoteAddr: fmt.Sprintf("%s", s.RemoteAddr()),
+				AuthMethod: authMethodKeyboardInteractive,
+				Prompt:     kiAnswers[i].Prompt,
+				Client:     sshClient(s.Context()),
+			},
+		})
+	}
+
 	// Set up I/O and logging.
 	logFileName := fmt.Sprintf("%s.%s", time.Now().Format(time.RFC3339Nano), ttylog.AsciicastFileExt)
 	sessionLogger.Record(&logger.LogEntry_OpenTtyLog{
@@ -202,7 +527,7 @@ func (h *Honeypot) HandleConnection(s SessionInfo) error {
 		},
 	})
 
-	logFd, err := h.configuration.CreateSessionLog(logFileName)
+	logFd, err := l.configuration.CreateSessionLog(logFileName)
 	if err != nil {
 		return err
 	}
@@ -213,25 +538,39 @@ func (h *Honeypot) HandleConnection(s SessionInfo) error {
 		return b == 127 || // Delete
 			b == 8 // Backspace
 	})
-	vio := ttylog.NewRecorder(vos.NewVIOAdapter(readCounter, s, s), ttylog.NewAsciicastLogSink(logFd))
+	activity := newActivityReader(readCounter, sessionStartTime)
+	vio := ttylog.NewRecorder(vos.NewVIOAdapter(activity, s, s), ttylog.NewAsciicastLogSink(logFd))
 
+	endReason := logger.SessionEnded_UNKNOWN
+	var filesystemChanges string
 	defer func() {
 		sessionLogger.Record(&logger.LogEntry_SessionEnded{
 			SessionEnded: &logger.SessionEnded{
 				DurationMs:         time.Since(sessionStartTime).Milliseconds(),
 				HumanKeypressCount: int64(readCounter.MatchedTotal),
 				StdinByteCount:     int64(readCounter.Total),
+				Reason:             endReason,
+				FilesystemChanges:  filesystemChanges,
 			},
 		})
 	}()
 
-	procName := h.configuration.OS.DefaultShell
+	procName := l.configuration.OS.DefaultShell
 	procArgs := []string{procName}
-	if remoteCommand := s.RawCommand(); remoteCommand != "" {
-		procArgs = append(procArgs, "-c", remoteCommand)
+	switch {
+	case s.Subsystem() == "sftp":
+		procName = l.configuration.OS.SFTPServerPath()
+		procArgs = []string{procName}
+	case s.RawCommand() != "":
+		procArgs = append(procArgs, "-c", s.RawCommand())
 	}
 
-	tenantOS := vos.NewTenantOS(h.sharedOS, sessionLogger, s)
+	tenantOS := vos.NewTenantOS(l.sharedOS, sessionLogger, s)
+	persistentFilesystem := l.persistentFilesystemName(s)
+	var restoredFilesystem bool
+	if persistentFilesystem != "" {
+		restoredFilesystem = l.restoreFilesystem(tenantOS, persistentFilesystem)
+	}
 	// Watch for window changes.
 	{
 		ptyInfo, winch, isPTY := s.Pty()
@@ -260,6 +599,31 @@ func (h *Honeypot) HandleConnection(s SessionInfo) error {
 		})()
 	}
 
+	if tenantOS.GetPTY().IsPTY {
+		active.setTerminal(tenantOS.SSHStdout(), l.sharedOS.Hostname())
+	}
+
+	// Interactive shells get the login banner, commands and subsystems don't.
+	if tenantOS.GetPTY().IsPTY && s.RawCommand() == "" && s.Subsystem() == "" {
+		uname := l.configuration.Uname
+		err := l.writeLoginBanner(vio.Stdout(), MotdData{
+			KernelName:       uname.KernelName,
+			Hostname:         uname.Nodename,
+			KernelRelease:    uname.KernelRelease,
+			KernelVersion:    uname.KernelVersion,
+			HardwarePlatform: uname.HardwarePlatform,
+			User:             s.User(),
+			Now:              sessionStartTime,
+			LastLogin: l.previousLogin(s.User(), LastLogin{
+				Time: sessionStartTime,
+				From: remoteIP(s.RemoteAddr()),
+			}),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	loginProc := tenantOS.LoginProc()
 	shellOS, err := loginProc.StartProcess(procName, procArgs, &vos.ProcAttr{
 		Env:   append(loginProc.Environ(), s.Environ()...),
@@ -270,32 +634,158 @@ func (h *Honeypot) HandleConnection(s SessionInfo) error {
 	}
 
 	// Start shell
-	s.Exit(shellOS.Run())
+	exited := make(chan int, 1)
+	go func() {
+		exited <- shellOS.Run()
+	}()
+
+	limits := l.configuration.Limits
+	endReason, exitStatus := waitForExit(
+		exited,
+		activity,
+		time.Duration(limits.IdleTimeout),
+		time.Duration(limits.MaxSessionDuration),
+		active.shutdown)
+	// Background jobs and commands still running when the session ends are
+	// stopped before its changes are saved.
+	tenantOS.KillAll()
+	timedOut := endReason == logger.SessionEnded_IDLE_TIMEOUT || endReason == logger.SessionEnded_MAX_DURATION
+	if timedOut && tenantOS.GetPTY().IsPTY {
+		io.WriteString(vio.Stdout(), autoLogoutMessage)
+	}
+	l.rememberAddedKeys(sessionLogger, loginProc, s.User())
+	filesystemChanges = l.saveFilesystemChanges(tenantOS, strings.TrimSuffix(logFileName, ttylog.AsciicastFileExt)+filesystemChangesFileExt)
+	if persistentFilesystem != "" {
+		l.persistFilesystem(tenantOS, persistentFilesystem, restoredFilesystem)
+	}
+	s.Exit(exitStatus)
 	return nil
 }
 
+// saveFilesystemChanges archives the files the session changed next to the
+// session log. It returns the archive's name or an empty string if nothing
+// was saved.
+func (l *listener) saveFilesystemChanges(tenantOS *vos.TenantOS, name string) string {
+	changes, err := tenantOS.FilesystemChanges()
+	if err != nil {
+		log.Printf("couldn't list filesystem changes: %v\n", err)
+		return ""
+	}
+	if changes.Empty() {
+		return ""
+	}
+
+	fd, err := l.configuration.CreateSessionLog(name)
+	if err != nil {
+		log.Printf("couldn't create filesystem changes archive: %v\n", err)
+		return ""
+	}
+	defer fd.Close()
+
+	if err := tenantOS.WriteFilesystemChanges(fd, changes); err != nil {
+		log.Printf("couldn't write filesystem changes archive: %v\n", err)
+		return ""
+	}
+	return name
+}
+
+// ListenAndServe starts every listener and blocks until one of them fails.
 func (h *Honeypot) ListenAndServe() error {
-	addr := fmt.Sprintf(":%d", h.configuration.SSHPort)
-	log.Printf("- Starting SSH server on %v\n", addr)
-	h.logger.Sessionless().Record(&logger.LogEntry_HoneypotEvent{
+	errs := make(chan error, 2*len(h.servers))
+	for _, srv := range h.servers {
+		go func(srv *server) {
+			errs <- srv.ListenAndServe()
+		}(srv)
+		if srv.telnet != nil {
+			go func(srv *server) {
+				errs <- srv.listenAndServeTelnet()
+			}(srv)
+		}
+	}
+	return <-errs
+}
+
+// Shutdown stops accepting connections and tells users the system is going
+// down for reboot. Sessions get the configured drain period to finish before
+// they're closed, then logs are flushed and closed. Cancelling the context
+// skips the rest of the drain period.
+func (h *Honeypot) Shutdown(ctx context.Context) error {
+	defer h.Close()
+
+	drainPeriod := time.Duration(h.configuration.ShutdownDrainPeriod)
+	drainCtx, cancel := context.WithTimeout(ctx, drainPeriod)
+	defer cancel()
+
+	for _, srv := range h.servers {
+		srv.stopListening()
+	}
+
+	now := time.Now()
+	h.sessions.broadcast(now, now.Add(drainPeriod))
+
+	var lastErr error
+	if err := h.sessions.wait(drainCtx); err != nil {
+		// Sessions log their end before they're removed, wait for that to
+		// finish so nothing is lost when the app log closes.
+		h.sessions.closeAll()
+		flushCtx, cancel := context.WithTimeout(context.Background(), sessionFlushTimeout)
+		defer cancel()
+		if err := h.sessions.wait(flushCtx); err != nil {
+			lastErr = err
+		}
+	}
+
+	for _, srv := range h.servers {
+		if err := srv.sshServer.Close(); err != nil {
+			lastErr = err
+		}
+		if srv.telnet != nil {
+			if err := srv.telnet.Close(); err != nil {
+				lastErr = err
+			}
+		}
+	}
+	return lastErr
+}
+
+// HandleConnection handles the session using the first listener.
+func (h *Honeypot) HandleConnection(s SessionInfo) error {
+	return h.servers[0].current().HandleConnection(s)
+}
+
+func (s *server) ListenAndServe() error {
+	log.Printf("- Starting SSH server on %v\n", s.sshServer.Addr)
+	s.current().logger.Sessionless().Record(&logger.LogEntry_HoneypotEvent{
 		HoneypotEvent: &logger.HoneypotEvent{
 			EventType: logger.HoneypotEvent_START,
 		},
 	})
 
-	return h.sshServer.ListenAndServe()
+	if err := s.sshServer.ListenAndServe(); err != ssh.ErrServerClosed {
+		return err
+	}
+	return nil
 }
 
-func (h *Honeypot) Shutdown(ctx context.Context) error {
-	defer h.Close()
-	log.Printf("Terminating SSH server on %s\n", h.sshServer.Addr)
-	h.logger.Sessionless().Record(&logger.LogEntry_HoneypotEvent{
+// stopListening stops accepting new connections, existing connections are
+// left open.
+func (s *server) stopListening() {
+	log.Printf("Terminating SSH server on %s\n", s.sshServer.Addr)
+	s.current().logger.Sessionless().Record(&logger.LogEntry_HoneypotEvent{
 		HoneypotEvent: &logger.HoneypotEvent{
 			EventType: logger.HoneypotEvent_TERMINATE,
 		},
 	})
 
-	return h.sshServer.Shutdown(ctx)
+	// Shutdown closes the listeners before waiting for connections to close,
+	// the cancelled context stops it from waiting.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.sshServer.Shutdown(ctx)
+
+	if s.telnet != nil {
+		s.telnet.stopListening()
+	}
 }
 
 type listCloser []io.Closer
@@ -317,3 +807,37 @@ func maybeBytes(data interface{}) []byte {
 	}
 	return nil
 }
+
+// sshClient returns the fingerprint of the client attached to the context or
+// nil if it's unknown.
+func sshClient(ctx context.Context) *logger.SSHClient {
+	fpConn, ok := ctx.Value(ContextFingerprintConn).(*fingerprint.Conn)
+	if !ok {
+		return nil
+	}
+	client := fpConn.Client()
+	if client == nil {
+		return nil
+	}
+
+	return &logger.SSHClient{
+		Version:                 client.Version,
+		KexAlgorithms:           client.KexAlgorithms,
+		HostKeyAlgorithms:       client.HostKeyAlgorithms,
+		CiphersClientServer:     client.CiphersClientServer,
+		CiphersServerClient:     client.CiphersServerClient,
+		MacsClientServer:        client.MACsClientServer,
+		MacsServerClient:        client.MACsServerClient,
+		CompressionClientServer: client.CompressionClientServer,
+		CompressionServerClient: client.CompressionServerClient,
+		Hassh:                   client.HASSH(),
+		HasshAlgorithms:         client.HASSHAlgorithms(),
+	}
+}
+
+func maybeString(data interface{}) string {
+	if str, ok := data.(string); ok {
+		return str
+	}
+	return ""
+}
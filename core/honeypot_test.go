@@ -0,0 +1,180 @@
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// lockedBuffer is a bytes.Buffer that can be read while connections write to
+// it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// loginAttempts returns the login attempts in the log.
+func (b *lockedBuffer) loginAttempts(t *testing.T) []*logger.LoginAttempt {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []*logger.LoginAttempt
+	err := logger.ReadJSONLinesLog(bytes.NewReader(b.buf.Bytes()), func(le *logger.LogEntry) {
+		if attempt := le.GetLoginAttempt(); attempt != nil {
+			out = append(out, attempt)
+		}
+	})
+	require.Nil(t, err)
+	return out
+}
+
+// connectSSH connects an SSH client to the honeypot's first server.
+func connectSSH(t *testing.T, honeypot *Honeypot, auth ...gossh.AuthMethod) (*gossh.Client, error) {
+	// Both sides send their version at once, so a synchronous net.Pipe would
+	// deadlock.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer ln.Close()
+	go func() {
+		if serverConn, err := ln.Accept(); err == nil {
+			honeypot.servers[0].sshServer.HandleConn(serverConn)
+		}
+	}()
+
+	clientConn, err := net.Dial("tcp", ln.Addr().String())
+	require.Nil(t, err)
+	conn, chans, reqs, err := gossh.NewClientConn(clientConn, ln.Addr().String(), &gossh.ClientConfig{
+		User:            "root",
+		Auth:            auth,
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		clientConn.Close()
+		return nil, err
+	}
+	client := gossh.NewClient(conn, chans, reqs)
+	t.Cleanup(func() { client.Close() })
+	return client, nil
+}
+
+// dialSSH connects an SSH client that logs in with the password "hunter2".
+func dialSSH(t *testing.T, honeypot *Honeypot) *gossh.Client {
+	client, err := connectSSH(t, honeypot, gossh.Password("hunter2"))
+	require.Nil(t, err)
+	return client
+}
+
+func TestHoneypot_keyboardInteractive(t *testing.T) {
+	tempDir := t.TempDir()
+	_, err := config.Initialize(tempDir, log.New(ioutil.Discard, "", 0))
+	require.Nil(t, err)
+	cfg, err := config.Load(tempDir)
+	require.Nil(t, err)
+	cfg.GlobalPasswords = []string{"hunter2"}
+	cfg.Limits.FailedLoginDelay = 0
+	cfg.SSHProfile = ""
+	cfg.KeyboardInteractivePrompts = []config.KeyboardInteractivePrompt{
+		{Prompt: "Password: "},
+		{Prompt: "Verification code: ", Echo: true},
+	}
+
+	eventLog := &lockedBuffer{}
+	honeypot, err := NewHoneypot(cfg, eventLog)
+	require.Nil(t, err)
+	t.Cleanup(func() { honeypot.Close() })
+
+	answer := func(code string) gossh.AuthMethod {
+		return gossh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+			assert.Equal(t, "root", user)
+			assert.Equal(t, []string{"Password: ", "Verification code: "}, questions)
+			assert.Equal(t, []bool{false, true}, echos)
+			return []string{code[:7], code[7:]}, nil
+		})
+	}
+
+	_, err = connectSSH(t, honeypot, answer("wrong00123456"))
+	assert.NotNil(t, err, "wrong answers are rejected")
+
+	client, err := connectSSH(t, honeypot, answer("hunter2654321"))
+	require.Nil(t, err)
+	session, err := client.NewSession()
+	require.Nil(t, err)
+	require.Nil(t, session.Run("true"))
+
+	var attempts []*logger.LoginAttempt
+	require.Eventually(t, func() bool {
+		attempts = eventLog.loginAttempts(t)
+		return len(attempts) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for i, want := range []struct {
+		result   logger.OperationResult
+		prompt   string
+		password string
+	}{
+		{logger.OperationResult_FAILURE, "Password: ", "wrong00"},
+		{logger.OperationResult_FAILURE, "Verification code: ", "123456"},
+		{logger.OperationResult_SUCCESS, "Password: ", "hunter2"},
+		{logger.OperationResult_SUCCESS, "Verification code: ", "654321"},
+	} {
+		assert.Equal(t, want.result, attempts[i].GetResult(), i)
+		assert.Equal(t, want.prompt, attempts[i].GetPrompt(), i)
+		assert.Equal(t, want.password, attempts[i].GetPassword(), i)
+		assert.Equal(t, "root", attempts[i].GetUsername(), i)
+		assert.Equal(t, authMethodKeyboardInteractive, attempts[i].GetAuthMethod(), i)
+	}
+}
+
+func TestHoneypot_authRateLimited(t *testing.T) {
+	tempDir := t.TempDir()
+	_, err := config.Initialize(tempDir, log.New(ioutil.Discard, "", 0))
+	require.Nil(t, err)
+	cfg, err := config.Load(tempDir)
+	require.Nil(t, err)
+	cfg.GlobalPasswords = []string{"hunter2"}
+	cfg.Limits.FailedLoginDelay = 0
+	cfg.Limits.AuthAttemptsPerMinute = 1
+	cfg.SSHProfile = ""
+
+	eventLog := &lockedBuffer{}
+	honeypot, err := NewHoneypot(cfg, eventLog)
+	require.Nil(t, err)
+	t.Cleanup(func() { honeypot.Close() })
+
+	_, err = connectSSH(t, honeypot, gossh.Password("wrong"))
+	assert.NotNil(t, err)
+	_, err = connectSSH(t, honeypot, gossh.Password("hunter2"))
+	assert.NotNil(t, err, "rate limited passwords aren't checked")
+	_, err = connectSSH(t, honeypot, gossh.KeyboardInteractive(func(string, string, []string, []bool) ([]string, error) {
+		return []string{"hunter2"}, nil
+	}))
+	assert.NotNil(t, err, "rate limited answers aren't checked")
+
+	attempts := eventLog.loginAttempts(t)
+	require.Len(t, attempts, 3)
+	assert.Equal(t, logger.OperationResult_FAILURE, attempts[0].GetResult())
+	for _, attempt := range attempts[1:] {
+		assert.Equal(t, logger.OperationResult_RATE_LIMITED, attempt.GetResult())
+		assert.Equal(t, "hunter2", attempt.GetPassword())
+	}
+	assert.Equal(t, authMethodPassword, attempts[1].GetAuthMethod())
+	assert.Equal(t, authMethodKeyboardInteractive, attempts[2].GetAuthMethod())
+	assert.Equal(t, "Password: ", attempts[2].GetPrompt())
+}
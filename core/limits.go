@@ -0,0 +1,169 @@
+package core
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/logger"
+)
+
+// remoteIP returns the IP portion of a remote address.
+func remoteIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// connLimiter tracks the number of open connections overall and per IP.
+type connLimiter struct {
+	mu    sync.Mutex
+	total int
+	perIP map[string]int
+}
+
+// acquire reserves a slot for a connection from the IP, if no slot is
+// available it returns the type of limit that was hit.
+func (l *connLimiter) acquire(ip string, maxTotal, maxPerIP int) (logger.HoneypotEvent_Type, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.perIP == nil {
+		l.perIP = make(map[string]int)
+	}
+
+	switch {
+	case maxTotal > 0 && l.total >= maxTotal:
+		return logger.HoneypotEvent_CONNECTION_LIMIT, false
+	case maxPerIP > 0 && l.perIP[ip] >= maxPerIP:
+		return logger.HoneypotEvent_IP_CONNECTION_LIMIT, false
+	}
+
+	l.total++
+	l.perIP[ip]++
+	return logger.HoneypotEvent_UNKNOWN, true
+}
+
+// release frees a slot acquired for the IP.
+func (l *connLimiter) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total--
+	if l.perIP[ip]--; l.perIP[ip] <= 0 {
+		delete(l.perIP, ip)
+	}
+}
+
+// limitedConn releases its connLimiter slot when closed.
+type limitedConn struct {
+	net.Conn
+
+	once    sync.Once
+	release func()
+}
+
+// Close implements io.Closer.
+func (c *limitedConn) Close() error {
+	c.once.Do(c.release)
+	return c.Conn.Close()
+}
+
+// authRateLimiter limits the number of authentication attempts per IP in
+// fixed one minute windows.
+type authRateLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*authWindow
+}
+
+type authWindow struct {
+	start    time.Time
+	attempts int
+}
+
+const (
+	authRateWindow = time.Minute
+	// authRateSweepSize is the number of tracked IPs after which expired
+	// windows are removed.
+	authRateSweepSize = 4096
+)
+
+// allow records an attempt from the IP and returns whether it's within the
+// limit.
+func (r *authRateLimiter) allow(ip string, now time.Time, perMinute int) bool {
+	if perMinute <= 0 {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.windows == nil {
+		r.windows = make(map[string]*authWindow)
+	}
+
+	if len(r.windows) > authRateSweepSize {
+		for key, window := range r.windows {
+			if now.Sub(window.start) >= authRateWindow {
+				delete(r.windows, key)
+			}
+		}
+	}
+
+	window, ok := r.windows[ip]
+	if !ok || now.Sub(window.start) >= authRateWindow {
+		window = &authWindow{start: now}
+		r.windows[ip] = window
+	}
+
+	window.attempts++
+	return window.attempts <= perMinute
+}
+
+// limitConnection reserves a connection slot for the client, it returns nil
+// if the connection should be rejected.
+func (l *listener) limitConnection(ctx context.Context, conn net.Conn) net.Conn {
+	limits := l.configuration.Limits
+	ip := remoteIP(conn.RemoteAddr())
+
+	if eventType, ok := l.honeypot.connLimiter.acquire(ip, limits.MaxConnections, limits.MaxConnectionsPerIP); !ok {
+		l.connectionLogger(ctx).Record(&logger.LogEntry_HoneypotEvent{
+			HoneypotEvent: &logger.HoneypotEvent{
+				EventType:  eventType,
+				RemoteAddr: conn.RemoteAddr().String(),
+			},
+		})
+		return nil
+	}
+
+	return &limitedConn{
+		Conn: conn,
+		release: func() {
+			l.honeypot.connLimiter.release(ip)
+		},
+	}
+}
+
+// allowAuthAttempt returns whether the client may attempt to authenticate.
+func (l *listener) allowAuthAttempt(ctx authContext) bool {
+	perMinute := l.configuration.Limits.AuthAttemptsPerMinute
+	if l.honeypot.authRateLimiter.allow(remoteIP(ctx.RemoteAddr()), time.Now(), perMinute) {
+		return true
+	}
+
+	l.connectionLogger(ctx).Record(&logger.LogEntry_HoneypotEvent{
+		HoneypotEvent: &logger.HoneypotEvent{
+			EventType:  logger.HoneypotEvent_AUTH_RATE_LIMIT,
+			RemoteAddr: ctx.RemoteAddr().String(),
+		},
+	})
+	return false
+}
+
+// tarpit slows down clients after a failed login.
+func (l *listener) tarpit() {
+	time.Sleep(time.Duration(l.configuration.Limits.FailedLoginDelay))
+}
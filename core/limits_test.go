@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnLimiter(t *testing.T) {
+	var limiter connLimiter
+
+	_, ok := limiter.acquire("10.0.0.1", 2, 1)
+	assert.True(t, ok)
+
+	eventType, ok := limiter.acquire("10.0.0.1", 2, 1)
+	assert.False(t, ok)
+	assert.Equal(t, logger.HoneypotEvent_IP_CONNECTION_LIMIT, eventType)
+
+	_, ok = limiter.acquire("10.0.0.2", 2, 1)
+	assert.True(t, ok)
+
+	eventType, ok = limiter.acquire("10.0.0.3", 2, 1)
+	assert.False(t, ok)
+	assert.Equal(t, logger.HoneypotEvent_CONNECTION_LIMIT, eventType)
+
+	limiter.release("10.0.0.1")
+	_, ok = limiter.acquire("10.0.0.1", 2, 1)
+	assert.True(t, ok)
+}
+
+func TestConnLimiter_unlimited(t *testing.T) {
+	var limiter connLimiter
+	for i := 0; i < 100; i++ {
+		_, ok := limiter.acquire("10.0.0.1", 0, 0)
+		assert.True(t, ok)
+	}
+}
+
+func TestAuthRateLimiter(t *testing.T) {
+	var limiter authRateLimiter
+	now := time.Unix(1000, 0)
+
+	assert.True(t, limiter.allow("10.0.0.1", now, 2))
+	assert.True(t, limiter.allow("10.0.0.1", now, 2))
+	assert.False(t, limiter.allow("10.0.0.1", now, 2))
+	assert.True(t, limiter.allow("10.0.0.2", now, 2))
+
+	// The window resets after a minute.
+	assert.True(t, limiter.allow("10.0.0.1", now.Add(time.Minute), 2))
+
+	// Zero is unlimited.
+	assert.True(t, limiter.allow("10.0.0.1", now, 0))
+}
@@ -24,9 +24,10 @@ const (
 type OperationResult int32
 
 const (
-	OperationResult_UNKNOWN OperationResult = 0
-	OperationResult_SUCCESS OperationResult = 1
-	OperationResult_FAILURE OperationResult = 2
+	OperationResult_UNKNOWN      OperationResult = 0
+	OperationResult_SUCCESS      OperationResult = 1
+	OperationResult_FAILURE      OperationResult = 2
+	OperationResult_RATE_LIMITED OperationResult = 3 // Rejected without being checked, the client made too many attempts.
 )
 
 // Enum value maps for OperationResult.
@@ -35,11 +36,13 @@ var (
 		0: "UNKNOWN",
 		1: "SUCCESS",
 		2: "FAILURE",
+		3: "RATE_LIMITED",
 	}
 	OperationResult_value = map[string]int32{
-		"UNKNOWN": 0,
-		"SUCCESS": 1,
-		"FAILURE": 2,
+		"UNKNOWN":      0,
+		"SUCCESS":      1,
+		"FAILURE":      2,
+		"RATE_LIMITED": 3,
 	}
 )
 
@@ -70,6 +73,169 @@ func (OperationResult) EnumDescriptor() ([]byte, []int) {
 	return file_log_proto_rawDescGZIP(), []int{0}
 }
 
+type FilesystemOp_Type int32
+
+const (
+	FilesystemOp_UNKNOWN FilesystemOp_Type = 0
+	FilesystemOp_CREATE  FilesystemOp_Type = 1
+	FilesystemOp_MKDIR   FilesystemOp_Type = 2
+	FilesystemOp_RENAME  FilesystemOp_Type = 3
+	FilesystemOp_REMOVE  FilesystemOp_Type = 4
+	FilesystemOp_CHMOD   FilesystemOp_Type = 5
+	FilesystemOp_SYMLINK FilesystemOp_Type = 6
+)
+
+// Enum value maps for FilesystemOp_Type.
+var (
+	FilesystemOp_Type_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "CREATE",
+		2: "MKDIR",
+		3: "RENAME",
+		4: "REMOVE",
+		5: "CHMOD",
+		6: "SYMLINK",
+	}
+	FilesystemOp_Type_value = map[string]int32{
+		"UNKNOWN": 0,
+		"CREATE":  1,
+		"MKDIR":   2,
+		"RENAME":  3,
+		"REMOVE":  4,
+		"CHMOD":   5,
+		"SYMLINK": 6,
+	}
+)
+
+func (x FilesystemOp_Type) Enum() *FilesystemOp_Type {
+	p := new(FilesystemOp_Type)
+	*p = x
+	return p
+}
+
+func (x FilesystemOp_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FilesystemOp_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_log_proto_enumTypes[1].Descriptor()
+}
+
+func (FilesystemOp_Type) Type() protoreflect.EnumType {
+	return &file_log_proto_enumTypes[1]
+}
+
+func (x FilesystemOp_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FilesystemOp_Type.Descriptor instead.
+func (FilesystemOp_Type) EnumDescriptor() ([]byte, []int) {
+	return file_log_proto_rawDescGZIP(), []int{1, 0}
+}
+
+// The layer of the session's filesystem an operation used.
+type FilesystemOp_Layer int32
+
+const (
+	FilesystemOp_UNKNOWN_LAYER FilesystemOp_Layer = 0
+	FilesystemOp_BASE          FilesystemOp_Layer = 1 // The shared, read only, root filesystem.
+	FilesystemOp_OVERLAY       FilesystemOp_Layer = 2 // The session's copy-on-write layer.
+)
+
+// Enum value maps for FilesystemOp_Layer.
+var (
+	FilesystemOp_Layer_name = map[int32]string{
+		0: "UNKNOWN_LAYER",
+		1: "BASE",
+		2: "OVERLAY",
+	}
+	FilesystemOp_Layer_value = map[string]int32{
+		"UNKNOWN_LAYER": 0,
+		"BASE":          1,
+		"OVERLAY":       2,
+	}
+)
+
+func (x FilesystemOp_Layer) Enum() *FilesystemOp_Layer {
+	p := new(FilesystemOp_Layer)
+	*p = x
+	return p
+}
+
+func (x FilesystemOp_Layer) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FilesystemOp_Layer) Descriptor() protoreflect.EnumDescriptor {
+	return file_log_proto_enumTypes[2].Descriptor()
+}
+
+func (FilesystemOp_Layer) Type() protoreflect.EnumType {
+	return &file_log_proto_enumTypes[2]
+}
+
+func (x FilesystemOp_Layer) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FilesystemOp_Layer.Descriptor instead.
+func (FilesystemOp_Layer) EnumDescriptor() ([]byte, []int) {
+	return file_log_proto_rawDescGZIP(), []int{1, 1}
+}
+
+type LoginAttempt_KeySource int32
+
+const (
+	LoginAttempt_UNKNOWN         LoginAttempt_KeySource = 0
+	LoginAttempt_CONFIG          LoginAttempt_KeySource = 1 // Key from the user's config.
+	LoginAttempt_AUTHORIZED_KEYS LoginAttempt_KeySource = 2 // Key from the authorized_keys file in the root filesystem.
+	LoginAttempt_REMEMBERED      LoginAttempt_KeySource = 3 // Key an attacker added to authorized_keys in an earlier session.
+)
+
+// Enum value maps for LoginAttempt_KeySource.
+var (
+	LoginAttempt_KeySource_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "CONFIG",
+		2: "AUTHORIZED_KEYS",
+		3: "REMEMBERED",
+	}
+	LoginAttempt_KeySource_value = map[string]int32{
+		"UNKNOWN":         0,
+		"CONFIG":          1,
+		"AUTHORIZED_KEYS": 2,
+		"REMEMBERED":      3,
+	}
+)
+
+func (x LoginAttempt_KeySource) Enum() *LoginAttempt_KeySource {
+	p := new(LoginAttempt_KeySource)
+	*p = x
+	return p
+}
+
+func (x LoginAttempt_KeySource) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LoginAttempt_KeySource) Descriptor() protoreflect.EnumDescriptor {
+	return file_log_proto_enumTypes[3].Descriptor()
+}
+
+func (LoginAttempt_KeySource) Type() protoreflect.EnumType {
+	return &file_log_proto_enumTypes[3]
+}
+
+func (x LoginAttempt_KeySource) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LoginAttempt_KeySource.Descriptor instead.
+func (LoginAttempt_KeySource) EnumDescriptor() ([]byte, []int) {
+	return file_log_proto_rawDescGZIP(), []int{3, 0}
+}
+
 type UnknownCommand_UnknownCommandStatus int32
 
 const (
@@ -106,11 +272,11 @@ func (x UnknownCommand_UnknownCommandStatus) String() string {
 }
 
 func (UnknownCommand_UnknownCommandStatus) Descriptor() protoreflect.EnumDescriptor {
-	return file_log_proto_enumTypes[1].Descriptor()
+	return file_log_proto_enumTypes[4].Descriptor()
 }
 
 func (UnknownCommand_UnknownCommandStatus) Type() protoreflect.EnumType {
-	return &file_log_proto_enumTypes[1]
+	return &file_log_proto_enumTypes[4]
 }
 
 func (x UnknownCommand_UnknownCommandStatus) Number() protoreflect.EnumNumber {
@@ -119,15 +285,21 @@ func (x UnknownCommand_UnknownCommandStatus) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use UnknownCommand_UnknownCommandStatus.Descriptor instead.
 func (UnknownCommand_UnknownCommandStatus) EnumDescriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{6, 0}
+	return file_log_proto_rawDescGZIP(), []int{9, 0}
 }
 
 type HoneypotEvent_Type int32
 
 const (
-	HoneypotEvent_UNKNOWN   HoneypotEvent_Type = 0
-	HoneypotEvent_START     HoneypotEvent_Type = 1 // Honeypot started
-	HoneypotEvent_TERMINATE HoneypotEvent_Type = 2 // Honeypot shutting down.
+	HoneypotEvent_UNKNOWN              HoneypotEvent_Type = 0
+	HoneypotEvent_START                HoneypotEvent_Type = 1 // Honeypot started
+	HoneypotEvent_TERMINATE            HoneypotEvent_Type = 2 // Honeypot shutting down.
+	HoneypotEvent_CONNECTION_LIMIT     HoneypotEvent_Type = 3 // Connection rejected, too many open connections.
+	HoneypotEvent_IP_CONNECTION_LIMIT  HoneypotEvent_Type = 4 // Connection rejected, too many open connections from the IP.
+	HoneypotEvent_AUTH_RATE_LIMIT      HoneypotEvent_Type = 5 // Authentication rejected, too many attempts from the IP.
+	HoneypotEvent_INVALID_PROXY_HEADER HoneypotEvent_Type = 6 // Connection rejected, missing or invalid PROXY protocol header.
+	HoneypotEvent_RELOAD               HoneypotEvent_Type = 7 // Configuration reloaded, new sessions use it.
+	HoneypotEvent_RELOAD_FAILED        HoneypotEvent_Type = 8 // Configuration couldn't be reloaded, the previous one is still used.
 )
 
 // Enum value maps for HoneypotEvent_Type.
@@ -136,11 +308,23 @@ var (
 		0: "UNKNOWN",
 		1: "START",
 		2: "TERMINATE",
+		3: "CONNECTION_LIMIT",
+		4: "IP_CONNECTION_LIMIT",
+		5: "AUTH_RATE_LIMIT",
+		6: "INVALID_PROXY_HEADER",
+		7: "RELOAD",
+		8: "RELOAD_FAILED",
 	}
 	HoneypotEvent_Type_value = map[string]int32{
-		"UNKNOWN":   0,
-		"START":     1,
-		"TERMINATE": 2,
+		"UNKNOWN":              0,
+		"START":                1,
+		"TERMINATE":            2,
+		"CONNECTION_LIMIT":     3,
+		"IP_CONNECTION_LIMIT":  4,
+		"AUTH_RATE_LIMIT":      5,
+		"INVALID_PROXY_HEADER": 6,
+		"RELOAD":               7,
+		"RELOAD_FAILED":        8,
 	}
 )
 
@@ -155,11 +339,11 @@ func (x HoneypotEvent_Type) String() string {
 }
 
 func (HoneypotEvent_Type) Descriptor() protoreflect.EnumDescriptor {
-	return file_log_proto_enumTypes[2].Descriptor()
+	return file_log_proto_enumTypes[5].Descriptor()
 }
 
 func (HoneypotEvent_Type) Type() protoreflect.EnumType {
-	return &file_log_proto_enumTypes[2]
+	return &file_log_proto_enumTypes[5]
 }
 
 func (x HoneypotEvent_Type) Number() protoreflect.EnumNumber {
@@ -168,16 +352,78 @@ func (x HoneypotEvent_Type) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use HoneypotEvent_Type.Descriptor instead.
 func (HoneypotEvent_Type) EnumDescriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{13, 0}
+	return file_log_proto_rawDescGZIP(), []int{16, 0}
+}
+
+type SessionEnded_Reason int32
+
+const (
+	SessionEnded_UNKNOWN      SessionEnded_Reason = 0
+	SessionEnded_EXIT         SessionEnded_Reason = 1 // The program exited or the client disconnected.
+	SessionEnded_IDLE_TIMEOUT SessionEnded_Reason = 2 // No input was received for too long.
+	SessionEnded_MAX_DURATION SessionEnded_Reason = 3 // The session reached its maximum lifetime.
+	SessionEnded_SHUTDOWN     SessionEnded_Reason = 4 // The honeypot shut down.
+)
+
+// Enum value maps for SessionEnded_Reason.
+var (
+	SessionEnded_Reason_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "EXIT",
+		2: "IDLE_TIMEOUT",
+		3: "MAX_DURATION",
+		4: "SHUTDOWN",
+	}
+	SessionEnded_Reason_value = map[string]int32{
+		"UNKNOWN":      0,
+		"EXIT":         1,
+		"IDLE_TIMEOUT": 2,
+		"MAX_DURATION": 3,
+		"SHUTDOWN":     4,
+	}
+)
+
+func (x SessionEnded_Reason) Enum() *SessionEnded_Reason {
+	p := new(SessionEnded_Reason)
+	*p = x
+	return p
+}
+
+func (x SessionEnded_Reason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SessionEnded_Reason) Descriptor() protoreflect.EnumDescriptor {
+	return file_log_proto_enumTypes[6].Descriptor()
+}
+
+func (SessionEnded_Reason) Type() protoreflect.EnumType {
+	return &file_log_proto_enumTypes[6]
+}
+
+func (x SessionEnded_Reason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SessionEnded_Reason.Descriptor instead.
+func (SessionEnded_Reason) EnumDescriptor() ([]byte, []int) {
+	return file_log_proto_rawDescGZIP(), []int{17, 0}
 }
 
 type LogEntry struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Timestamp of the log event in micros since the UNIX epoch.
 	TimestampMicros int64 `protobuf:"varint,1,opt,name=timestamp_micros,json=timestampMicros,proto3" json:"timestamp_micros,omitempty"`
-	// Unique session identifier for the log message. Blank if the event
-	// wasn't in the context of a session.
+	// Unique identifier of the channel (shell, command or subsystem) the event
+	// happened in. Blank if the event wasn't in the context of a session.
 	SessionId string `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// Name of the listener the event came from. Blank if the honeypot has no
+	// named listeners.
+	Listener string `protobuf:"bytes,3,opt,name=listener,proto3" json:"listener,omitempty"`
+	// Unique identifier of the connection the event happened on, shared by
+	// failed logins and every session on the connection. Blank if the event
+	// wasn't in the context of a connection.
+	ConnectionId string `protobuf:"bytes,4,opt,name=connection_id,json=connectionId,proto3" json:"connection_id,omitempty"`
 	// Types that are valid to be assigned to LogType:
 	//
 	//	*LogEntry_LoginAttempt
@@ -194,6 +440,8 @@ type LogEntry struct {
 	//	*LogEntry_Panic
 	//	*LogEntry_HoneypotEvent
 	//	*LogEntry_SessionEnded
+	//	*LogEntry_SftpRequest
+	//	*LogEntry_PortForward
 	LogType       isLogEntry_LogType `protobuf_oneof:"log_type"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -243,6 +491,20 @@ func (x *LogEntry) GetSessionId() string {
 	return ""
 }
 
+func (x *LogEntry) GetListener() string {
+	if x != nil {
+		return x.Listener
+	}
+	return ""
+}
+
+func (x *LogEntry) GetConnectionId() string {
+	if x != nil {
+		return x.ConnectionId
+	}
+	return ""
+}
+
 func (x *LogEntry) GetLogType() isLogEntry_LogType {
 	if x != nil {
 		return x.LogType
@@ -376,6 +638,24 @@ func (x *LogEntry) GetSessionEnded() *SessionEnded {
 	return nil
 }
 
+func (x *LogEntry) GetSftpRequest() *SFTPRequest {
+	if x != nil {
+		if x, ok := x.LogType.(*LogEntry_SftpRequest); ok {
+			return x.SftpRequest
+		}
+	}
+	return nil
+}
+
+func (x *LogEntry) GetPortForward() *PortForward {
+	if x != nil {
+		if x, ok := x.LogType.(*LogEntry_PortForward); ok {
+			return x.PortForward
+		}
+	}
+	return nil
+}
+
 type isLogEntry_LogType interface {
 	isLogEntry_LogType()
 }
@@ -437,6 +717,14 @@ type LogEntry_SessionEnded struct {
 	SessionEnded *SessionEnded `protobuf:"bytes,28,opt,name=session_ended,json=sessionEnded,proto3,oneof"`
 }
 
+type LogEntry_SftpRequest struct {
+	SftpRequest *SFTPRequest `protobuf:"bytes,29,opt,name=sftp_request,json=sftpRequest,proto3,oneof"`
+}
+
+type LogEntry_PortForward struct {
+	PortForward *PortForward `protobuf:"bytes,30,opt,name=port_forward,json=portForward,proto3,oneof"`
+}
+
 func (*LogEntry_LoginAttempt) isLogEntry_LogType() {}
 
 func (*LogEntry_FilesystemOperation) isLogEntry_LogType() {}
@@ -465,8 +753,29 @@ func (*LogEntry_HoneypotEvent) isLogEntry_LogType() {}
 
 func (*LogEntry_SessionEnded) isLogEntry_LogType() {}
 
+func (*LogEntry_SftpRequest) isLogEntry_LogType() {}
+
+func (*LogEntry_PortForward) isLogEntry_LogType() {}
+
+// A change to the filesystem made by a process.
 type FilesystemOp struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The operation that was performed.
+	Type FilesystemOp_Type `protobuf:"varint,1,opt,name=type,proto3,enum=FilesystemOp_Type" json:"type,omitempty"`
+	// Absolute path the operation was performed on.
+	Path string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	// Destination of renames and the target of symlinks.
+	NewPath string `protobuf:"bytes,3,opt,name=new_path,json=newPath,proto3" json:"new_path,omitempty"`
+	// Mode passed to chmod and mkdir.
+	Mode uint32 `protobuf:"varint,4,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Whether the operation succeeded.
+	Result OperationResult `protobuf:"varint,5,opt,name=result,proto3,enum=OperationResult" json:"result,omitempty"`
+	// Error returned to the process if the operation failed.
+	Error string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	// Process that performed the operation.
+	Process *Process `protobuf:"bytes,7,opt,name=process,proto3" json:"process,omitempty"`
+	// Layer the operation used, unknown if it failed.
+	Layer         FilesystemOp_Layer `protobuf:"varint,8,opt,name=layer,proto3,enum=FilesystemOp_Layer" json:"layer,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -501,6 +810,117 @@ func (*FilesystemOp) Descriptor() ([]byte, []int) {
 	return file_log_proto_rawDescGZIP(), []int{1}
 }
 
+func (x *FilesystemOp) GetType() FilesystemOp_Type {
+	if x != nil {
+		return x.Type
+	}
+	return FilesystemOp_UNKNOWN
+}
+
+func (x *FilesystemOp) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FilesystemOp) GetNewPath() string {
+	if x != nil {
+		return x.NewPath
+	}
+	return ""
+}
+
+func (x *FilesystemOp) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *FilesystemOp) GetResult() OperationResult {
+	if x != nil {
+		return x.Result
+	}
+	return OperationResult_UNKNOWN
+}
+
+func (x *FilesystemOp) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *FilesystemOp) GetProcess() *Process {
+	if x != nil {
+		return x.Process
+	}
+	return nil
+}
+
+func (x *FilesystemOp) GetLayer() FilesystemOp_Layer {
+	if x != nil {
+		return x.Layer
+	}
+	return FilesystemOp_UNKNOWN_LAYER
+}
+
+// A process in the session.
+type Process struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Process ID.
+	Pid int32 `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	// Command line arguments including the command.
+	Command       []string `protobuf:"bytes,2,rep,name=command,proto3" json:"command,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Process) Reset() {
+	*x = Process{}
+	mi := &file_log_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Process) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Process) ProtoMessage() {}
+
+func (x *Process) ProtoReflect() protoreflect.Message {
+	mi := &file_log_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Process.ProtoReflect.Descriptor instead.
+func (*Process) Descriptor() ([]byte, []int) {
+	return file_log_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Process) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *Process) GetCommand() []string {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
 type LoginAttempt struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// The result of the login attempt.
@@ -520,14 +940,26 @@ type LoginAttempt struct {
 	// Raw command string as passed to SSH.
 	RawCommand string `protobuf:"bytes,8,opt,name=raw_command,json=rawCommand,proto3" json:"raw_command,omitempty"`
 	// The SSH subsystem requested.
-	Subsystem     string `protobuf:"bytes,9,opt,name=subsystem,proto3" json:"subsystem,omitempty"`
+	Subsystem string `protobuf:"bytes,9,opt,name=subsystem,proto3" json:"subsystem,omitempty"`
+	// The authentication method used e.g. password, publickey or
+	// keyboard-interactive.
+	AuthMethod string `protobuf:"bytes,10,opt,name=auth_method,json=authMethod,proto3" json:"auth_method,omitempty"`
+	// The prompt that was answered for keyboard-interactive logins.
+	Prompt string `protobuf:"bytes,11,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	// Identifying information about the connecting client.
+	Client *SSHClient `protobuf:"bytes,12,opt,name=client,proto3" json:"client,omitempty"`
+	// Where the accepted public key came from for publickey logins.
+	KeySource LoginAttempt_KeySource `protobuf:"varint,13,opt,name=key_source,json=keySource,proto3,enum=LoginAttempt_KeySource" json:"key_source,omitempty"`
+	// Identifying information about the connecting telnet client, set for
+	// logins over telnet.
+	TelnetClient  *TelnetClient `protobuf:"bytes,14,opt,name=telnet_client,json=telnetClient,proto3" json:"telnet_client,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *LoginAttempt) Reset() {
 	*x = LoginAttempt{}
-	mi := &file_log_proto_msgTypes[2]
+	mi := &file_log_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -539,7 +971,7 @@ func (x *LoginAttempt) String() string {
 func (*LoginAttempt) ProtoMessage() {}
 
 func (x *LoginAttempt) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[2]
+	mi := &file_log_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -552,7 +984,7 @@ func (x *LoginAttempt) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LoginAttempt.ProtoReflect.Descriptor instead.
 func (*LoginAttempt) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{2}
+	return file_log_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *LoginAttempt) GetResult() OperationResult {
@@ -618,6 +1050,225 @@ func (x *LoginAttempt) GetSubsystem() string {
 	return ""
 }
 
+func (x *LoginAttempt) GetAuthMethod() string {
+	if x != nil {
+		return x.AuthMethod
+	}
+	return ""
+}
+
+func (x *LoginAttempt) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *LoginAttempt) GetClient() *SSHClient {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+func (x *LoginAttempt) GetKeySource() LoginAttempt_KeySource {
+	if x != nil {
+		return x.KeySource
+	}
+	return LoginAttempt_UNKNOWN
+}
+
+func (x *LoginAttempt) GetTelnetClient() *TelnetClient {
+	if x != nil {
+		return x.TelnetClient
+	}
+	return nil
+}
+
+// Identifying information an SSH client sends before authenticating.
+type SSHClient struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Identification string e.g. SSH-2.0-OpenSSH_8.2p1.
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// Algorithms offered in the client's KEXINIT in preference order.
+	KexAlgorithms           []string `protobuf:"bytes,2,rep,name=kex_algorithms,json=kexAlgorithms,proto3" json:"kex_algorithms,omitempty"`
+	HostKeyAlgorithms       []string `protobuf:"bytes,3,rep,name=host_key_algorithms,json=hostKeyAlgorithms,proto3" json:"host_key_algorithms,omitempty"`
+	CiphersClientServer     []string `protobuf:"bytes,4,rep,name=ciphers_client_server,json=ciphersClientServer,proto3" json:"ciphers_client_server,omitempty"`
+	CiphersServerClient     []string `protobuf:"bytes,5,rep,name=ciphers_server_client,json=ciphersServerClient,proto3" json:"ciphers_server_client,omitempty"`
+	MacsClientServer        []string `protobuf:"bytes,6,rep,name=macs_client_server,json=macsClientServer,proto3" json:"macs_client_server,omitempty"`
+	MacsServerClient        []string `protobuf:"bytes,7,rep,name=macs_server_client,json=macsServerClient,proto3" json:"macs_server_client,omitempty"`
+	CompressionClientServer []string `protobuf:"bytes,8,rep,name=compression_client_server,json=compressionClientServer,proto3" json:"compression_client_server,omitempty"`
+	CompressionServerClient []string `protobuf:"bytes,9,rep,name=compression_server_client,json=compressionServerClient,proto3" json:"compression_server_client,omitempty"`
+	// HASSH fingerprint of the client, see https://github.com/salesforce/hassh
+	Hassh string `protobuf:"bytes,10,opt,name=hassh,proto3" json:"hassh,omitempty"`
+	// The algorithm string the HASSH fingerprint was computed over.
+	HasshAlgorithms string `protobuf:"bytes,11,opt,name=hassh_algorithms,json=hasshAlgorithms,proto3" json:"hassh_algorithms,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SSHClient) Reset() {
+	*x = SSHClient{}
+	mi := &file_log_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SSHClient) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SSHClient) ProtoMessage() {}
+
+func (x *SSHClient) ProtoReflect() protoreflect.Message {
+	mi := &file_log_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SSHClient.ProtoReflect.Descriptor instead.
+func (*SSHClient) Descriptor() ([]byte, []int) {
+	return file_log_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SSHClient) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *SSHClient) GetKexAlgorithms() []string {
+	if x != nil {
+		return x.KexAlgorithms
+	}
+	return nil
+}
+
+func (x *SSHClient) GetHostKeyAlgorithms() []string {
+	if x != nil {
+		return x.HostKeyAlgorithms
+	}
+	return nil
+}
+
+func (x *SSHClient) GetCiphersClientServer() []string {
+	if x != nil {
+		return x.CiphersClientServer
+	}
+	return nil
+}
+
+func (x *SSHClient) GetCiphersServerClient() []string {
+	if x != nil {
+		return x.CiphersServerClient
+	}
+	return nil
+}
+
+func (x *SSHClient) GetMacsClientServer() []string {
+	if x != nil {
+		return x.MacsClientServer
+	}
+	return nil
+}
+
+func (x *SSHClient) GetMacsServerClient() []string {
+	if x != nil {
+		return x.MacsServerClient
+	}
+	return nil
+}
+
+func (x *SSHClient) GetCompressionClientServer() []string {
+	if x != nil {
+		return x.CompressionClientServer
+	}
+	return nil
+}
+
+func (x *SSHClient) GetCompressionServerClient() []string {
+	if x != nil {
+		return x.CompressionServerClient
+	}
+	return nil
+}
+
+func (x *SSHClient) GetHassh() string {
+	if x != nil {
+		return x.Hassh
+	}
+	return ""
+}
+
+func (x *SSHClient) GetHasshAlgorithms() string {
+	if x != nil {
+		return x.HasshAlgorithms
+	}
+	return ""
+}
+
+// Identifying information a telnet client sends while negotiating options.
+type TelnetClient struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Terminal type the client sent e.g. XTERM.
+	TerminalType string `protobuf:"bytes,1,opt,name=terminal_type,json=terminalType,proto3" json:"terminal_type,omitempty"`
+	// Option commands the client sent in order e.g. "WILL NAWS".
+	Negotiation   []string `protobuf:"bytes,2,rep,name=negotiation,proto3" json:"negotiation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TelnetClient) Reset() {
+	*x = TelnetClient{}
+	mi := &file_log_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TelnetClient) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TelnetClient) ProtoMessage() {}
+
+func (x *TelnetClient) ProtoReflect() protoreflect.Message {
+	mi := &file_log_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TelnetClient.ProtoReflect.Descriptor instead.
+func (*TelnetClient) Descriptor() ([]byte, []int) {
+	return file_log_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TelnetClient) GetTerminalType() string {
+	if x != nil {
+		return x.TerminalType
+	}
+	return ""
+}
+
+func (x *TelnetClient) GetNegotiation() []string {
+	if x != nil {
+		return x.Negotiation
+	}
+	return nil
+}
+
 type OpenTTYLog struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -627,7 +1278,7 @@ type OpenTTYLog struct {
 
 func (x *OpenTTYLog) Reset() {
 	*x = OpenTTYLog{}
-	mi := &file_log_proto_msgTypes[3]
+	mi := &file_log_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -639,7 +1290,7 @@ func (x *OpenTTYLog) String() string {
 func (*OpenTTYLog) ProtoMessage() {}
 
 func (x *OpenTTYLog) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[3]
+	mi := &file_log_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -652,7 +1303,7 @@ func (x *OpenTTYLog) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OpenTTYLog.ProtoReflect.Descriptor instead.
 func (*OpenTTYLog) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{3}
+	return file_log_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *OpenTTYLog) GetName() string {
@@ -670,7 +1321,7 @@ type ConnectionLost struct {
 
 func (x *ConnectionLost) Reset() {
 	*x = ConnectionLost{}
-	mi := &file_log_proto_msgTypes[4]
+	mi := &file_log_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -682,7 +1333,7 @@ func (x *ConnectionLost) String() string {
 func (*ConnectionLost) ProtoMessage() {}
 
 func (x *ConnectionLost) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[4]
+	mi := &file_log_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -695,7 +1346,7 @@ func (x *ConnectionLost) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConnectionLost.ProtoReflect.Descriptor instead.
 func (*ConnectionLost) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{4}
+	return file_log_proto_rawDescGZIP(), []int{7}
 }
 
 type RunCommand struct {
@@ -712,7 +1363,7 @@ type RunCommand struct {
 
 func (x *RunCommand) Reset() {
 	*x = RunCommand{}
-	mi := &file_log_proto_msgTypes[5]
+	mi := &file_log_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -724,7 +1375,7 @@ func (x *RunCommand) String() string {
 func (*RunCommand) ProtoMessage() {}
 
 func (x *RunCommand) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[5]
+	mi := &file_log_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -737,7 +1388,7 @@ func (x *RunCommand) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RunCommand.ProtoReflect.Descriptor instead.
 func (*RunCommand) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{5}
+	return file_log_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *RunCommand) GetCommand() []string {
@@ -775,7 +1426,7 @@ type UnknownCommand struct {
 
 func (x *UnknownCommand) Reset() {
 	*x = UnknownCommand{}
-	mi := &file_log_proto_msgTypes[6]
+	mi := &file_log_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -787,7 +1438,7 @@ func (x *UnknownCommand) String() string {
 func (*UnknownCommand) ProtoMessage() {}
 
 func (x *UnknownCommand) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[6]
+	mi := &file_log_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -800,7 +1451,7 @@ func (x *UnknownCommand) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UnknownCommand.ProtoReflect.Descriptor instead.
 func (*UnknownCommand) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{6}
+	return file_log_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *UnknownCommand) GetCommand() []string {
@@ -840,7 +1491,7 @@ type TerminalUpdate struct {
 
 func (x *TerminalUpdate) Reset() {
 	*x = TerminalUpdate{}
-	mi := &file_log_proto_msgTypes[7]
+	mi := &file_log_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -852,7 +1503,7 @@ func (x *TerminalUpdate) String() string {
 func (*TerminalUpdate) ProtoMessage() {}
 
 func (x *TerminalUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[7]
+	mi := &file_log_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -865,7 +1516,7 @@ func (x *TerminalUpdate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TerminalUpdate.ProtoReflect.Descriptor instead.
 func (*TerminalUpdate) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{7}
+	return file_log_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *TerminalUpdate) GetWidth() int32 {
@@ -898,15 +1549,25 @@ func (x *TerminalUpdate) GetIsPty() bool {
 
 type OpenFile struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Path of the file that was opened.
-	Path          string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Absolute path of the file that was opened.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Flags the file was opened with e.g. O_WRONLY|O_CREATE.
+	Flags string `protobuf:"bytes,2,opt,name=flags,proto3" json:"flags,omitempty"`
+	// Whether the file was opened.
+	Result OperationResult `protobuf:"varint,3,opt,name=result,proto3,enum=OperationResult" json:"result,omitempty"`
+	// Error returned to the process if the open failed.
+	Error string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	// Process that opened the file.
+	Process *Process `protobuf:"bytes,5,opt,name=process,proto3" json:"process,omitempty"`
+	// Layer the file was opened from, unknown if the open failed.
+	Layer         FilesystemOp_Layer `protobuf:"varint,6,opt,name=layer,proto3,enum=FilesystemOp_Layer" json:"layer,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *OpenFile) Reset() {
 	*x = OpenFile{}
-	mi := &file_log_proto_msgTypes[8]
+	mi := &file_log_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -918,7 +1579,7 @@ func (x *OpenFile) String() string {
 func (*OpenFile) ProtoMessage() {}
 
 func (x *OpenFile) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[8]
+	mi := &file_log_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -931,7 +1592,7 @@ func (x *OpenFile) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OpenFile.ProtoReflect.Descriptor instead.
 func (*OpenFile) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{8}
+	return file_log_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *OpenFile) GetPath() string {
@@ -941,6 +1602,41 @@ func (x *OpenFile) GetPath() string {
 	return ""
 }
 
+func (x *OpenFile) GetFlags() string {
+	if x != nil {
+		return x.Flags
+	}
+	return ""
+}
+
+func (x *OpenFile) GetResult() OperationResult {
+	if x != nil {
+		return x.Result
+	}
+	return OperationResult_UNKNOWN
+}
+
+func (x *OpenFile) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *OpenFile) GetProcess() *Process {
+	if x != nil {
+		return x.Process
+	}
+	return nil
+}
+
+func (x *OpenFile) GetLayer() FilesystemOp_Layer {
+	if x != nil {
+		return x.Layer
+	}
+	return FilesystemOp_UNKNOWN_LAYER
+}
+
 // A potential missing Honeypot feature, should be reported or fixed.
 type InvalidInvocation struct {
 	state   protoimpl.MessageState `protogen:"open.v1"`
@@ -960,7 +1656,7 @@ type InvalidInvocation struct {
 
 func (x *InvalidInvocation) Reset() {
 	*x = InvalidInvocation{}
-	mi := &file_log_proto_msgTypes[9]
+	mi := &file_log_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -972,7 +1668,7 @@ func (x *InvalidInvocation) String() string {
 func (*InvalidInvocation) ProtoMessage() {}
 
 func (x *InvalidInvocation) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[9]
+	mi := &file_log_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -985,7 +1681,7 @@ func (x *InvalidInvocation) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InvalidInvocation.ProtoReflect.Descriptor instead.
 func (*InvalidInvocation) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{9}
+	return file_log_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *InvalidInvocation) GetCommand() []string {
@@ -1038,14 +1734,16 @@ type Credentials struct {
 	// Password used to authenticate.
 	Password string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
 	// Private key used to authenticate.
-	PrivateKey    []byte `protobuf:"bytes,3,opt,name=private_key,json=privateKey,proto3" json:"private_key,omitempty"`
+	PrivateKey []byte `protobuf:"bytes,3,opt,name=private_key,json=privateKey,proto3" json:"private_key,omitempty"`
+	// Public key authorized to log in, in SSH wire format.
+	PublicKey     []byte `protobuf:"bytes,4,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Credentials) Reset() {
 	*x = Credentials{}
-	mi := &file_log_proto_msgTypes[10]
+	mi := &file_log_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1057,7 +1755,7 @@ func (x *Credentials) String() string {
 func (*Credentials) ProtoMessage() {}
 
 func (x *Credentials) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[10]
+	mi := &file_log_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1070,7 +1768,7 @@ func (x *Credentials) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Credentials.ProtoReflect.Descriptor instead.
 func (*Credentials) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{10}
+	return file_log_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *Credentials) GetUsername() string {
@@ -1094,6 +1792,13 @@ func (x *Credentials) GetPrivateKey() []byte {
 	return nil
 }
 
+func (x *Credentials) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
 // Information about a downloaded file.
 type Download struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -1109,7 +1814,7 @@ type Download struct {
 
 func (x *Download) Reset() {
 	*x = Download{}
-	mi := &file_log_proto_msgTypes[11]
+	mi := &file_log_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1121,7 +1826,7 @@ func (x *Download) String() string {
 func (*Download) ProtoMessage() {}
 
 func (x *Download) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[11]
+	mi := &file_log_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1134,7 +1839,7 @@ func (x *Download) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Download.ProtoReflect.Descriptor instead.
 func (*Download) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{11}
+	return file_log_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *Download) GetName() string {
@@ -1171,7 +1876,7 @@ type Panic struct {
 
 func (x *Panic) Reset() {
 	*x = Panic{}
-	mi := &file_log_proto_msgTypes[12]
+	mi := &file_log_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1183,7 +1888,7 @@ func (x *Panic) String() string {
 func (*Panic) ProtoMessage() {}
 
 func (x *Panic) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[12]
+	mi := &file_log_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1196,7 +1901,7 @@ func (x *Panic) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Panic.ProtoReflect.Descriptor instead.
 func (*Panic) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{12}
+	return file_log_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *Panic) GetContext() string {
@@ -1217,14 +1922,18 @@ func (x *Panic) GetStacktrace() string {
 type HoneypotEvent struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Context about what was going on before the panic.
-	EventType     HoneypotEvent_Type `protobuf:"varint,1,opt,name=event_type,json=eventType,proto3,enum=HoneypotEvent_Type" json:"event_type,omitempty"`
+	EventType HoneypotEvent_Type `protobuf:"varint,1,opt,name=event_type,json=eventType,proto3,enum=HoneypotEvent_Type" json:"event_type,omitempty"`
+	// Remote address of the client the event is about, if any.
+	RemoteAddr string `protobuf:"bytes,2,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
+	// Error that caused the event, if any.
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *HoneypotEvent) Reset() {
 	*x = HoneypotEvent{}
-	mi := &file_log_proto_msgTypes[13]
+	mi := &file_log_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1236,7 +1945,7 @@ func (x *HoneypotEvent) String() string {
 func (*HoneypotEvent) ProtoMessage() {}
 
 func (x *HoneypotEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[13]
+	mi := &file_log_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1249,7 +1958,7 @@ func (x *HoneypotEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HoneypotEvent.ProtoReflect.Descriptor instead.
 func (*HoneypotEvent) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{13}
+	return file_log_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *HoneypotEvent) GetEventType() HoneypotEvent_Type {
@@ -1259,6 +1968,20 @@ func (x *HoneypotEvent) GetEventType() HoneypotEvent_Type {
 	return HoneypotEvent_UNKNOWN
 }
 
+func (x *HoneypotEvent) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
+}
+
+func (x *HoneypotEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 // Summary reported at the end of a session.
 type SessionEnded struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -1268,13 +1991,18 @@ type SessionEnded struct {
 	HumanKeypressCount int64 `protobuf:"varint,2,opt,name=human_keypress_count,json=humanKeypressCount,proto3" json:"human_keypress_count,omitempty"`
 	// Number of bytes written through stdin.
 	StdinByteCount int64 `protobuf:"varint,3,opt,name=stdin_byte_count,json=stdinByteCount,proto3" json:"stdin_byte_count,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// Why the session ended.
+	Reason SessionEnded_Reason `protobuf:"varint,4,opt,name=reason,proto3,enum=SessionEnded_Reason" json:"reason,omitempty"`
+	// Name of the tar.gz of files the session created or modified in the
+	// session log directory, empty if the filesystem wasn't changed.
+	FilesystemChanges string `protobuf:"bytes,5,opt,name=filesystem_changes,json=filesystemChanges,proto3" json:"filesystem_changes,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *SessionEnded) Reset() {
 	*x = SessionEnded{}
-	mi := &file_log_proto_msgTypes[14]
+	mi := &file_log_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1286,7 +2014,7 @@ func (x *SessionEnded) String() string {
 func (*SessionEnded) ProtoMessage() {}
 
 func (x *SessionEnded) ProtoReflect() protoreflect.Message {
-	mi := &file_log_proto_msgTypes[14]
+	mi := &file_log_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1299,7 +2027,7 @@ func (x *SessionEnded) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SessionEnded.ProtoReflect.Descriptor instead.
 func (*SessionEnded) Descriptor() ([]byte, []int) {
-	return file_log_proto_rawDescGZIP(), []int{14}
+	return file_log_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *SessionEnded) GetDurationMs() int64 {
@@ -1323,15 +2051,259 @@ func (x *SessionEnded) GetStdinByteCount() int64 {
 	return 0
 }
 
+func (x *SessionEnded) GetReason() SessionEnded_Reason {
+	if x != nil {
+		return x.Reason
+	}
+	return SessionEnded_UNKNOWN
+}
+
+func (x *SessionEnded) GetFilesystemChanges() string {
+	if x != nil {
+		return x.FilesystemChanges
+	}
+	return ""
+}
+
+// An operation requested through the SFTP subsystem.
+type SFTPRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Request method e.g. Get, Put, Rename, Remove, Setstat or Write.
+	Method string `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	// Path the operation was performed on.
+	Path string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	// Destination path for renames and links.
+	Target string `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	// Flags the file was opened with e.g. read, write, create.
+	OpenFlags []string `protobuf:"bytes,4,rep,name=open_flags,json=openFlags,proto3" json:"open_flags,omitempty"`
+	// Permissions set by Setstat requests.
+	Mode uint32 `protobuf:"varint,5,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Number of bytes written to the file before it was closed.
+	BytesWritten int64 `protobuf:"varint,6,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	// Whether the operation succeeded.
+	Result OperationResult `protobuf:"varint,7,opt,name=result,proto3,enum=OperationResult" json:"result,omitempty"`
+	// The error returned to the client, if any.
+	Error         string `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SFTPRequest) Reset() {
+	*x = SFTPRequest{}
+	mi := &file_log_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SFTPRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SFTPRequest) ProtoMessage() {}
+
+func (x *SFTPRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_log_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SFTPRequest.ProtoReflect.Descriptor instead.
+func (*SFTPRequest) Descriptor() ([]byte, []int) {
+	return file_log_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SFTPRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *SFTPRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SFTPRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *SFTPRequest) GetOpenFlags() []string {
+	if x != nil {
+		return x.OpenFlags
+	}
+	return nil
+}
+
+func (x *SFTPRequest) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *SFTPRequest) GetBytesWritten() int64 {
+	if x != nil {
+		return x.BytesWritten
+	}
+	return 0
+}
+
+func (x *SFTPRequest) GetResult() OperationResult {
+	if x != nil {
+		return x.Result
+	}
+	return OperationResult_UNKNOWN
+}
+
+func (x *SFTPRequest) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// A port forwarding request e.g. from ssh -L, -D or -R. No real connections
+// are made.
+type PortForward struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Type of the request: direct-tcpip, tcpip-forward or cancel-tcpip-forward.
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// Host the client asked to connect to or listen on.
+	Host string `protobuf:"bytes,2,opt,name=host,proto3" json:"host,omitempty"`
+	// Port the client asked to connect to or listen on.
+	Port uint32 `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	// Originator of a direct-tcpip connection as reported by the client.
+	OriginHost string `protobuf:"bytes,4,opt,name=origin_host,json=originHost,proto3" json:"origin_host,omitempty"`
+	// Originator port of a direct-tcpip connection as reported by the client.
+	OriginPort uint32 `protobuf:"varint,5,opt,name=origin_port,json=originPort,proto3" json:"origin_port,omitempty"`
+	// The first bytes the client sent through the channel.
+	Payload []byte `protobuf:"bytes,6,opt,name=payload,proto3" json:"payload,omitempty"`
+	// Total number of bytes the client sent through the channel.
+	ByteCount int64 `protobuf:"varint,7,opt,name=byte_count,json=byteCount,proto3" json:"byte_count,omitempty"`
+	// Whether a canned response was sent to the client.
+	Responded bool `protobuf:"varint,8,opt,name=responded,proto3" json:"responded,omitempty"`
+	// Remote address of the SSH connection.
+	RemoteAddr    string `protobuf:"bytes,9,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PortForward) Reset() {
+	*x = PortForward{}
+	mi := &file_log_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PortForward) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PortForward) ProtoMessage() {}
+
+func (x *PortForward) ProtoReflect() protoreflect.Message {
+	mi := &file_log_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PortForward.ProtoReflect.Descriptor instead.
+func (*PortForward) Descriptor() ([]byte, []int) {
+	return file_log_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *PortForward) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *PortForward) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *PortForward) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *PortForward) GetOriginHost() string {
+	if x != nil {
+		return x.OriginHost
+	}
+	return ""
+}
+
+func (x *PortForward) GetOriginPort() uint32 {
+	if x != nil {
+		return x.OriginPort
+	}
+	return 0
+}
+
+func (x *PortForward) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *PortForward) GetByteCount() int64 {
+	if x != nil {
+		return x.ByteCount
+	}
+	return 0
+}
+
+func (x *PortForward) GetResponded() bool {
+	if x != nil {
+		return x.Responded
+	}
+	return false
+}
+
+func (x *PortForward) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
+}
+
 var File_log_proto protoreflect.FileDescriptor
 
 const file_log_proto_rawDesc = "" +
 	"\n" +
-	"\tlog.proto\"\xd7\x06\n" +
+	"\tlog.proto\"\xfe\a\n" +
 	"\bLogEntry\x12)\n" +
 	"\x10timestamp_micros\x18\x01 \x01(\x03R\x0ftimestampMicros\x12\x1d\n" +
 	"\n" +
-	"session_id\x18\x02 \x01(\tR\tsessionId\x124\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12\x1a\n" +
+	"\blistener\x18\x03 \x01(\tR\blistener\x12#\n" +
+	"\rconnection_id\x18\x04 \x01(\tR\fconnectionId\x124\n" +
 	"\rlogin_attempt\x18\x0f \x01(\v2\r.LoginAttemptH\x00R\floginAttempt\x12B\n" +
 	"\x14filesystem_operation\x18\x10 \x01(\v2\r.FilesystemOpH\x00R\x13filesystemOperation\x12/\n" +
 	"\fopen_tty_log\x18\x11 \x01(\v2\v.OpenTTYLogH\x00R\n" +
@@ -1347,10 +2319,38 @@ const file_log_proto_rawDesc = "" +
 	"\bdownload\x18\x19 \x01(\v2\t.DownloadH\x00R\bdownload\x12\x1e\n" +
 	"\x05panic\x18\x1a \x01(\v2\x06.PanicH\x00R\x05panic\x127\n" +
 	"\x0ehoneypot_event\x18\x1b \x01(\v2\x0e.HoneypotEventH\x00R\rhoneypotEvent\x124\n" +
-	"\rsession_ended\x18\x1c \x01(\v2\r.SessionEndedH\x00R\fsessionEndedB\n" +
+	"\rsession_ended\x18\x1c \x01(\v2\r.SessionEndedH\x00R\fsessionEnded\x121\n" +
+	"\fsftp_request\x18\x1d \x01(\v2\f.SFTPRequestH\x00R\vsftpRequest\x121\n" +
+	"\fport_forward\x18\x1e \x01(\v2\f.PortForwardH\x00R\vportForwardB\n" +
+	"\n" +
+	"\blog_typeJ\x04\b\x05\x10\x0f\"\x97\x03\n" +
+	"\fFilesystemOp\x12&\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x12.FilesystemOp.TypeR\x04type\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x19\n" +
+	"\bnew_path\x18\x03 \x01(\tR\anewPath\x12\x12\n" +
+	"\x04mode\x18\x04 \x01(\rR\x04mode\x12(\n" +
+	"\x06result\x18\x05 \x01(\x0e2\x10.OperationResultR\x06result\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x12\"\n" +
+	"\aprocess\x18\a \x01(\v2\b.ProcessR\aprocess\x12)\n" +
+	"\x05layer\x18\b \x01(\x0e2\x13.FilesystemOp.LayerR\x05layer\"Z\n" +
+	"\x04Type\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\n" +
+	"\n" +
+	"\x06CREATE\x10\x01\x12\t\n" +
+	"\x05MKDIR\x10\x02\x12\n" +
 	"\n" +
-	"\blog_typeJ\x04\b\x03\x10\x0f\"\x0e\n" +
-	"\fFilesystemOp\"\xbe\x02\n" +
+	"\x06RENAME\x10\x03\x12\n" +
+	"\n" +
+	"\x06REMOVE\x10\x04\x12\t\n" +
+	"\x05CHMOD\x10\x05\x12\v\n" +
+	"\aSYMLINK\x10\x06\"1\n" +
+	"\x05Layer\x12\x11\n" +
+	"\rUNKNOWN_LAYER\x10\x00\x12\b\n" +
+	"\x04BASE\x10\x01\x12\v\n" +
+	"\aOVERLAY\x10\x02\"5\n" +
+	"\aProcess\x12\x10\n" +
+	"\x03pid\x18\x01 \x01(\x05R\x03pid\x12\x18\n" +
+	"\acommand\x18\x02 \x03(\tR\acommand\"\xd2\x04\n" +
 	"\fLoginAttempt\x12(\n" +
 	"\x06result\x18\x01 \x01(\x0e2\x10.OperationResultR\x06result\x12\x1a\n" +
 	"\busername\x18\x02 \x01(\tR\busername\x12\x1d\n" +
@@ -1363,7 +2363,39 @@ const file_log_proto_rawDesc = "" +
 	"\acommand\x18\a \x03(\tR\acommand\x12\x1f\n" +
 	"\vraw_command\x18\b \x01(\tR\n" +
 	"rawCommand\x12\x1c\n" +
-	"\tsubsystem\x18\t \x01(\tR\tsubsystem\" \n" +
+	"\tsubsystem\x18\t \x01(\tR\tsubsystem\x12\x1f\n" +
+	"\vauth_method\x18\n" +
+	" \x01(\tR\n" +
+	"authMethod\x12\x16\n" +
+	"\x06prompt\x18\v \x01(\tR\x06prompt\x12\"\n" +
+	"\x06client\x18\f \x01(\v2\n" +
+	".SSHClientR\x06client\x126\n" +
+	"\n" +
+	"key_source\x18\r \x01(\x0e2\x17.LoginAttempt.KeySourceR\tkeySource\x122\n" +
+	"\rtelnet_client\x18\x0e \x01(\v2\r.TelnetClientR\ftelnetClient\"I\n" +
+	"\tKeySource\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\n" +
+	"\n" +
+	"\x06CONFIG\x10\x01\x12\x13\n" +
+	"\x0fAUTHORIZED_KEYS\x10\x02\x12\x0e\n" +
+	"\n" +
+	"REMEMBERED\x10\x03\"\xf9\x03\n" +
+	"\tSSHClient\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12%\n" +
+	"\x0ekex_algorithms\x18\x02 \x03(\tR\rkexAlgorithms\x12.\n" +
+	"\x13host_key_algorithms\x18\x03 \x03(\tR\x11hostKeyAlgorithms\x122\n" +
+	"\x15ciphers_client_server\x18\x04 \x03(\tR\x13ciphersClientServer\x122\n" +
+	"\x15ciphers_server_client\x18\x05 \x03(\tR\x13ciphersServerClient\x12,\n" +
+	"\x12macs_client_server\x18\x06 \x03(\tR\x10macsClientServer\x12,\n" +
+	"\x12macs_server_client\x18\a \x03(\tR\x10macsServerClient\x12:\n" +
+	"\x19compression_client_server\x18\b \x03(\tR\x17compressionClientServer\x12:\n" +
+	"\x19compression_server_client\x18\t \x03(\tR\x17compressionServerClient\x12\x14\n" +
+	"\x05hassh\x18\n" +
+	" \x01(\tR\x05hassh\x12)\n" +
+	"\x10hassh_algorithms\x18\v \x01(\tR\x0fhasshAlgorithms\"U\n" +
+	"\fTelnetClient\x12#\n" +
+	"\rterminal_type\x18\x01 \x01(\tR\fterminalType\x12 \n" +
+	"\vnegotiation\x18\x02 \x03(\tR\vnegotiation\" \n" +
 	"\n" +
 	"OpenTTYLog\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\"\x10\n" +
@@ -1386,9 +2418,14 @@ const file_log_proto_rawDesc = "" +
 	"\x05width\x18\x01 \x01(\x05R\x05width\x12\x16\n" +
 	"\x06height\x18\x02 \x01(\x05R\x06height\x12\x12\n" +
 	"\x04term\x18\x03 \x01(\tR\x04term\x12\x15\n" +
-	"\x06is_pty\x18\x04 \x01(\bR\x05isPty\"\x1e\n" +
+	"\x06is_pty\x18\x04 \x01(\bR\x05isPty\"\xc3\x01\n" +
 	"\bOpenFile\x12\x12\n" +
-	"\x04path\x18\x01 \x01(\tR\x04path\"\xbf\x01\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
+	"\x05flags\x18\x02 \x01(\tR\x05flags\x12(\n" +
+	"\x06result\x18\x03 \x01(\x0e2\x10.OperationResultR\x06result\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\"\n" +
+	"\aprocess\x18\x05 \x01(\v2\b.ProcessR\aprocess\x12)\n" +
+	"\x05layer\x18\x06 \x01(\x0e2\x13.FilesystemOp.LayerR\x05layer\"\xbf\x01\n" +
 	"\x11InvalidInvocation\x12\x18\n" +
 	"\acommand\x18\x01 \x03(\tR\acommand\x12\x14\n" +
 	"\x05error\x18\x02 \x01(\tR\x05error\x12\x1f\n" +
@@ -1398,12 +2435,14 @@ const file_log_proto_rawDesc = "" +
 	"sourceLine\x12\x1f\n" +
 	"\vmod_version\x18\x05 \x01(\tR\n" +
 	"modVersion\x12\x17\n" +
-	"\amod_sum\x18\x06 \x01(\tR\x06modSum\"f\n" +
+	"\amod_sum\x18\x06 \x01(\tR\x06modSum\"\x85\x01\n" +
 	"\vCredentials\x12\x1a\n" +
 	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
 	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x1f\n" +
 	"\vprivate_key\x18\x03 \x01(\fR\n" +
-	"privateKey\"P\n" +
+	"privateKey\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x04 \x01(\fR\tpublicKey\"P\n" +
 	"\bDownload\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
 	"\x06source\x18\x02 \x01(\tR\x06source\x12\x18\n" +
@@ -1412,23 +2451,66 @@ const file_log_proto_rawDesc = "" +
 	"\acontext\x18\x01 \x01(\tR\acontext\x12\x1e\n" +
 	"\n" +
 	"stacktrace\x18\x02 \x01(\tR\n" +
-	"stacktrace\"r\n" +
+	"stacktrace\"\xa7\x02\n" +
 	"\rHoneypotEvent\x122\n" +
 	"\n" +
-	"event_type\x18\x01 \x01(\x0e2\x13.HoneypotEvent.TypeR\teventType\"-\n" +
+	"event_type\x18\x01 \x01(\x0e2\x13.HoneypotEvent.TypeR\teventType\x12\x1f\n" +
+	"\vremote_addr\x18\x02 \x01(\tR\n" +
+	"remoteAddr\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xaa\x01\n" +
 	"\x04Type\x12\v\n" +
 	"\aUNKNOWN\x10\x00\x12\t\n" +
 	"\x05START\x10\x01\x12\r\n" +
-	"\tTERMINATE\x10\x02\"\x8b\x01\n" +
+	"\tTERMINATE\x10\x02\x12\x14\n" +
+	"\x10CONNECTION_LIMIT\x10\x03\x12\x17\n" +
+	"\x13IP_CONNECTION_LIMIT\x10\x04\x12\x13\n" +
+	"\x0fAUTH_RATE_LIMIT\x10\x05\x12\x18\n" +
+	"\x14INVALID_PROXY_HEADER\x10\x06\x12\n" +
+	"\n" +
+	"\x06RELOAD\x10\a\x12\x11\n" +
+	"\rRELOAD_FAILED\x10\b\"\xbb\x02\n" +
 	"\fSessionEnded\x12\x1f\n" +
 	"\vduration_ms\x18\x01 \x01(\x03R\n" +
 	"durationMs\x120\n" +
 	"\x14human_keypress_count\x18\x02 \x01(\x03R\x12humanKeypressCount\x12(\n" +
-	"\x10stdin_byte_count\x18\x03 \x01(\x03R\x0estdinByteCount*8\n" +
+	"\x10stdin_byte_count\x18\x03 \x01(\x03R\x0estdinByteCount\x12,\n" +
+	"\x06reason\x18\x04 \x01(\x0e2\x14.SessionEnded.ReasonR\x06reason\x12-\n" +
+	"\x12filesystem_changes\x18\x05 \x01(\tR\x11filesystemChanges\"Q\n" +
+	"\x06Reason\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\b\n" +
+	"\x04EXIT\x10\x01\x12\x10\n" +
+	"\fIDLE_TIMEOUT\x10\x02\x12\x10\n" +
+	"\fMAX_DURATION\x10\x03\x12\f\n" +
+	"\bSHUTDOWN\x10\x04\"\xe9\x01\n" +
+	"\vSFTPRequest\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\tR\x06target\x12\x1d\n" +
+	"\n" +
+	"open_flags\x18\x04 \x03(\tR\topenFlags\x12\x12\n" +
+	"\x04mode\x18\x05 \x01(\rR\x04mode\x12#\n" +
+	"\rbytes_written\x18\x06 \x01(\x03R\fbytesWritten\x12(\n" +
+	"\x06result\x18\a \x01(\x0e2\x10.OperationResultR\x06result\x12\x14\n" +
+	"\x05error\x18\b \x01(\tR\x05error\"\x83\x02\n" +
+	"\vPortForward\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04host\x18\x02 \x01(\tR\x04host\x12\x12\n" +
+	"\x04port\x18\x03 \x01(\rR\x04port\x12\x1f\n" +
+	"\vorigin_host\x18\x04 \x01(\tR\n" +
+	"originHost\x12\x1f\n" +
+	"\vorigin_port\x18\x05 \x01(\rR\n" +
+	"originPort\x12\x18\n" +
+	"\apayload\x18\x06 \x01(\fR\apayload\x12\x1d\n" +
+	"\n" +
+	"byte_count\x18\a \x01(\x03R\tbyteCount\x12\x1c\n" +
+	"\tresponded\x18\b \x01(\bR\tresponded\x12\x1f\n" +
+	"\vremote_addr\x18\t \x01(\tR\n" +
+	"remoteAddr*J\n" +
 	"\x0fOperationResult\x12\v\n" +
 	"\aUNKNOWN\x10\x00\x12\v\n" +
 	"\aSUCCESS\x10\x01\x12\v\n" +
-	"\aFAILURE\x10\x02B/Z-github.com/josephlewis42/honeyssh/core/loggerb\x06proto3"
+	"\aFAILURE\x10\x02\x12\x10\n" +
+	"\fRATE_LIMITED\x10\x03B/Z-github.com/josephlewis42/honeyssh/core/loggerb\x06proto3"
 
 var (
 	file_log_proto_rawDescOnce sync.Once
@@ -1442,51 +2524,74 @@ func file_log_proto_rawDescGZIP() []byte {
 	return file_log_proto_rawDescData
 }
 
-var file_log_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_log_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_log_proto_enumTypes = make([]protoimpl.EnumInfo, 7)
+var file_log_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_log_proto_goTypes = []any{
 	(OperationResult)(0),                     // 0: OperationResult
-	(UnknownCommand_UnknownCommandStatus)(0), // 1: UnknownCommand.UnknownCommandStatus
-	(HoneypotEvent_Type)(0),                  // 2: HoneypotEvent.Type
-	(*LogEntry)(nil),                         // 3: LogEntry
-	(*FilesystemOp)(nil),                     // 4: FilesystemOp
-	(*LoginAttempt)(nil),                     // 5: LoginAttempt
-	(*OpenTTYLog)(nil),                       // 6: OpenTTYLog
-	(*ConnectionLost)(nil),                   // 7: ConnectionLost
-	(*RunCommand)(nil),                       // 8: RunCommand
-	(*UnknownCommand)(nil),                   // 9: UnknownCommand
-	(*TerminalUpdate)(nil),                   // 10: TerminalUpdate
-	(*OpenFile)(nil),                         // 11: OpenFile
-	(*InvalidInvocation)(nil),                // 12: InvalidInvocation
-	(*Credentials)(nil),                      // 13: Credentials
-	(*Download)(nil),                         // 14: Download
-	(*Panic)(nil),                            // 15: Panic
-	(*HoneypotEvent)(nil),                    // 16: HoneypotEvent
-	(*SessionEnded)(nil),                     // 17: SessionEnded
+	(FilesystemOp_Type)(0),                   // 1: FilesystemOp.Type
+	(FilesystemOp_Layer)(0),                  // 2: FilesystemOp.Layer
+	(LoginAttempt_KeySource)(0),              // 3: LoginAttempt.KeySource
+	(UnknownCommand_UnknownCommandStatus)(0), // 4: UnknownCommand.UnknownCommandStatus
+	(HoneypotEvent_Type)(0),                  // 5: HoneypotEvent.Type
+	(SessionEnded_Reason)(0),                 // 6: SessionEnded.Reason
+	(*LogEntry)(nil),                         // 7: LogEntry
+	(*FilesystemOp)(nil),                     // 8: FilesystemOp
+	(*Process)(nil),                          // 9: Process
+	(*LoginAttempt)(nil),                     // 10: LoginAttempt
+	(*SSHClient)(nil),                        // 11: SSHClient
+	(*TelnetClient)(nil),                     // 12: TelnetClient
+	(*OpenTTYLog)(nil),                       // 13: OpenTTYLog
+	(*ConnectionLost)(nil),                   // 14: ConnectionLost
+	(*RunCommand)(nil),                       // 15: RunCommand
+	(*UnknownCommand)(nil),                   // 16: UnknownCommand
+	(*TerminalUpdate)(nil),                   // 17: TerminalUpdate
+	(*OpenFile)(nil),                         // 18: OpenFile
+	(*InvalidInvocation)(nil),                // 19: InvalidInvocation
+	(*Credentials)(nil),                      // 20: Credentials
+	(*Download)(nil),                         // 21: Download
+	(*Panic)(nil),                            // 22: Panic
+	(*HoneypotEvent)(nil),                    // 23: HoneypotEvent
+	(*SessionEnded)(nil),                     // 24: SessionEnded
+	(*SFTPRequest)(nil),                      // 25: SFTPRequest
+	(*PortForward)(nil),                      // 26: PortForward
 }
 var file_log_proto_depIdxs = []int32{
-	5,  // 0: LogEntry.login_attempt:type_name -> LoginAttempt
-	4,  // 1: LogEntry.filesystem_operation:type_name -> FilesystemOp
-	6,  // 2: LogEntry.open_tty_log:type_name -> OpenTTYLog
-	7,  // 3: LogEntry.connection_lost:type_name -> ConnectionLost
-	8,  // 4: LogEntry.run_command:type_name -> RunCommand
-	9,  // 5: LogEntry.unknown_command:type_name -> UnknownCommand
-	10, // 6: LogEntry.terminal_update:type_name -> TerminalUpdate
-	11, // 7: LogEntry.open_file:type_name -> OpenFile
-	12, // 8: LogEntry.invalid_invocation:type_name -> InvalidInvocation
-	13, // 9: LogEntry.used_credentials:type_name -> Credentials
-	14, // 10: LogEntry.download:type_name -> Download
-	15, // 11: LogEntry.panic:type_name -> Panic
-	16, // 12: LogEntry.honeypot_event:type_name -> HoneypotEvent
-	17, // 13: LogEntry.session_ended:type_name -> SessionEnded
-	0,  // 14: LoginAttempt.result:type_name -> OperationResult
-	1,  // 15: UnknownCommand.status:type_name -> UnknownCommand.UnknownCommandStatus
-	2,  // 16: HoneypotEvent.event_type:type_name -> HoneypotEvent.Type
-	17, // [17:17] is the sub-list for method output_type
-	17, // [17:17] is the sub-list for method input_type
-	17, // [17:17] is the sub-list for extension type_name
-	17, // [17:17] is the sub-list for extension extendee
-	0,  // [0:17] is the sub-list for field type_name
+	10, // 0: LogEntry.login_attempt:type_name -> LoginAttempt
+	8,  // 1: LogEntry.filesystem_operation:type_name -> FilesystemOp
+	13, // 2: LogEntry.open_tty_log:type_name -> OpenTTYLog
+	14, // 3: LogEntry.connection_lost:type_name -> ConnectionLost
+	15, // 4: LogEntry.run_command:type_name -> RunCommand
+	16, // 5: LogEntry.unknown_command:type_name -> UnknownCommand
+	17, // 6: LogEntry.terminal_update:type_name -> TerminalUpdate
+	18, // 7: LogEntry.open_file:type_name -> OpenFile
+	19, // 8: LogEntry.invalid_invocation:type_name -> InvalidInvocation
+	20, // 9: LogEntry.used_credentials:type_name -> Credentials
+	21, // 10: LogEntry.download:type_name -> Download
+	22, // 11: LogEntry.panic:type_name -> Panic
+	23, // 12: LogEntry.honeypot_event:type_name -> HoneypotEvent
+	24, // 13: LogEntry.session_ended:type_name -> SessionEnded
+	25, // 14: LogEntry.sftp_request:type_name -> SFTPRequest
+	26, // 15: LogEntry.port_forward:type_name -> PortForward
+	1,  // 16: FilesystemOp.type:type_name -> FilesystemOp.Type
+	0,  // 17: FilesystemOp.result:type_name -> OperationResult
+	9,  // 18: FilesystemOp.process:type_name -> Process
+	2,  // 19: FilesystemOp.layer:type_name -> FilesystemOp.Layer
+	0,  // 20: LoginAttempt.result:type_name -> OperationResult
+	11, // 21: LoginAttempt.client:type_name -> SSHClient
+	3,  // 22: LoginAttempt.key_source:type_name -> LoginAttempt.KeySource
+	12, // 23: LoginAttempt.telnet_client:type_name -> TelnetClient
+	4,  // 24: UnknownCommand.status:type_name -> UnknownCommand.UnknownCommandStatus
+	0,  // 25: OpenFile.result:type_name -> OperationResult
+	9,  // 26: OpenFile.process:type_name -> Process
+	2,  // 27: OpenFile.layer:type_name -> FilesystemOp.Layer
+	5,  // 28: HoneypotEvent.event_type:type_name -> HoneypotEvent.Type
+	6,  // 29: SessionEnded.reason:type_name -> SessionEnded.Reason
+	0,  // 30: SFTPRequest.result:type_name -> OperationResult
+	31, // [31:31] is the sub-list for method output_type
+	31, // [31:31] is the sub-list for method input_type
+	31, // [31:31] is the sub-list for extension type_name
+	31, // [31:31] is the sub-list for extension extendee
+	0,  // [0:31] is the sub-list for field type_name
 }
 
 func init() { file_log_proto_init() }
@@ -1509,14 +2614,16 @@ func file_log_proto_init() {
 		(*LogEntry_Panic)(nil),
 		(*LogEntry_HoneypotEvent)(nil),
 		(*LogEntry_SessionEnded)(nil),
+		(*LogEntry_SftpRequest)(nil),
+		(*LogEntry_PortForward)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_log_proto_rawDesc), len(file_log_proto_rawDesc)),
-			NumEnums:      3,
-			NumMessages:   15,
+			NumEnums:      7,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"sort"
 	"strings"
 
@@ -60,9 +61,46 @@ func (r *BugReport) Update(le *LogEntry) {
 	}
 }
 
+// InteractionReport groups sessions by the connection they were opened on.
 type InteractionReport struct {
-	// Map of sessionID -> interactions
-	interactions map[string]*InteractiveSession
+	// Map of connectionID -> connection
+	connections map[string]*InteractiveConnection
+}
+
+// InteractiveConnection holds the activity on a single SSH connection.
+type InteractiveConnection struct {
+	RemoteAddr   string `json:"remote_addr,omitempty"`
+	LogEntries   int    `json:"log_entries"`
+	FailedLogins int    `json:"failed_logins"`
+
+	// Map of sessionID -> session
+	Sessions map[string]*InteractiveSession `json:"sessions"`
+}
+
+func (c *InteractiveConnection) Update(le *LogEntry) {
+	c.LogEntries++
+
+	if loginAttempt := le.GetLoginAttempt(); loginAttempt != nil {
+		c.RemoteAddr = loginAttempt.GetRemoteAddr()
+		if loginAttempt.GetResult() != OperationResult_SUCCESS {
+			c.FailedLogins++
+		}
+	}
+
+	sessionID := le.GetSessionId()
+	if sessionID == "" {
+		return
+	}
+	if c.Sessions == nil {
+		c.Sessions = make(map[string]*InteractiveSession)
+	}
+	session, ok := c.Sessions[sessionID]
+	if !ok {
+		session = &InteractiveSession{}
+		c.Sessions[sessionID] = session
+	}
+
+	session.Update(le)
 }
 
 type InteractiveSession struct {
@@ -113,8 +151,8 @@ func (i *InteractiveSession) Update(le *LogEntry) {
 }
 
 func (i *InteractionReport) init() {
-	if i.interactions == nil {
-		i.interactions = make(map[string]*InteractiveSession)
+	if i.connections == nil {
+		i.connections = make(map[string]*InteractiveConnection)
 	}
 }
 
@@ -122,20 +160,25 @@ func (i *InteractionReport) init() {
 func (i *InteractionReport) MarshalJSON() ([]byte, error) {
 	i.init()
 
-	return json.Marshal(i.interactions)
+	return json.Marshal(i.connections)
 }
 
 func (i *InteractionReport) Update(le *LogEntry) {
 	i.init()
 
-	sessionID := le.GetSessionId()
-	if sessionID == "" {
+	connectionID := le.GetConnectionId()
+	if connectionID == "" {
+		// Logs written before connection IDs existed have one connection per
+		// session.
+		connectionID = le.GetSessionId()
+	}
+	if connectionID == "" {
 		return
 	}
-	report, ok := i.interactions[sessionID]
+	report, ok := i.connections[connectionID]
 	if !ok {
-		report = &InteractiveSession{}
-		i.interactions[sessionID] = report
+		report = &InteractiveConnection{}
+		i.connections[connectionID] = report
 	}
 
 	report.Update(le)
@@ -147,11 +190,16 @@ type Report struct {
 	InvalidEntries StrCounter `json:"unknown_log_entries,omitempty"`
 
 	LoginAttempt      LoginAttemptReport      `json:"login_attempt_report"`
+	Client            ClientReport            `json:"client_report"`
 	RunCommand        RunCommandReport        `json:"run_command_report"`
 	UnknownCommand    UnknownCommandReport    `json:"unknown_command_report"`
 	InvalidInvocation InvalidInvocationReport `json:"invalid_invocation_report"`
 	Credentials       CredentialsReport       `json:"credential_report"`
 	Download          DownloadReport          `json:"download_report"`
+	SFTPRequest       SFTPRequestReport       `json:"sftp_request_report"`
+	PortForward       PortForwardReport       `json:"port_forward_report"`
+	Filesystem        FilesystemReport        `json:"filesystem_report"`
+	HoneypotEvent     HoneypotEventReport     `json:"honeypot_event_report"`
 	Panic             PanicReport             `json:"panic_report"`
 }
 
@@ -161,6 +209,7 @@ func (r *Report) Update(le *LogEntry) {
 	switch event := le.GetLogType().(type) {
 	case *LogEntry_LoginAttempt:
 		r.LoginAttempt.update(event.LoginAttempt)
+		r.Client.update(event.LoginAttempt.GetClient())
 	case *LogEntry_RunCommand:
 		r.RunCommand.update(event.RunCommand)
 	case *LogEntry_Panic:
@@ -171,7 +220,17 @@ func (r *Report) Update(le *LogEntry) {
 		r.UnknownCommand.update(event.UnknownCommand)
 	case *LogEntry_InvalidInvocation:
 		r.InvalidInvocation.update(event.InvalidInvocation)
-	case *LogEntry_TerminalUpdate, *LogEntry_HoneypotEvent, *LogEntry_OpenTtyLog:
+	case *LogEntry_SftpRequest:
+		r.SFTPRequest.update(event.SftpRequest)
+	case *LogEntry_PortForward:
+		r.PortForward.update(event.PortForward)
+	case *LogEntry_FilesystemOperation:
+		r.Filesystem.updateOperation(event.FilesystemOperation)
+	case *LogEntry_OpenFile:
+		r.Filesystem.updateOpen(event.OpenFile)
+	case *LogEntry_HoneypotEvent:
+		r.HoneypotEvent.update(event.HoneypotEvent)
+	case *LogEntry_TerminalUpdate, *LogEntry_OpenTtyLog:
 		// Ignore
 	default:
 		r.InvalidEntries.Increment(fmt.Sprintf("%T", event))
@@ -185,12 +244,40 @@ type LoginAttemptReport struct {
 	Usernames StrCounter `json:"usernames"`
 	// List of login attempt results and their counts.
 	Results StrCounter `json:"results"`
+	// List of authentication methods and their counts.
+	AuthMethods StrCounter `json:"auth_methods"`
 }
 
 func (r *LoginAttemptReport) update(la *LoginAttempt) {
 	r.Passwords.Increment(la.Password)
 	r.Usernames.Increment(la.Username)
 	r.Results.Increment(la.GetResult().String())
+	r.AuthMethods.Increment(la.GetAuthMethod())
+}
+
+type ClientReport struct {
+	// Login attempts by client fingerprint, most common first.
+	fingerprints *PathCounter
+}
+
+func (r *ClientReport) update(c *SSHClient) {
+	if c == nil {
+		return
+	}
+	if r.fingerprints == nil {
+		r.fingerprints = NewPathCounter("hassh", "version")
+	}
+	r.fingerprints.Increment(c.GetHassh(), c.GetVersion())
+}
+
+// MarshalJSON implemnts custom JSON marshaler.
+func (r ClientReport) MarshalJSON() ([]byte, error) {
+	if r.fingerprints == nil {
+		return json.Marshal(map[string]interface{}{})
+	}
+	return json.Marshal(map[string]interface{}{
+		"fingerprints": r.fingerprints,
+	})
 }
 
 type RunCommandReport struct {
@@ -247,6 +334,55 @@ func (r *DownloadReport) update(d *Download) {
 	}
 }
 
+type SFTPRequestReport struct {
+	Methods StrCounter `json:"methods"`
+	Results StrCounter `json:"results"`
+}
+
+func (r *SFTPRequestReport) update(req *SFTPRequest) {
+	r.Methods.Increment(req.Method)
+	r.Results.Increment(req.GetResult().String())
+}
+
+type PortForwardReport struct {
+	Types        StrCounter `json:"types"`
+	Destinations StrCounter `json:"destinations"`
+}
+
+func (r *PortForwardReport) update(pf *PortForward) {
+	r.Types.Increment(pf.Type)
+	r.Destinations.Increment(net.JoinHostPort(pf.Host, fmt.Sprintf("%d", pf.Port)))
+}
+
+type FilesystemReport struct {
+	// Operations counts changes to the filesystem by type.
+	Operations StrCounter `json:"operations"`
+	// ChangedPaths counts the paths operations were performed on.
+	ChangedPaths StrCounter `json:"changed_paths"`
+	// OpenedPaths counts the paths of opened files.
+	OpenedPaths StrCounter `json:"opened_paths"`
+	Results     StrCounter `json:"results"`
+}
+
+func (r *FilesystemReport) updateOperation(op *FilesystemOp) {
+	r.Operations.Increment(op.GetType().String())
+	r.ChangedPaths.Increment(op.Path)
+	r.Results.Increment(op.GetResult().String())
+}
+
+func (r *FilesystemReport) updateOpen(open *OpenFile) {
+	r.OpenedPaths.Increment(open.Path)
+	r.Results.Increment(open.GetResult().String())
+}
+
+type HoneypotEventReport struct {
+	Types StrCounter `json:"types"`
+}
+
+func (r *HoneypotEventReport) update(e *HoneypotEvent) {
+	r.Types.Increment(e.GetEventType().String())
+}
+
 type PanicReport struct {
 	Contexts []string `json:"contexts"`
 }
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInteractionReport(t *testing.T) {
+	var report InteractionReport
+	entries := []*LogEntry{
+		{ConnectionId: "conn1", LogType: &LogEntry_LoginAttempt{LoginAttempt: &LoginAttempt{
+			Result:     OperationResult_FAILURE,
+			RemoteAddr: "10.0.0.1:1234",
+		}}},
+		{ConnectionId: "conn1", SessionId: "shell", LogType: &LogEntry_LoginAttempt{LoginAttempt: &LoginAttempt{
+			Result:     OperationResult_SUCCESS,
+			Username:   "root",
+			RemoteAddr: "10.0.0.1:1234",
+		}}},
+		{ConnectionId: "conn1", SessionId: "shell", LogType: &LogEntry_RunCommand{RunCommand: &RunCommand{
+			Command: []string{"uname", "-a"},
+		}}},
+		{ConnectionId: "conn1", SessionId: "exec", LogType: &LogEntry_RunCommand{RunCommand: &RunCommand{
+			Command: []string{"id"},
+		}}},
+		// Entries from before connection IDs are grouped by session.
+		{SessionId: "legacy", LogType: &LogEntry_RunCommand{RunCommand: &RunCommand{
+			Command: []string{"w"},
+		}}},
+		// Events outside of a connection are ignored.
+		{LogType: &LogEntry_HoneypotEvent{HoneypotEvent: &HoneypotEvent{}}},
+	}
+	for _, le := range entries {
+		report.Update(le)
+	}
+
+	require.Len(t, report.connections, 2)
+
+	conn := report.connections["conn1"]
+	assert.Equal(t, "10.0.0.1:1234", conn.RemoteAddr)
+	assert.Equal(t, 4, conn.LogEntries)
+	assert.Equal(t, 1, conn.FailedLogins)
+	require.Len(t, conn.Sessions, 2)
+	assert.Equal(t, "root", conn.Sessions["shell"].Login.Username)
+	assert.Equal(t, []string{"uname -a"}, conn.Sessions["shell"].Commands)
+	assert.Equal(t, []string{"id"}, conn.Sessions["exec"].Commands)
+
+	assert.Equal(t, []string{"w"}, report.connections["legacy"].Sessions["legacy"].Commands)
+}
+
+func TestReport_filesystem(t *testing.T) {
+	var report Report
+	for _, le := range []*LogEntry{
+		{LogType: &LogEntry_FilesystemOperation{FilesystemOperation: &FilesystemOp{
+			Type:   FilesystemOp_CREATE,
+			Path:   "/tmp/payload",
+			Result: OperationResult_SUCCESS,
+		}}},
+		{LogType: &LogEntry_OpenFile{OpenFile: &OpenFile{
+			Path:   "/tmp/payload",
+			Result: OperationResult_SUCCESS,
+		}}},
+		{LogType: &LogEntry_OpenFile{OpenFile: &OpenFile{
+			Path:   "/etc/shadow",
+			Result: OperationResult_FAILURE,
+		}}},
+	} {
+		report.Update(le)
+	}
+
+	assert.Empty(t, report.InvalidEntries.internal)
+	assert.Equal(t, map[string]int{"CREATE": 1}, report.Filesystem.Operations.internal)
+	assert.Equal(t, map[string]int{"/tmp/payload": 1}, report.Filesystem.ChangedPaths.internal)
+	assert.Equal(t, map[string]int{"/tmp/payload": 1, "/etc/shadow": 1}, report.Filesystem.OpenedPaths.internal)
+	assert.Equal(t, map[string]int{"SUCCESS": 2, "FAILURE": 1}, report.Filesystem.Results.internal)
+}
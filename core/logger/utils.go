@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"time"
@@ -32,35 +34,65 @@ func NewJsonLinesLogRecorder(w io.Writer) *Logger {
 	}
 }
 
-func (l *Logger) recordLogType(sessionID string, event isLogEntry_LogType) error {
+// WithListener creates a Logger that tags entries with the listener name.
+func (l *Logger) WithListener(name string) *Logger {
+	return &Logger{
+		Record: func(le *LogEntry) error {
+			le.Listener = name
+			return l.Record(le)
+		},
+	}
+}
+
+func (l *Logger) recordLogType(connectionID, sessionID string, event isLogEntry_LogType) error {
 	le := &LogEntry{}
 	le.TimestampMicros = time.Now().UnixMicro()
+	le.ConnectionId = connectionID
 	le.SessionId = sessionID
 	le.LogType = event
 
 	return l.Record(le)
 }
 
-// NewSession creates a logger with attached session ID.
-func (l *Logger) NewSession(sessionID string) *SessionLogger {
-	return &SessionLogger{Logger: l, sessionID: sessionID}
+// NewConnection creates a logger with attached connection ID.
+func (l *Logger) NewConnection(connectionID string) *SessionLogger {
+	return &SessionLogger{Logger: l, connectionID: connectionID}
 }
 
-// NewSession creates a logger with attached session ID.
+// Sessionless creates a logger for events outside of a connection.
 func (l *Logger) Sessionless() *SessionLogger {
-	return &SessionLogger{Logger: l, sessionID: ""}
+	return &SessionLogger{Logger: l}
 }
 
-// SessionLogger logs messages with a shared session ID.
+// NewID returns a random identifier for a connection or session.
+func NewID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// SessionLogger logs messages with a shared connection and session ID.
 type SessionLogger struct {
 	*Logger
-	sessionID string
+	connectionID string
+	sessionID    string
 }
 
 type LogType = isLogEntry_LogType
 
+// NewSession creates a logger for a session on the same connection.
+func (l *SessionLogger) NewSession(sessionID string) *SessionLogger {
+	return &SessionLogger{Logger: l.Logger, connectionID: l.connectionID, sessionID: sessionID}
+}
+
 func (l *SessionLogger) Record(event LogType) error {
-	return l.recordLogType(l.sessionID, event)
+	return l.recordLogType(l.connectionID, l.sessionID, event)
+}
+
+func (l *SessionLogger) ConnectionID() string {
+	return l.connectionID
 }
 
 func (l *SessionLogger) SessionID() string {
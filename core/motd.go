@@ -0,0 +1,110 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// lastLogFormat matches the format sshd uses for the "Last login" line.
+const lastLogFormat = "Mon Jan _2 15:04:05 2006"
+
+// firstLoginDelay is how long after boot a user's fabricated previous login
+// happened.
+const firstLoginDelay = 3 * time.Minute
+
+// LastLogin is a previous login for a user.
+type LastLogin struct {
+	Time time.Time
+	From string
+}
+
+// MotdData is passed to the MOTD template.
+type MotdData struct {
+	// Uname fields as shown by `uname`.
+	KernelName       string
+	Hostname         string
+	KernelRelease    string
+	KernelVersion    string
+	HardwarePlatform string
+
+	// User is the name of the user logging in.
+	User string
+	// Now is the time of the login.
+	Now time.Time
+	// LastLogin is the user's previous login, it's nil if there isn't one.
+	LastLogin *LastLogin
+}
+
+// parseMotd parses the MOTD template and checks that it renders.
+func parseMotd(motd string) (*template.Template, error) {
+	tmpl, err := template.New("motd").Parse(motd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid motd: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, MotdData{}); err != nil {
+		return nil, fmt.Errorf("invalid motd: %w", err)
+	}
+	return tmpl, nil
+}
+
+// lastLogTracker remembers the most recent login of each user.
+type lastLogTracker struct {
+	mu     sync.Mutex
+	logins map[string]LastLogin
+}
+
+// swap records the current login for the user and returns the previous one.
+func (l *lastLogTracker) swap(user string, current LastLogin) (LastLogin, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.logins == nil {
+		l.logins = make(map[string]LastLogin)
+	}
+
+	previous, ok := l.logins[user]
+	l.logins[user] = current
+	return previous, ok
+}
+
+// previousLogin records the user's login and returns the one before it. Users
+// that haven't logged in yet get a login shortly after boot from the
+// configured address so the machine doesn't look freshly installed.
+func (l *listener) previousLogin(user string, current LastLogin) *LastLogin {
+	previous, ok := l.lastLog.swap(user, current)
+	if ok {
+		return &previous
+	}
+
+	if l.configuration.LastLoginFrom == "" {
+		return nil
+	}
+	previous = LastLogin{
+		Time: l.sharedOS.BootTime().Add(firstLoginDelay),
+		From: l.configuration.LastLoginFrom,
+	}
+	if previous.Time.After(current.Time) {
+		return nil
+	}
+	return &previous
+}
+
+// writeLoginBanner writes the MOTD and "Last login" line the way sshd does for
+// interactive logins.
+func (l *listener) writeLoginBanner(w io.Writer, data MotdData) error {
+	var sb strings.Builder
+	if err := l.motd.Execute(&sb, data); err != nil {
+		return err
+	}
+	if data.LastLogin != nil {
+		fmt.Fprintf(&sb, "Last login: %s from %s\n", data.LastLogin.Time.Format(lastLogFormat), data.LastLogin.From)
+	}
+
+	// The terminal is in raw mode so newlines need carriage returns.
+	_, err := io.WriteString(w, strings.ReplaceAll(sb.String(), "\n", "\r\n"))
+	return err
+}
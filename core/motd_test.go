@@ -0,0 +1,56 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/josephlewis42/honeyssh/core/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLoginBanner(t *testing.T) {
+	motd, err := parseMotd("{{.KernelName}} {{.Hostname}}\n{{with .LastLogin}}prev {{.From}}\n{{end}}")
+	require.Nil(t, err)
+	l := &listener{motd: motd}
+
+	out := &bytes.Buffer{}
+	require.Nil(t, l.writeLoginBanner(out, MotdData{
+		KernelName: "Linux",
+		Hostname:   "web01",
+		LastLogin: &LastLogin{
+			Time: time.Date(2021, time.June, 5, 8, 4, 3, 0, time.UTC),
+			From: "10.0.0.1",
+		},
+	}))
+
+	assert.Equal(t, "Linux web01\r\nprev 10.0.0.1\r\nLast login: Sat Jun  5 08:04:03 2021 from 10.0.0.1\r\n", out.String())
+}
+
+func TestParseMotd_invalid(t *testing.T) {
+	for _, motd := range []string{"{{.Missing}}", "{{.LastLogin.From}}", "{{"} {
+		_, err := parseMotd(motd)
+		assert.NotNil(t, err, "motd %q", motd)
+	}
+}
+
+func TestPreviousLogin(t *testing.T) {
+	boot := time.Date(2021, time.June, 5, 8, 0, 0, 0, time.UTC)
+	cfg := &config.Configuration{LastLoginFrom: "192.168.1.20"}
+	l := &listener{
+		configuration: cfg,
+		sharedOS:      vos.NewSharedOS(nil, nil, cfg, func() time.Time { return boot }),
+		lastLog:       &lastLogTracker{},
+	}
+
+	first := LastLogin{Time: boot.Add(time.Hour), From: "10.0.0.1"}
+	assert.Equal(t, &LastLogin{Time: boot.Add(firstLoginDelay), From: "192.168.1.20"}, l.previousLogin("root", first))
+
+	second := LastLogin{Time: boot.Add(2 * time.Hour), From: "10.0.0.2"}
+	assert.Equal(t, &first, l.previousLogin("root", second))
+
+	// Logins before the fabricated one don't get a previous login.
+	assert.Nil(t, l.previousLogin("admin", LastLogin{Time: boot}))
+}
@@ -0,0 +1,216 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/josephlewis42/honeyssh/core/vos"
+)
+
+// persistentFilesystemsName is the state file the saved filesystems are
+// listed in.
+const persistentFilesystemsName = "persistent_filesystems.json"
+
+// savedFilesystem is a filesystem kept between sessions.
+type savedFilesystem struct {
+	LastUsed time.Time `json:"last_used"`
+	// Size is the total size of the files in the filesystem.
+	Size int64 `json:"size"`
+}
+
+// persistentFilesystems tracks the filesystems saved in the config directory,
+// keyed by file name.
+type persistentFilesystems struct {
+	mu    sync.Mutex
+	saved map[string]savedFilesystem
+	// saving holds the locks of filesystems being saved.
+	saving map[string]*saveLock
+}
+
+// saveLock serializes saves of a filesystem, refs counts the sessions holding
+// or waiting for it.
+type saveLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock waits for other sessions to finish saving the filesystem and returns
+// the function that releases it.
+func (p *persistentFilesystems) lock(name string) func() {
+	p.mu.Lock()
+	if p.saving == nil {
+		p.saving = make(map[string]*saveLock)
+	}
+	lock, ok := p.saving[name]
+	if !ok {
+		lock = &saveLock{}
+		p.saving[name] = lock
+	}
+	lock.refs++
+	p.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if lock.refs--; lock.refs == 0 {
+			delete(p.saving, name)
+		}
+	}
+}
+
+// lookup returns whether the filesystem was saved and hasn't expired.
+func (p *persistentFilesystems) lookup(policy config.PersistentFilesystems, name string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	saved, ok := p.saved[name]
+	return ok && !policy.Expired(saved.LastUsed, now)
+}
+
+// touch records that the filesystem was used, it returns the names of the
+// filesystems that should be removed to stay within the policy's limits.
+func (p *persistentFilesystems) touch(policy config.PersistentFilesystems, name string, size int64, now time.Time) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.saved == nil {
+		p.saved = make(map[string]savedFilesystem)
+	}
+	p.saved[name] = savedFilesystem{LastUsed: now, Size: size}
+
+	var names []string
+	for name := range p.saved {
+		names = append(names, name)
+	}
+	// Most recently used first.
+	sort.Slice(names, func(i, j int) bool {
+		return p.saved[names[i]].LastUsed.After(p.saved[names[j]].LastUsed)
+	})
+
+	var removed []string
+	for i, name := range names {
+		tooMany := policy.MaxFilesystems > 0 && i >= policy.MaxFilesystems
+		if tooMany || policy.Expired(p.saved[name].LastUsed, now) {
+			delete(p.saved, name)
+			removed = append(removed, name)
+		}
+	}
+	return removed
+}
+
+// save writes the list of saved filesystems to the state file.
+func (p *persistentFilesystems) save(configuration *config.Configuration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return configuration.WriteState(persistentFilesystemsName, p.saved)
+}
+
+// persistentFilesystemName returns the name of the file the session's
+// filesystem is saved to or an empty string if filesystems aren't kept.
+func (l *listener) persistentFilesystemName(s SessionInfo) string {
+	var parts []string
+	switch l.configuration.PersistentFilesystems.Key {
+	case config.PersistentFilesystemKeyIP:
+		parts = []string{remoteIP(s.RemoteAddr())}
+	case config.PersistentFilesystemKeyCredential:
+		parts = sessionCredential(s)
+	case config.PersistentFilesystemKeyIPAndCredential:
+		parts = append([]string{remoteIP(s.RemoteAddr())}, sessionCredential(s)...)
+	default:
+		return ""
+	}
+
+	// Hash the key so credentials aren't stored in file names.
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:]) + ".tar.gz"
+}
+
+// sessionCredential returns the username and password or public key the
+// session authenticated with.
+func sessionCredential(s SessionInfo) []string {
+	return []string{
+		s.User(),
+		maybeString(s.Context().Value(ContextAuthPassword)),
+		string(maybeBytes(s.Context().Value(ContextAuthPublicKey))),
+	}
+}
+
+// restoreFilesystem restores a filesystem saved by an earlier session, it
+// returns whether one was restored.
+func (l *listener) restoreFilesystem(tenantOS *vos.TenantOS, name string) bool {
+	policy := l.configuration.PersistentFilesystems
+	if !l.honeypot.persistentFilesystems.lookup(policy, name, time.Now()) {
+		return false
+	}
+
+	fd, err := l.configuration.OpenPersistentFilesystem(name)
+	if err != nil {
+		log.Printf("couldn't open persistent filesystem: %v\n", err)
+		return false
+	}
+	defer fd.Close()
+
+	if err := tenantOS.RestoreOverlay(fd); err != nil {
+		log.Printf("couldn't restore persistent filesystem: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// persistFilesystem saves the session's filesystem so it can be restored by
+// the attacker's next session. restored is whether the session started with
+// the saved filesystem.
+func (l *listener) persistFilesystem(tenantOS *vos.TenantOS, name string, restored bool) {
+	policy := l.configuration.PersistentFilesystems
+	persistent := &l.honeypot.persistentFilesystems
+	// Sessions with the same key, like parallel exec channels, finish one at
+	// a time.
+	defer persistent.lock(name)()
+
+	changes, err := tenantOS.FilesystemChanges()
+	if err != nil {
+		log.Printf("couldn't list filesystem changes: %v\n", err)
+		return
+	}
+	size, err := tenantOS.OverlaySize()
+	if err != nil {
+		log.Printf("couldn't get persistent filesystem size: %v\n", err)
+		return
+	}
+
+	switch {
+	case changes.Empty() && !restored:
+		// Don't save filesystems for sessions that didn't change anything.
+		return
+	case policy.MaxSizeBytes > 0 && size > policy.MaxSizeBytes:
+		log.Printf("not saving persistent filesystem of %d bytes, the limit is %d\n", size, policy.MaxSizeBytes)
+		return
+	case !changes.Empty():
+		if err := l.configuration.WritePersistentFilesystem(name, tenantOS.WriteOverlay); err != nil {
+			log.Printf("couldn't save persistent filesystem: %v\n", err)
+			return
+		}
+	}
+
+	for _, removed := range persistent.touch(policy, name, size, time.Now()) {
+		err := l.configuration.RemovePersistentFilesystem(removed)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("couldn't remove persistent filesystem: %v\n", err)
+		}
+	}
+	if err := persistent.save(l.configuration); err != nil {
+		log.Printf("couldn't save persistent filesystems: %v\n", err)
+	}
+}
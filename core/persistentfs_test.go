@@ -0,0 +1,89 @@
+package core
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistentFilesystems(t *testing.T) {
+	var persistent persistentFilesystems
+	policy := config.PersistentFilesystems{
+		Key:            config.PersistentFilesystemKeyIP,
+		Retention:      config.Duration(time.Hour),
+		MaxFilesystems: 2,
+	}
+	now := time.Date(2006, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	assert.False(t, persistent.lookup(policy, "a", now))
+	assert.Empty(t, persistent.touch(policy, "a", 10, now))
+	assert.True(t, persistent.lookup(policy, "a", now.Add(time.Hour)))
+	assert.False(t, persistent.lookup(policy, "a", now.Add(time.Hour+time.Second)), "expired")
+
+	assert.Empty(t, persistent.touch(policy, "b", 10, now.Add(time.Minute)))
+	assert.Equal(t, []string{"a"}, persistent.touch(policy, "c", 10, now.Add(2*time.Minute)), "least recently used")
+	assert.Equal(t, []string{"b"}, persistent.touch(policy, "c", 10, now.Add(62*time.Minute)), "expired")
+}
+
+func TestPersistentFilesystems_lock(t *testing.T) {
+	var persistent persistentFilesystems
+
+	unlock := persistent.lock("a")
+	persistent.lock("b")()
+
+	locked := make(chan struct{})
+	go func() {
+		defer persistent.lock("a")()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("saves of the same filesystem weren't serialized")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	unlock()
+	<-locked
+	require.Eventually(t, func() bool {
+		persistent.mu.Lock()
+		defer persistent.mu.Unlock()
+		return len(persistent.saving) == 0
+	}, time.Second, time.Millisecond, "released locks are forgotten")
+}
+
+func TestHoneypot_persistentFilesystem(t *testing.T) {
+	tempDir := t.TempDir()
+	_, err := config.Initialize(tempDir, log.New(ioutil.Discard, "", 0))
+	require.Nil(t, err)
+	cfg, err := config.Load(tempDir)
+	require.Nil(t, err)
+	cfg.GlobalPasswords = []string{"hunter2", "letmein"}
+	cfg.Limits.FailedLoginDelay = 0
+	cfg.Telnet.Port = 2323
+	cfg.PersistentFilesystems.Key = config.PersistentFilesystemKeyCredential
+
+	honeypot, err := NewHoneypot(cfg, ioutil.Discard)
+	require.Nil(t, err)
+	defer honeypot.Close()
+
+	runTelnetSession(honeypot, "root\r\nhunter2\r\necho stage2 > /tmp/payload\r\nexit\r\n")
+
+	out := runTelnetSession(honeypot, "root\r\nhunter2\r\ncat /tmp/payload\r\nexit\r\n")
+	assert.Contains(t, out, "cat /tmp/payload\nstage2\n")
+
+	out = runTelnetSession(honeypot, "root\r\nletmein\r\ncat /tmp/payload\r\nexit\r\n")
+	assert.Contains(t, out, "cat: open : open /tmp/payload: file does not exist", "other credentials get a fresh filesystem")
+
+	saved, err := filepath.Glob(filepath.Join(tempDir, config.PersistentFilesystemsDirName, "*.tar.gz"))
+	require.Nil(t, err)
+	assert.Len(t, saved, 1)
+	_, err = os.Stat(filepath.Join(tempDir, persistentFilesystemsName))
+	assert.Nil(t, err)
+}
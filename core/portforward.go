@@ -0,0 +1,138 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/josephlewis42/honeyssh/core/logger"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	forwardTypeDirectTCPIP  = "direct-tcpip"
+	forwardTypeTCPIPForward = "tcpip-forward"
+	forwardTypeCancel       = "cancel-tcpip-forward"
+)
+
+// directTCPIPData is the extra data sent when opening a direct-tcpip channel.
+// See RFC 4254 section 7.2.
+type directTCPIPData struct {
+	DestAddr   string
+	DestPort   uint32
+	OriginAddr string
+	OriginPort uint32
+}
+
+// tcpipForwardRequest is the payload of tcpip-forward and
+// cancel-tcpip-forward global requests. See RFC 4254 section 7.1.
+type tcpipForwardRequest struct {
+	BindAddr string
+	BindPort uint32
+}
+
+type tcpipForwardSuccess struct {
+	BindPort uint32
+}
+
+// handleDirectTCPIP accepts local forwarding (ssh -L/-D) channels, captures
+// what the client sends and optionally answers with a canned response. It
+// never connects to the requested destination.
+func (l *listener) handleDirectTCPIP(srv *ssh.Server, conn *gossh.ServerConn, newChan gossh.NewChannel, ctx ssh.Context) {
+	var data directTCPIPData
+	if err := gossh.Unmarshal(newChan.ExtraData(), &data); err != nil {
+		newChan.Reject(gossh.ConnectionFailed, "error parsing forward data: "+err.Error())
+		return
+	}
+
+	ch, reqs, err := newChan.Accept()
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+	go gossh.DiscardRequests(reqs)
+
+	// Clients can hold channels open forever, close them once they go quiet.
+	idleTimeout := l.configuration.PortForward.ChannelIdleTimeout()
+	idle := time.AfterFunc(idleTimeout, func() {
+		ch.Close()
+	})
+	defer idle.Stop()
+
+	forward := &logger.PortForward{
+		Type:       forwardTypeDirectTCPIP,
+		Host:       data.DestAddr,
+		Port:       data.DestPort,
+		OriginHost: data.OriginAddr,
+		OriginPort: data.OriginPort,
+		RemoteAddr: fmt.Sprintf("%v", ctx.RemoteAddr()),
+	}
+	// Each forwarded channel gets its own session ID.
+	channelLogger := l.connectionLogger(ctx).NewSession(logger.NewID())
+	defer func() {
+		channelLogger.Record(&logger.LogEntry_PortForward{
+			PortForward: forward,
+		})
+	}()
+
+	responder := l.configuration.PortForward.Responder(data.DestPort)
+	if responder != nil && responder.Banner != "" {
+		io.WriteString(ch, responder.Banner)
+		forward.Responded = true
+	}
+
+	captureBytes := l.configuration.PortForward.CaptureBytes
+	buf := make([]byte, 4096)
+	for {
+		n, err := ch.Read(buf)
+		if n > 0 {
+			idle.Reset(idleTimeout)
+			if remaining := captureBytes - len(forward.Payload); remaining > 0 {
+				if remaining > n {
+					remaining = n
+				}
+				forward.Payload = append(forward.Payload, buf[:remaining]...)
+			}
+			forward.ByteCount += int64(n)
+
+			if responder != nil && responder.Response != "" {
+				io.WriteString(ch, responder.Response)
+				forward.Responded = true
+				ch.CloseWrite()
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handleTCPIPForward pretends to start remote forwarding (ssh -R). Nothing
+// listens on the port.
+func (l *listener) handleTCPIPForward(ctx ssh.Context, srv *ssh.Server, req *gossh.Request) (bool, []byte) {
+	var payload tcpipForwardRequest
+	if err := gossh.Unmarshal(req.Payload, &payload); err != nil {
+		return false, nil
+	}
+
+	l.connectionLogger(ctx).Record(&logger.LogEntry_PortForward{
+		PortForward: &logger.PortForward{
+			Type:       req.Type,
+			Host:       payload.BindAddr,
+			Port:       payload.BindPort,
+			RemoteAddr: fmt.Sprintf("%v", ctx.RemoteAddr()),
+		},
+	})
+
+	if req.Type == forwardTypeTCPIPForward && payload.BindPort == 0 {
+		// The client asked the server to pick a port, choose one from the
+		// Linux ephemeral range.
+		return true, gossh.Marshal(&tcpipForwardSuccess{
+			BindPort: uint32(32768 + rand.Intn(60999-32768)),
+		})
+	}
+	return true, nil
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// portForwards returns the port forward events in the log.
+func (b *lockedBuffer) portForwards(t *testing.T) []*logger.PortForward {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []*logger.PortForward
+	err := logger.ReadJSONLinesLog(bytes.NewReader(b.buf.Bytes()), func(le *logger.LogEntry) {
+		if forward := le.GetPortForward(); forward != nil {
+			out = append(out, forward)
+		}
+	})
+	require.Nil(t, err)
+	return out
+}
+
+func newPortForwardHoneypot(t *testing.T) (*Honeypot, *lockedBuffer) {
+	tempDir := t.TempDir()
+	_, err := config.Initialize(tempDir, log.New(ioutil.Discard, "", 0))
+	require.Nil(t, err)
+	cfg, err := config.Load(tempDir)
+	require.Nil(t, err)
+	cfg.GlobalPasswords = []string{"hunter2"}
+	cfg.Limits.FailedLoginDelay = 0
+	cfg.PortForward.IdleTimeout = config.Duration(50 * time.Millisecond)
+
+	eventLog := &lockedBuffer{}
+	honeypot, err := NewHoneypot(cfg, eventLog)
+	require.Nil(t, err)
+	t.Cleanup(func() { honeypot.Close() })
+	return honeypot, eventLog
+}
+
+func TestHoneypot_directTCPIP(t *testing.T) {
+	honeypot, eventLog := newPortForwardHoneypot(t)
+	client := dialSSH(t, honeypot)
+
+	web, err := client.Dial("tcp", "203.0.113.5:80")
+	require.Nil(t, err)
+	_, err = io.WriteString(web, "GET / HTTP/1.1\r\n\r\n")
+	require.Nil(t, err)
+	response, err := io.ReadAll(web)
+	require.Nil(t, err)
+	assert.Contains(t, string(response), "HTTP/1.1 200 OK\r\n")
+	web.Close()
+
+	// Channels without a responder are closed once they go quiet.
+	quiet, err := client.Dial("tcp", "203.0.113.5:6667")
+	require.Nil(t, err)
+	_, err = io.WriteString(quiet, "NICK bot\r\n")
+	require.Nil(t, err)
+	closed := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(quiet)
+		closed <- err
+	}()
+	select {
+	case err := <-closed:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle channel wasn't closed")
+	}
+
+	var forwards []*logger.PortForward
+	require.Eventually(t, func() bool {
+		forwards = eventLog.portForwards(t)
+		return len(forwards) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, forwardTypeDirectTCPIP, forwards[0].GetType())
+	assert.Equal(t, "203.0.113.5", forwards[0].GetHost())
+	assert.Equal(t, uint32(80), forwards[0].GetPort())
+	assert.Equal(t, "GET / HTTP/1.1\r\n\r\n", string(forwards[0].GetPayload()))
+	assert.True(t, forwards[0].GetResponded())
+
+	assert.Equal(t, uint32(6667), forwards[1].GetPort())
+	assert.Equal(t, int64(len("NICK bot\r\n")), forwards[1].GetByteCount())
+	assert.False(t, forwards[1].GetResponded())
+}
+
+func TestHoneypot_tcpipForward(t *testing.T) {
+	honeypot, eventLog := newPortForwardHoneypot(t)
+	client := dialSSH(t, honeypot)
+
+	ok, payload, err := client.SendRequest(forwardTypeTCPIPForward, true, gossh.Marshal(&tcpipForwardRequest{
+		BindAddr: "0.0.0.0",
+		BindPort: 0,
+	}))
+	require.Nil(t, err)
+	require.True(t, ok)
+	var bound tcpipForwardSuccess
+	require.Nil(t, gossh.Unmarshal(payload, &bound))
+	assert.GreaterOrEqual(t, bound.BindPort, uint32(32768))
+	assert.Less(t, bound.BindPort, uint32(60999))
+
+	ok, _, err = client.SendRequest(forwardTypeCancel, true, gossh.Marshal(&tcpipForwardRequest{
+		BindAddr: "0.0.0.0",
+		BindPort: bound.BindPort,
+	}))
+	require.Nil(t, err)
+	assert.True(t, ok)
+
+	forwards := eventLog.portForwards(t)
+	require.Len(t, forwards, 2)
+	assert.Equal(t, forwardTypeTCPIPForward, forwards[0].GetType())
+	assert.Equal(t, "0.0.0.0", forwards[0].GetHost())
+	assert.Equal(t, forwardTypeCancel, forwards[1].GetType())
+	assert.Equal(t, bound.BindPort, forwards[1].GetPort())
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"sort"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/josephlewis42/honeyssh/core/config"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// defaultSSHVersion is used if no profile is selected.
+const defaultSSHVersion = "OpenSSH_8.2p1"
+
+// hostKeyFormat returns the key format of a host key algorithm.
+func hostKeyFormat(algorithm string) string {
+	switch algorithm {
+	case gossh.KeyAlgoRSASHA256, gossh.KeyAlgoRSASHA512:
+		return gossh.KeyAlgoRSA
+	default:
+		return algorithm
+	}
+}
+
+// profileHostSigners orders the host keys to match the profile's host key
+// algorithms, dropping keys the profile doesn't offer. RSA keys are limited to
+// the listed signature algorithms.
+func profileHostSigners(hostKeys []config.HostKey, profile *config.SSHProfile) ([]ssh.Signer, error) {
+	type rankedSigner struct {
+		signer ssh.Signer
+		rank   int
+	}
+
+	var ranked []rankedSigner
+	for _, hostKey := range hostKeys {
+		signer := hostKey.Signer
+		if profile == nil || len(profile.HostKeyAlgorithms) == 0 {
+			ranked = append(ranked, rankedSigner{signer: signer})
+			continue
+		}
+
+		keyFormat := signer.PublicKey().Type()
+		rank := -1
+		var algorithms []string
+		for i, algorithm := range profile.HostKeyAlgorithms {
+			if hostKeyFormat(algorithm) != keyFormat {
+				continue
+			}
+			if rank < 0 {
+				rank = i
+			}
+			algorithms = append(algorithms, algorithm)
+		}
+		if rank < 0 {
+			continue
+		}
+
+		if algorithmSigner, ok := signer.(gossh.AlgorithmSigner); ok && keyFormat == gossh.KeyAlgoRSA {
+			restricted, err := gossh.NewSignerWithAlgorithms(algorithmSigner, algorithms)
+			if err != nil {
+				return nil, err
+			}
+			signer = restricted
+		}
+		ranked = append(ranked, rankedSigner{signer: signer, rank: rank})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].rank < ranked[j].rank
+	})
+
+	var out []ssh.Signer
+	for _, r := range ranked {
+		out = append(out, r.signer)
+	}
+	return out, nil
+}
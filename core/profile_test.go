@@ -0,0 +1,78 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestProfileHostSigners(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	rsaSigner, err := gossh.NewSignerFromKey(rsaKey)
+	require.Nil(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	edSigner, err := gossh.NewSignerFromKey(edKey)
+	require.Nil(t, err)
+
+	hostKeys := []config.HostKey{
+		{Name: "ssh_host_ed25519_key", Signer: edSigner},
+		{Name: "ssh_host_rsa_key", Signer: rsaSigner},
+	}
+
+	t.Run("no profile", func(t *testing.T) {
+		signers, err := profileHostSigners(hostKeys, nil)
+		require.Nil(t, err)
+		assert.Len(t, signers, 2)
+	})
+
+	t.Run("ordered and restricted", func(t *testing.T) {
+		signers, err := profileHostSigners(hostKeys, &config.SSHProfile{
+			HostKeyAlgorithms: []string{"rsa-sha2-512", "ecdsa-sha2-nistp256", "ssh-ed25519"},
+		})
+		require.Nil(t, err)
+		require.Len(t, signers, 2)
+
+		rsaMulti, ok := signers[0].(gossh.MultiAlgorithmSigner)
+		require.True(t, ok)
+		assert.Equal(t, []string{"rsa-sha2-512"}, rsaMulti.Algorithms())
+		assert.Equal(t, "ssh-ed25519", signers[1].PublicKey().Type())
+	})
+
+	t.Run("unlisted keys dropped", func(t *testing.T) {
+		signers, err := profileHostSigners(hostKeys, &config.SSHProfile{
+			HostKeyAlgorithms: []string{"ssh-rsa"},
+		})
+		require.Nil(t, err)
+		require.Len(t, signers, 1)
+		assert.Equal(t, "ssh-rsa", signers[0].PublicKey().Type())
+	})
+}
+
+func TestNewHoneypot_noAuthMethods(t *testing.T) {
+	tempDir := t.TempDir()
+	_, err := config.Initialize(tempDir, log.New(ioutil.Discard, "", 0))
+	require.Nil(t, err)
+	cfg, err := config.Load(tempDir)
+	require.Nil(t, err)
+	cfg.SSHProfiles = append(cfg.SSHProfiles, config.SSHProfile{
+		Name:        "kbd-only",
+		Version:     "OpenSSH_7.6p1",
+		AuthMethods: []string{"keyboard-interactive"},
+	})
+	cfg.SSHProfile = "kbd-only"
+	cfg.KeyboardInteractivePrompts = nil
+
+	_, err = NewHoneypot(cfg, ioutil.Discard)
+	require.NotNil(t, err, "servers without auth methods let everyone in")
+	assert.Contains(t, err.Error(), "no usable auth methods")
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/josephlewis42/honeyssh/core/proxyproto"
+)
+
+// proxyHeaderTimeout is how long to wait for the load balancer to send the
+// PROXY protocol header.
+const proxyHeaderTimeout = 10 * time.Second
+
+// readProxyHeader replaces the connection's remote address with the client
+// address from its PROXY protocol header, it returns nil if the connection
+// should be rejected.
+func (l *listener) readProxyHeader(ctx context.Context, conn net.Conn) net.Conn {
+	proxyConn, err := proxyproto.NewConn(conn, proxyHeaderTimeout)
+	if err != nil {
+		l.connectionLogger(ctx).Record(&logger.LogEntry_HoneypotEvent{
+			HoneypotEvent: &logger.HoneypotEvent{
+				EventType:  logger.HoneypotEvent_INVALID_PROXY_HEADER,
+				RemoteAddr: conn.RemoteAddr().String(),
+			},
+		})
+		return nil
+	}
+	return proxyConn
+}
@@ -0,0 +1,191 @@
+// Package proxyproto reads PROXY protocol v1 and v2 headers sent by load
+// balancers to pass along the address of the real client.
+//
+// See: https://www.haproxy.org/download/2.8/doc/proxy-protocol.txt
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// v1MaxLength is the maximum length of a v1 header including the CRLF.
+	v1MaxLength = 107
+	// v2HeaderLength is the length of the fixed part of a v2 header.
+	v2HeaderLength = 16
+)
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	// ErrInvalidHeader is returned if the connection doesn't start with a
+	// valid PROXY protocol header.
+	ErrInvalidHeader = errors.New("invalid PROXY protocol header")
+)
+
+// Conn is a net.Conn that reports the client address from the PROXY header
+// as its remote address.
+type Conn struct {
+	net.Conn
+
+	reader     *bufio.Reader
+	remoteAddr net.Addr
+}
+
+// NewConn reads the PROXY header from the connection, waiting at most
+// timeout for it to arrive.
+func NewConn(conn net.Conn, timeout time.Duration) (*Conn, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	addr, err := ReadHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	return &Conn{
+		Conn:       conn,
+		reader:     reader,
+		remoteAddr: addr,
+	}, nil
+}
+
+// Read implements io.Reader.
+func (c *Conn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the client address from the header, or the address of
+// the peer if the header didn't contain one e.g. for health checks.
+func (c *Conn) RemoteAddr() net.Addr {
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// ReadHeader reads a v1 or v2 header and returns the source address it
+// contains. The address is nil for headers that don't carry one, such as
+// v1 UNKNOWN and v2 LOCAL headers.
+func ReadHeader(r *bufio.Reader) (net.Addr, error) {
+	// The shortest v1 header ("PROXY UNKNOWN\r\n") is longer than the v2
+	// signature so it's always safe to peek this far.
+	start, err := r.Peek(len(v2Signature))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.Equal(start, v2Signature):
+		return readV2(r)
+	case bytes.HasPrefix(start, v1Prefix):
+		return readV1(r)
+	default:
+		return nil, fmt.Errorf("%w: missing signature", ErrInvalidHeader)
+	}
+}
+
+func readV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		if len(line) >= v1MaxLength {
+			return nil, fmt.Errorf("%w: v1 header too long", ErrInvalidHeader)
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+	}
+
+	fields := strings.Split(strings.TrimSuffix(string(line), "\r\n"), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("%w: v1 header has %d fields", ErrInvalidHeader, len(fields))
+	}
+
+	srcIP := net.ParseIP(fields[2])
+	dstIP := net.ParseIP(fields[3])
+	if srcIP == nil || dstIP == nil {
+		return nil, fmt.Errorf("%w: bad v1 address", ErrInvalidHeader)
+	}
+	switch isV4 := srcIP.To4() != nil && dstIP.To4() != nil; {
+	case fields[1] == "TCP4" && isV4:
+	case fields[1] == "TCP6" && !isV4:
+	default:
+		return nil, fmt.Errorf("%w: bad v1 protocol %q", ErrInvalidHeader, fields[1])
+	}
+
+	srcPort, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad v1 port", ErrInvalidHeader)
+	}
+	if _, err := strconv.ParseUint(fields[5], 10, 16); err != nil {
+		return nil, fmt.Errorf("%w: bad v1 port", ErrInvalidHeader)
+	}
+
+	return &net.TCPAddr{IP: srcIP, Port: int(srcPort)}, nil
+}
+
+func readV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, v2HeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	version, command := header[12]>>4, header[12]&0x0F
+	family := header[13] >> 4
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	if version != 2 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidHeader, version)
+	}
+	switch command {
+	case 0x0: // LOCAL, sent by the proxy itself e.g. for health checks.
+		return nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, fmt.Errorf("%w: unsupported command %d", ErrInvalidHeader, command)
+	}
+
+	// TLVs after the addresses are ignored.
+	switch family {
+	case 0x1: // AF_INET
+		if len(payload) < 12 {
+			return nil, fmt.Errorf("%w: short IPv4 addresses", ErrInvalidHeader)
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:4]),
+			Port: int(binary.BigEndian.Uint16(payload[8:10])),
+		}, nil
+	case 0x2: // AF_INET6
+		if len(payload) < 36 {
+			return nil, fmt.Errorf("%w: short IPv6 addresses", ErrInvalidHeader)
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:16]),
+			Port: int(binary.BigEndian.Uint16(payload[32:34])),
+		}, nil
+	default: // AF_UNSPEC and AF_UNIX carry no usable address.
+		return nil, nil
+	}
+}
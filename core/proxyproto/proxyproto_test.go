@@ -0,0 +1,95 @@
+package proxyproto
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadHeader(t *testing.T) {
+	cases := map[string]struct {
+		header string
+		want   string
+	}{
+		"v1 tcp4": {
+			header: "PROXY TCP4 203.0.113.7 10.0.0.1 51234 22\r\n",
+			want:   "203.0.113.7:51234",
+		},
+		"v1 tcp6": {
+			header: "PROXY TCP6 2001:db8::1 2001:db8::2 51234 22\r\n",
+			want:   "[2001:db8::1]:51234",
+		},
+		"v1 unknown": {
+			header: "PROXY UNKNOWN\r\n",
+		},
+		"v2 ipv4": {
+			header: "\r\n\r\n\x00\r\nQUIT\n" + "\x21\x11\x00\x0c" +
+				"\xcb\x00\x71\x07" + "\x0a\x00\x00\x01" + "\xc8\x22" + "\x00\x16",
+			want: "203.0.113.7:51234",
+		},
+		"v2 ipv4 with tlv": {
+			header: "\r\n\r\n\x00\r\nQUIT\n" + "\x21\x11\x00\x10" +
+				"\xcb\x00\x71\x07" + "\x0a\x00\x00\x01" + "\xc8\x22" + "\x00\x16" +
+				"\x04\x00\x01\x00",
+			want: "203.0.113.7:51234",
+		},
+		"v2 local": {
+			header: "\r\n\r\n\x00\r\nQUIT\n" + "\x20\x00\x00\x00",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tc.header + "SSH-2.0-Client\r\n"))
+			addr, err := ReadHeader(r)
+			require.Nil(t, err)
+			if tc.want == "" {
+				assert.Nil(t, addr)
+			} else {
+				assert.Equal(t, tc.want, addr.String())
+			}
+
+			rest, err := io.ReadAll(r)
+			require.Nil(t, err)
+			assert.Equal(t, "SSH-2.0-Client\r\n", string(rest))
+		})
+	}
+}
+
+func TestReadHeader_invalid(t *testing.T) {
+	for _, header := range []string{
+		"SSH-2.0-OpenSSH_8.2p1\r\n",
+		"PROXY TCP4 203.0.113.7 10.0.0.1 51234\r\n",
+		"PROXY TCP4 2001:db8::1 10.0.0.1 51234 22\r\n",
+		"PROXY TCP4 203.0.113.7 10.0.0.1 99999 22\r\n",
+		"PROXY TCP4 " + strings.Repeat("1", 100) + "\r\n",
+		"\r\n\r\n\x00\r\nQUIT\n" + "\x11\x11\x00\x00",
+		"\r\n\r\n\x00\r\nQUIT\n" + "\x21\x11\x00\x04" + "\xcb\x00\x71\x07",
+	} {
+		_, err := ReadHeader(bufio.NewReader(strings.NewReader(header)))
+		assert.ErrorIs(t, err, ErrInvalidHeader, "header %q", header)
+	}
+}
+
+func TestNewConn(t *testing.T) {
+	serverSide, clientSide := net.Pipe()
+	defer serverSide.Close()
+	defer clientSide.Close()
+
+	go clientSide.Write([]byte("PROXY TCP4 203.0.113.7 10.0.0.1 51234 22\r\nhello"))
+
+	conn, err := NewConn(serverSide, 5*time.Second)
+	require.Nil(t, err)
+	assert.Equal(t, "203.0.113.7:51234", conn.RemoteAddr().String())
+
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(conn, buf)
+	require.Nil(t, err)
+	assert.Equal(t, "hello", string(buf))
+}
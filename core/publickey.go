@@ -0,0 +1,182 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"log"
+	"path"
+	"sync"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/josephlewis42/honeyssh/core/vos"
+	"github.com/spf13/afero"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// rememberedKeysName is the state file remembered keys are saved to.
+const rememberedKeysName = "remembered_keys.json"
+
+// rememberedKeys holds public keys attackers added to authorized_keys files,
+// keyed by username.
+type rememberedKeys struct {
+	mu sync.Mutex
+	// Keys are base64 encoded in SSH wire format.
+	keys map[string][]string
+}
+
+// contains returns whether the key was remembered for the user.
+func (r *rememberedKeys) contains(username string, key ssh.PublicKey) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.containsLocked(username, base64.StdEncoding.EncodeToString(key.Marshal()))
+}
+
+func (r *rememberedKeys) containsLocked(username, encodedKey string) bool {
+	for _, remembered := range r.keys[username] {
+		if remembered == encodedKey {
+			return true
+		}
+	}
+	return false
+}
+
+// add remembers the key for the user and returns whether it was new.
+func (r *rememberedKeys) add(username string, key ssh.PublicKey) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	encodedKey := base64.StdEncoding.EncodeToString(key.Marshal())
+	if r.containsLocked(username, encodedKey) {
+		return false
+	}
+
+	if r.keys == nil {
+		r.keys = make(map[string][]string)
+	}
+	r.keys[username] = append(r.keys[username], encodedKey)
+	return true
+}
+
+// save writes the remembered keys to the state file.
+func (r *rememberedKeys) save(configuration *config.Configuration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return configuration.WriteState(rememberedKeysName, r.keys)
+}
+
+// parseAuthorizedKeys parses keys in authorized_keys format, skipping invalid
+// lines.
+func parseAuthorizedKeys(data []byte) []ssh.PublicKey {
+	var out []ssh.PublicKey
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, _, _, _, err := gossh.ParseAuthorizedKey(scanner.Bytes())
+		if err == nil {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+func containsKey(keys []ssh.PublicKey, key ssh.PublicKey) bool {
+	for _, k := range keys {
+		if ssh.KeysEqual(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizedKeysPath returns the path of the user's authorized_keys file in the
+// virtual filesystem or blank if it's disabled.
+func (l *listener) authorizedKeysPath(username string) string {
+	file := l.configuration.PublicKeyAuth.AuthorizedKeysFile
+	if file == "" {
+		return ""
+	}
+
+	home := "/"
+	if usr, ok := l.sharedOS.GetUser(username); ok && usr.Home != "" {
+		home = usr.Home
+	}
+	return path.Join(home, file)
+}
+
+// readAuthorizedKeys reads the user's authorized_keys file from the
+// filesystem.
+func (l *listener) readAuthorizedKeys(fs vos.VFS, username string) []ssh.PublicKey {
+	keysPath := l.authorizedKeysPath(username)
+	if keysPath == "" {
+		return nil
+	}
+
+	data, err := afero.ReadFile(fs, keysPath)
+	if err != nil {
+		return nil
+	}
+	return parseAuthorizedKeys(data)
+}
+
+// checkPublicKey returns where the key allowed to log in as the user came
+// from, or UNKNOWN if the key isn't allowed.
+func (l *listener) checkPublicKey(username string, key ssh.PublicKey) logger.LoginAttempt_KeySource {
+	var configKeys []ssh.PublicKey
+	for _, line := range l.configuration.GetAuthorizedKeys(username) {
+		configKeys = append(configKeys, parseAuthorizedKeys([]byte(line))...)
+	}
+
+	switch {
+	case containsKey(configKeys, key):
+		return logger.LoginAttempt_CONFIG
+	case containsKey(l.readAuthorizedKeys(l.sharedOS.ReadOnlyFs(), username), key):
+		return logger.LoginAttempt_AUTHORIZED_KEYS
+	case l.configuration.PublicKeyAuth.RememberAddedKeys && l.honeypot.rememberedKeys.contains(username, key):
+		return logger.LoginAttempt_REMEMBERED
+	default:
+		return logger.LoginAttempt_UNKNOWN
+	}
+}
+
+// rememberAddedKeys saves keys added to authorized_keys files during the
+// session so they can be used to log in later.
+func (l *listener) rememberAddedKeys(sessionLogger *logger.SessionLogger, sessionFS vos.VFS, sessionUser string) {
+	if !l.configuration.PublicKeyAuth.RememberAddedKeys {
+		return
+	}
+
+	usernames := []string{sessionUser}
+	for _, usr := range l.configuration.Users {
+		if usr.Username != sessionUser {
+			usernames = append(usernames, usr.Username)
+		}
+	}
+
+	var changed bool
+	for _, username := range usernames {
+		baseKeys := l.readAuthorizedKeys(l.sharedOS.ReadOnlyFs(), username)
+		for _, key := range l.readAuthorizedKeys(sessionFS, username) {
+			if containsKey(baseKeys, key) || !l.honeypot.rememberedKeys.add(username, key) {
+				continue
+			}
+			changed = true
+
+			sessionLogger.Record(&logger.LogEntry_UsedCredentials{
+				UsedCredentials: &logger.Credentials{
+					Username:  username,
+					PublicKey: key.Marshal(),
+				},
+			})
+		}
+	}
+
+	if changed {
+		if err := l.honeypot.rememberedKeys.save(l.configuration); err != nil {
+			log.Printf("couldn't save remembered keys: %v\n", err)
+		}
+	}
+}
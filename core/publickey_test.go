@@ -0,0 +1,49 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func newTestPublicKey(t *testing.T) gossh.PublicKey {
+	t.Helper()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	key, err := gossh.NewPublicKey(pub)
+	require.Nil(t, err)
+	return key
+}
+
+func TestParseAuthorizedKeys(t *testing.T) {
+	first := newTestPublicKey(t)
+	second := newTestPublicKey(t)
+
+	data := "# comment\n" +
+		string(gossh.MarshalAuthorizedKey(first)) +
+		"not a key\n" +
+		"\n" +
+		`command="/bin/false" ` + string(gossh.MarshalAuthorizedKey(second))
+
+	keys := parseAuthorizedKeys([]byte(data))
+	require.Len(t, keys, 2)
+	assert.True(t, containsKey(keys, first))
+	assert.True(t, containsKey(keys, second))
+	assert.False(t, containsKey(keys, newTestPublicKey(t)))
+}
+
+func TestRememberedKeys(t *testing.T) {
+	key := newTestPublicKey(t)
+	remembered := &rememberedKeys{}
+
+	assert.False(t, remembered.contains("root", key))
+	assert.True(t, remembered.add("root", key), "first add")
+	assert.False(t, remembered.add("root", key), "duplicate add")
+	assert.True(t, remembered.contains("root", key))
+	assert.False(t, remembered.contains("admin", key), "keys are per user")
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/josephlewis42/honeyssh/core/logger"
+)
+
+// Reload loads a new configuration that new sessions will use, sessions that
+// are already running keep the configuration they started with. Listener
+// ports, SSH profiles and host keys only change on restart.
+//
+// The outcome is logged, if the configuration can't be loaded the previous one
+// stays in use.
+func (h *Honeypot) Reload(load func() (*config.Configuration, error)) error {
+	event := &logger.HoneypotEvent{
+		EventType: logger.HoneypotEvent_RELOAD,
+	}
+
+	err := h.reload(load)
+	if err != nil {
+		event.EventType = logger.HoneypotEvent_RELOAD_FAILED
+		event.Error = err.Error()
+	}
+
+	h.logger.Sessionless().Record(&logger.LogEntry_HoneypotEvent{
+		HoneypotEvent: event,
+	})
+	return err
+}
+
+func (h *Honeypot) reload(load func() (*config.Configuration, error)) error {
+	configuration, err := load()
+	if err != nil {
+		return err
+	}
+
+	listenerConfigs := configuration.ListenerConfigurations()
+	if len(listenerConfigs) != len(h.servers) {
+		return fmt.Errorf("number of listeners changed from %d to %d, restart to apply", len(h.servers), len(listenerConfigs))
+	}
+
+	// Create every listener before swapping any so a bad configuration doesn't
+	// leave the honeypot partially reloaded.
+	var listeners []*listener
+	for i, listenerConfig := range listenerConfigs {
+		previous := h.servers[i].current()
+		if name := listenerConfig.ListenerName(); name != previous.configuration.ListenerName() {
+			return fmt.Errorf("listener %q was replaced by %q, restart to apply", previous.configuration.ListenerName(), name)
+		}
+
+		l, err := h.newListener(listenerConfig, previous)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, l)
+	}
+
+	for i, l := range listeners {
+		h.servers[i].swap(l)
+	}
+	h.configuration = configuration
+	return nil
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lastEvent returns the last honeypot event in the log.
+func lastEvent(t *testing.T, eventLog *bytes.Buffer) *logger.HoneypotEvent {
+	t.Helper()
+
+	var last *logger.HoneypotEvent
+	err := logger.ReadJSONLinesLog(bytes.NewReader(eventLog.Bytes()), func(le *logger.LogEntry) {
+		if event := le.GetHoneypotEvent(); event != nil {
+			last = event
+		}
+	})
+	require.Nil(t, err)
+	return last
+}
+
+func TestHoneypot_Reload(t *testing.T) {
+	tempDir := t.TempDir()
+	_, err := config.Initialize(tempDir, log.New(ioutil.Discard, "", 0))
+	require.Nil(t, err)
+	cfg, err := config.Load(tempDir)
+	require.Nil(t, err)
+
+	eventLog := &bytes.Buffer{}
+	honeypot, err := NewHoneypot(cfg, eventLog)
+	require.Nil(t, err)
+	defer honeypot.Close()
+
+	original := honeypot.servers[0].current()
+
+	t.Run("success", func(t *testing.T) {
+		err := honeypot.Reload(func() (*config.Configuration, error) {
+			reloaded, err := config.Load(tempDir)
+			if err != nil {
+				return nil, err
+			}
+			reloaded.GlobalPasswords = []string{"hunter2"}
+			return reloaded, nil
+		})
+		require.Nil(t, err)
+
+		current := honeypot.servers[0].current()
+		assert.Equal(t, []string{"hunter2"}, current.configuration.GlobalPasswords)
+		assert.Empty(t, original.configuration.GlobalPasswords, "running sessions keep their configuration")
+		assert.Equal(t, original.sharedOS.BootTime(), current.sharedOS.BootTime())
+		assert.Same(t, original.lastLog, current.lastLog)
+		assert.Equal(t, logger.HoneypotEvent_RELOAD, lastEvent(t, eventLog).GetEventType())
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		before := honeypot.servers[0].current()
+		err := honeypot.Reload(func() (*config.Configuration, error) {
+			return nil, errors.New("bad config")
+		})
+		assert.NotNil(t, err)
+		assert.Same(t, before, honeypot.servers[0].current())
+		event := lastEvent(t, eventLog)
+		assert.Equal(t, logger.HoneypotEvent_RELOAD_FAILED, event.GetEventType())
+		assert.Equal(t, "bad config", event.GetError())
+	})
+
+	t.Run("listeners changed", func(t *testing.T) {
+		err := honeypot.Reload(func() (*config.Configuration, error) {
+			reloaded, err := config.Load(tempDir)
+			if err != nil {
+				return nil, err
+			}
+			reloaded.Listeners = []config.Listener{{Name: "a", SSHPort: 2222}, {Name: "b", SSHPort: 2223}}
+			return reloaded, nil
+		})
+		assert.NotNil(t, err)
+	})
+}
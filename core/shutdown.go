@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// wallTimeFormat matches the time format of systemd's shutdown broadcasts.
+	wallTimeFormat = "Mon 2006-01-02 15:04:05 MST"
+
+	// sessionFlushTimeout is how long closed sessions get to finish logging
+	// during shutdown.
+	sessionFlushTimeout = 5 * time.Second
+)
+
+// activeSession is a running session that's ended when the honeypot shuts
+// down.
+type activeSession struct {
+	// shutdown is closed when the session must end.
+	shutdown chan struct{}
+
+	mu sync.Mutex
+	// terminal writes directly to the client's terminal, nil if the session
+	// doesn't have one.
+	terminal io.Writer
+	hostname string
+}
+
+// setTerminal sets where shutdown broadcasts are written.
+func (a *activeSession) setTerminal(w io.Writer, hostname string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.terminal = w
+	a.hostname = hostname
+}
+
+// sessionTracker tracks running sessions so they can be drained on shutdown.
+type sessionTracker struct {
+	mu           sync.Mutex
+	sessions     map[*activeSession]bool
+	shuttingDown bool
+	// empty is closed when the last session is removed, nil if nothing is
+	// waiting.
+	empty chan struct{}
+}
+
+// add starts tracking a session, if the honeypot is shutting down the session
+// is told to end immediately.
+func (t *sessionTracker) add() *activeSession {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	session := &activeSession{shutdown: make(chan struct{})}
+	if t.shuttingDown {
+		close(session.shutdown)
+	}
+	if t.sessions == nil {
+		t.sessions = make(map[*activeSession]bool)
+	}
+	t.sessions[session] = true
+	return session
+}
+
+// remove stops tracking a session once it has finished logging.
+func (t *sessionTracker) remove(session *activeSession) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.sessions, session)
+	if len(t.sessions) == 0 && t.empty != nil {
+		close(t.empty)
+		t.empty = nil
+	}
+}
+
+// broadcast announces the reboot to every session with a terminal, like
+// `shutdown -r` does.
+func (t *sessionTracker) broadcast(now, rebootAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for session := range t.sessions {
+		session.mu.Lock()
+		terminal, hostname := session.terminal, session.hostname
+		session.mu.Unlock()
+		if terminal == nil {
+			continue
+		}
+
+		msg := fmt.Sprintf("\nBroadcast message from root@%s (%s):\n\nThe system is going down for reboot at %s!\n\n",
+			hostname,
+			now.Format(wallTimeFormat),
+			rebootAt.Format(wallTimeFormat))
+
+		// Slow clients mustn't hold up the shutdown.
+		go io.WriteString(terminal, strings.ReplaceAll(msg, "\n", "\r\n"))
+	}
+}
+
+// closeAll tells every session, including ones started later, to end.
+func (t *sessionTracker) closeAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.shuttingDown {
+		return
+	}
+	t.shuttingDown = true
+	for session := range t.sessions {
+		close(session.shutdown)
+	}
+}
+
+// wait blocks until every session has been removed or the context is done.
+func (t *sessionTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if len(t.sessions) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.empty == nil {
+		t.empty = make(chan struct{})
+	}
+	empty := t.empty
+	t.mu.Unlock()
+
+	select {
+	case <-empty:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer collects writes from the broadcast goroutines.
+type syncBuffer chan string
+
+func (b syncBuffer) Write(p []byte) (int, error) {
+	b <- string(p)
+	return len(p), nil
+}
+
+func TestSessionTracker(t *testing.T) {
+	var tracker sessionTracker
+
+	withTerminal := tracker.add()
+	terminal := make(syncBuffer, 1)
+	withTerminal.setTerminal(terminal, "web01")
+	withoutTerminal := tracker.add()
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tracker.broadcast(now, now.Add(time.Minute))
+	assert.Equal(t,
+		"\r\nBroadcast message from root@web01 (Fri 2026-01-02 03:04:05 UTC):\r\n\r\n"+
+			"The system is going down for reboot at Fri 2026-01-02 03:05:05 UTC!\r\n\r\n",
+		<-terminal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, tracker.wait(ctx), context.DeadlineExceeded)
+
+	tracker.closeAll()
+	for _, session := range []*activeSession{withTerminal, withoutTerminal} {
+		select {
+		case <-session.shutdown:
+		default:
+			t.Error("session wasn't told to shut down")
+		}
+	}
+
+	late := tracker.add()
+	select {
+	case <-late.shutdown:
+	default:
+		t.Error("sessions started after shutdown should end immediately")
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- tracker.wait(context.Background())
+	}()
+	tracker.remove(withTerminal)
+	tracker.remove(withoutTerminal)
+	tracker.remove(late)
+	assert.Nil(t, <-done)
+}
@@ -0,0 +1,403 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/josephlewis42/honeyssh/core/telnet"
+)
+
+const (
+	// telnetLoginTimeout matches login's default LOGIN_TIMEOUT.
+	telnetLoginTimeout = 60 * time.Second
+	// telnetLoginRetries is the number of failed logins before the connection
+	// is closed, like BusyBox login.
+	telnetLoginRetries = 3
+	// maxTelnetLineLength is the longest username or password kept.
+	maxTelnetLineLength = 256
+	// telnetAcceptRetryDelay is how long to wait after a failed accept.
+	telnetAcceptRetryDelay = time.Second
+)
+
+// telnetServer accepts telnet connections for a server.
+type telnetServer struct {
+	addr string
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]bool
+	closed   bool
+}
+
+// newTelnetServer creates a telnet server if the configuration enables it.
+func newTelnetServer(port int) *telnetServer {
+	if port == 0 {
+		return nil
+	}
+	return &telnetServer{addr: fmt.Sprintf(":%d", port)}
+}
+
+// track adds or removes an open connection, it returns false if the server
+// has been closed.
+func (t *telnetServer) track(conn net.Conn, add bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !add {
+		delete(t.conns, conn)
+		return true
+	}
+	if t.closed {
+		return false
+	}
+	if t.conns == nil {
+		t.conns = make(map[net.Conn]bool)
+	}
+	t.conns[conn] = true
+	return true
+}
+
+func (t *telnetServer) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.closed
+}
+
+// stopListening stops accepting new connections, existing connections are
+// left open.
+func (t *telnetServer) stopListening() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	if t.listener != nil {
+		t.listener.Close()
+	}
+}
+
+// Close stops accepting connections and closes the open ones.
+func (t *telnetServer) Close() error {
+	t.stopListening()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var lastErr error
+	for conn := range t.conns {
+		if err := conn.Close(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// listenAndServeTelnet accepts telnet connections until the server is shut
+// down.
+func (s *server) listenAndServeTelnet() error {
+	log.Printf("- Starting telnet server on %v\n", s.telnet.addr)
+	ln, err := net.Listen("tcp", s.telnet.addr)
+	if err != nil {
+		return err
+	}
+
+	s.telnet.mu.Lock()
+	closed := s.telnet.closed
+	s.telnet.listener = ln
+	s.telnet.mu.Unlock()
+	if closed {
+		return ln.Close()
+	}
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.telnet.isClosed() {
+				return nil
+			}
+			log.Printf("telnet: accept error: %v; retrying in %v\n", err, telnetAcceptRetryDelay)
+			time.Sleep(telnetAcceptRetryDelay)
+			continue
+		}
+
+		go s.handleTelnet(conn)
+	}
+}
+
+func (s *server) handleTelnet(conn net.Conn) {
+	defer conn.Close()
+	if !s.telnet.track(conn, true) {
+		return
+	}
+	defer s.telnet.track(conn, false)
+
+	s.current().handleTelnet(conn)
+}
+
+// handleTelnet logs the client in and runs their session.
+func (l *listener) handleTelnet(conn net.Conn) {
+	ctx := context.WithValue(context.Background(), ContextConnectionID, logger.NewID())
+
+	if l.configuration.ProxyProtocol {
+		conn = l.readProxyHeader(ctx, conn)
+		if conn == nil {
+			return
+		}
+	}
+
+	conn = l.limitConnection(ctx, conn)
+	if conn == nil {
+		return
+	}
+
+	telnetConn := telnet.NewConn(conn)
+	defer telnetConn.Close()
+	ctx = context.WithValue(ctx, ContextTelnetConn, telnetConn)
+
+	login, ok := l.telnetLogin(ctx, telnetConn)
+	if !ok {
+		return
+	}
+	l.HandleConnection(&telnetSession{login: login, conn: telnetConn})
+}
+
+// telnetLogin asks for a username and password like login(1), it returns
+// the login once the client has authenticated.
+func (l *listener) telnetLogin(ctx context.Context, conn *telnet.Conn) (*telnetLogin, bool) {
+	if err := conn.Negotiate(); err != nil {
+		return nil, false
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(telnetLoginTimeout)); err != nil {
+		return nil, false
+	}
+
+	if banner := strings.TrimRight(l.configuration.Telnet.Banner, "\n"); banner != "" {
+		io.WriteString(conn, strings.ReplaceAll(banner, "\n", "\r\n")+"\r\n")
+	}
+
+	for failures := 0; failures < telnetLoginRetries; {
+		fmt.Fprintf(conn, "%s login: ", l.sharedOS.Hostname())
+		username, err := readTelnetLine(conn, true)
+		if err != nil {
+			writeTelnetLoginError(conn, err)
+			return nil, false
+		}
+		if username == "" {
+			continue
+		}
+
+		io.WriteString(conn, "Password: ")
+		password, err := readTelnetLine(conn, false)
+		if err != nil {
+			writeTelnetLoginError(conn, err)
+			return nil, false
+		}
+
+		login := &telnetLogin{
+			Context:    ctx,
+			user:       username,
+			remoteAddr: conn.RemoteAddr(),
+		}
+
+		// Rate limited attempts are logged so brute force attempts aren't lost.
+		result := logger.OperationResult_RATE_LIMITED
+		if l.allowAuthAttempt(login) {
+			if l.checkPassword(login, password) {
+				// The login will be logged when the session starts.
+				login.Context = context.WithValue(login.Context, ContextAuthPassword, password)
+				login.Context = context.WithValue(login.Context, ContextAuthMethod, authMethodPassword)
+				if err := conn.SetReadDeadline(time.Time{}); err != nil {
+					return nil, false
+				}
+				return login, true
+			}
+			result = logger.OperationResult_FAILURE
+		}
+
+		l.connectionLogger(ctx).Record(&logger.LogEntry_LoginAttempt{
+			LoginAttempt: &logger.LoginAttempt{
+				Result:       result,
+				Username:     username,
+				Password:     password,
+				RemoteAddr:   fmt.Sprintf("%v", conn.RemoteAddr()),
+				AuthMethod:   authMethodPassword,
+				TelnetClient: telnetClient(ctx),
+			},
+		})
+		if result == logger.OperationResult_FAILURE {
+			l.tarpit()
+		}
+		io.WriteString(conn, "\r\nLogin incorrect\r\n")
+		failures++
+	}
+
+	return nil, false
+}
+
+// writeTelnetLoginError tells the client why the login ended, if it's still
+// there to see it.
+func writeTelnetLoginError(conn *telnet.Conn, err error) {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		fmt.Fprintf(conn, "\r\nLogin timed out after %d seconds.\r\n", int(telnetLoginTimeout.Seconds()))
+	}
+}
+
+// readTelnetLine reads a line typed at a login prompt. Clients that agreed to
+// the server echoing don't show what's typed, so it's echoed back if echo is
+// set.
+func readTelnetLine(conn io.ReadWriter, echo bool) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		if n == 0 {
+			continue
+		}
+
+		switch ch := buf[0]; {
+		case ch == '\r' || ch == '\n':
+			io.WriteString(conn, "\r\n")
+			return string(line), nil
+		case ch == 0x7f || ch == '\b':
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+				if echo {
+					io.WriteString(conn, "\b \b")
+				}
+			}
+		case ch == 0x04 && len(line) == 0: // Ctrl-D
+			return "", io.EOF
+		case ch < ' ':
+			// Ignore other control characters.
+		case len(line) < maxTelnetLineLength:
+			line = append(line, ch)
+			if echo {
+				conn.Write(buf)
+			}
+		}
+	}
+}
+
+// telnetClient returns the telnet client information attached to the context
+// or nil if the connection isn't telnet.
+func telnetClient(ctx context.Context) *logger.TelnetClient {
+	conn, ok := ctx.Value(ContextTelnetConn).(*telnet.Conn)
+	if !ok {
+		return nil
+	}
+
+	return &logger.TelnetClient{
+		TerminalType: conn.TerminalType(),
+		Negotiation:  conn.Negotiation(),
+	}
+}
+
+// telnetLogin is the user a telnet client logged in as.
+type telnetLogin struct {
+	context.Context
+
+	user       string
+	remoteAddr net.Addr
+}
+
+var _ authContext = (*telnetLogin)(nil)
+
+func (t *telnetLogin) User() string {
+	return t.user
+}
+
+func (t *telnetLogin) RemoteAddr() net.Addr {
+	return t.remoteAddr
+}
+
+// telnetSession is an interactive shell over telnet.
+type telnetSession struct {
+	login *telnetLogin
+	conn  *telnet.Conn
+}
+
+var _ SessionInfo = (*telnetSession)(nil)
+
+func (t *telnetSession) Context() context.Context {
+	return t.login
+}
+
+func (t *telnetSession) Environ() []string {
+	return nil
+}
+
+func (t *telnetSession) RawCommand() string {
+	return ""
+}
+
+func (t *telnetSession) Subsystem() string {
+	return ""
+}
+
+func (t *telnetSession) Command() []string {
+	return nil
+}
+
+// Pty returns the client's terminal, telnet sessions always have one.
+func (t *telnetSession) Pty() (ssh.Pty, <-chan ssh.Window, bool) {
+	window, ok := t.conn.Window()
+	if !ok {
+		window = telnet.Window{Width: 80, Height: 24}
+	}
+
+	winch := make(chan ssh.Window)
+	go func() {
+		defer close(winch)
+		for window := range t.conn.WindowChanges() {
+			winch <- ssh.Window{Width: window.Width, Height: window.Height}
+		}
+	}()
+
+	return ssh.Pty{
+		// telnetd sets TERM to the lowercase terminal type.
+		Term: strings.ToLower(t.conn.TerminalType()),
+		Window: ssh.Window{
+			Width:  window.Width,
+			Height: window.Height,
+		},
+	}, winch, true
+}
+
+func (t *telnetSession) User() string {
+	return t.login.User()
+}
+
+func (t *telnetSession) RemoteAddr() net.Addr {
+	return t.login.RemoteAddr()
+}
+
+// Exit does nothing, telnet has no exit status. The connection is closed
+// when the session ends.
+func (t *telnetSession) Exit(code int) error {
+	return nil
+}
+
+func (t *telnetSession) Write(b []byte) (int, error) {
+	return t.conn.Write(b)
+}
+
+func (t *telnetSession) Read(b []byte) (int, error) {
+	return t.conn.Read(b)
+}
+
+func (t *telnetSession) Close() error {
+	return t.conn.Close()
+}
@@ -0,0 +1,394 @@
+// Package telnet negotiates telnet options with clients so they can be given
+// an interactive terminal, reads and writes on the connection carry only
+// data.
+//
+// See: RFC 854 (protocol), RFC 857 (ECHO), RFC 858 (SGA), RFC 1073 (NAWS) and
+// RFC 1091 (TTYPE).
+package telnet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Telnet commands.
+const (
+	cmdSE   = 240
+	cmdIP   = 244
+	cmdSB   = 250
+	cmdWILL = 251
+	cmdWONT = 252
+	cmdDO   = 253
+	cmdDONT = 254
+	cmdIAC  = 255
+)
+
+// Telnet options.
+const (
+	optEcho  = 1
+	optSGA   = 3
+	optTTYPE = 24
+	optNAWS  = 31
+)
+
+const (
+	ttypeIS   = 0
+	ttypeSend = 1
+
+	// maxSubnegotiation is the number of subnegotiation bytes kept, the rest
+	// are discarded.
+	maxSubnegotiation = 256
+	// maxNegotiation is the number of client commands recorded.
+	maxNegotiation = 64
+
+	// ctrlC is sent to the program when the client sends an interrupt.
+	ctrlC = 0x03
+)
+
+var commandNames = map[byte]string{
+	cmdWILL: "WILL",
+	cmdWONT: "WONT",
+	cmdDO:   "DO",
+	cmdDONT: "DONT",
+	cmdSB:   "SB",
+}
+
+var optionNames = map[byte]string{
+	0:        "BINARY",
+	optEcho:  "ECHO",
+	optSGA:   "SGA",
+	5:        "STATUS",
+	6:        "TIMING-MARK",
+	optTTYPE: "TTYPE",
+	optNAWS:  "NAWS",
+	32:       "TSPEED",
+	33:       "LFLOW",
+	34:       "LINEMODE",
+	35:       "XDISPLOC",
+	36:       "ENVIRON",
+	39:       "NEW-ENVIRON",
+}
+
+// optionState tracks one side of an option, see the Q method in RFC 1143 for
+// a more complete version.
+type optionState int
+
+const (
+	optionOff optionState = iota
+	optionRequested
+	optionOn
+)
+
+// Options the server enables on its side and asks the client to enable.
+var (
+	localOptions  = map[byte]bool{optEcho: true, optSGA: true}
+	remoteOptions = map[byte]bool{optSGA: true, optTTYPE: true, optNAWS: true}
+)
+
+// Window is the size of the client's terminal in characters.
+type Window struct {
+	Width  int
+	Height int
+}
+
+// Conn is a net.Conn that handles telnet commands in the data stream.
+type Conn struct {
+	net.Conn
+
+	readMu sync.Mutex
+	reader *bufio.Reader
+	// lastCR is set if the last data byte was a carriage return.
+	lastCR bool
+
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	local         map[byte]optionState
+	remote        map[byte]optionState
+	terminalType  string
+	window        Window
+	hasWindow     bool
+	negotiation   []string
+	windowChanges chan Window
+	closed        bool
+}
+
+// NewConn wraps the connection, call Negotiate to start option negotiation.
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{
+		Conn:          conn,
+		reader:        bufio.NewReader(conn),
+		local:         make(map[byte]optionState),
+		remote:        make(map[byte]optionState),
+		windowChanges: make(chan Window, 1),
+	}
+}
+
+// Negotiate asks the client to use character at a time mode with the server
+// echoing and to send its terminal type and window size. Responses are
+// handled as data is read.
+func (c *Conn) Negotiate() error {
+	c.mu.Lock()
+	var out []byte
+	for _, opt := range []byte{optEcho, optSGA} {
+		c.local[opt] = optionRequested
+		out = append(out, cmdIAC, cmdWILL, opt)
+	}
+	for _, opt := range []byte{optSGA, optTTYPE, optNAWS} {
+		c.remote[opt] = optionRequested
+		out = append(out, cmdIAC, cmdDO, opt)
+	}
+	c.mu.Unlock()
+
+	return c.writeRaw(out)
+}
+
+// TerminalType returns the terminal type the client sent, blank if it hasn't
+// sent one.
+func (c *Conn) TerminalType() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.terminalType
+}
+
+// Window returns the client's window size and whether the client has sent
+// it.
+func (c *Conn) Window() (Window, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.window, c.hasWindow
+}
+
+// WindowChanges receives the latest window size when the client sends a new
+// one, it's closed when the connection is closed.
+func (c *Conn) WindowChanges() <-chan Window {
+	return c.windowChanges
+}
+
+// Negotiation returns the option commands the client sent in order e.g.
+// "WILL NAWS", useful for fingerprinting.
+func (c *Conn) Negotiation() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.negotiation...)
+}
+
+// Read implements io.Reader, telnet commands are handled and removed from
+// the data. Carriage returns followed by a line feed or NUL are read as a
+// single carriage return, like the enter key in a terminal.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	n := 0
+	for n < len(b) && (n == 0 || c.reader.Buffered() > 0) {
+		ch, err := c.reader.ReadByte()
+		if err == nil && ch == cmdIAC {
+			var ok bool
+			ch, ok, err = c.readCommand()
+			if err == nil && !ok {
+				continue
+			}
+		}
+		if err != nil {
+			if n > 0 {
+				// The error is returned again on the next read.
+				return n, nil
+			}
+			return 0, err
+		}
+
+		if c.lastCR && (ch == '\n' || ch == 0) {
+			c.lastCR = false
+			continue
+		}
+		c.lastCR = ch == '\r'
+		b[n] = ch
+		n++
+	}
+	return n, nil
+}
+
+// Write implements io.Writer, IAC bytes in the data are escaped.
+func (c *Conn) Write(b []byte) (int, error) {
+	if err := c.writeRaw(bytes.ReplaceAll(b, []byte{cmdIAC}, []byte{cmdIAC, cmdIAC})); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close implements io.Closer.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.windowChanges)
+	}
+	c.mu.Unlock()
+
+	return c.Conn.Close()
+}
+
+func (c *Conn) writeRaw(b []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err := c.Conn.Write(b)
+	return err
+}
+
+// readCommand reads the command following an IAC, it returns the data byte
+// the command stands for if there is one.
+func (c *Conn) readCommand() (byte, bool, error) {
+	cmd, err := c.reader.ReadByte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch cmd {
+	case cmdIAC:
+		return cmdIAC, true, nil
+	case cmdIP:
+		return ctrlC, true, nil
+	case cmdWILL, cmdWONT, cmdDO, cmdDONT:
+		opt, err := c.reader.ReadByte()
+		if err != nil {
+			return 0, false, err
+		}
+		c.negotiate(cmd, opt)
+	case cmdSB:
+		opt, data, err := c.readSubnegotiation()
+		if err != nil {
+			return 0, false, err
+		}
+		c.subnegotiate(opt, data)
+	}
+
+	// Other commands like NOP, GA and AYT are ignored.
+	return 0, false, nil
+}
+
+// readSubnegotiation reads the option and data of a subnegotiation up to the
+// closing IAC SE.
+func (c *Conn) readSubnegotiation() (byte, []byte, error) {
+	opt, err := c.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var data []byte
+	for {
+		ch, err := c.reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		if ch == cmdIAC {
+			if ch, err = c.reader.ReadByte(); err != nil {
+				return 0, nil, err
+			}
+			if ch == cmdSE {
+				return opt, data, nil
+			}
+		}
+		if len(data) < maxSubnegotiation {
+			data = append(data, ch)
+		}
+	}
+}
+
+// negotiate responds to an option command from the client.
+func (c *Conn) negotiate(cmd, opt byte) {
+	c.mu.Lock()
+	c.record(cmd, opt)
+
+	var reply []byte
+	switch cmd {
+	case cmdWILL, cmdWONT:
+		var enabled bool
+		reply, enabled = answer(c.remote, remoteOptions, opt, cmd == cmdWILL, cmdDO, cmdDONT)
+		if enabled && opt == optTTYPE {
+			reply = append(reply, cmdIAC, cmdSB, optTTYPE, ttypeSend, cmdIAC, cmdSE)
+		}
+	case cmdDO, cmdDONT:
+		reply, _ = answer(c.local, localOptions, opt, cmd == cmdDO, cmdWILL, cmdWONT)
+	}
+	c.mu.Unlock()
+
+	if len(reply) > 0 {
+		// Write errors show up on the next read.
+		c.writeRaw(reply)
+	}
+}
+
+// answer updates the state of one side of an option after the client asks
+// to enable or disable it. It returns the reply to send, if any, and whether
+// the option was just enabled.
+func answer(states map[byte]optionState, supported map[byte]bool, opt byte, enable bool, accept, refuse byte) ([]byte, bool) {
+	state := states[opt]
+	switch {
+	case enable && !supported[opt]:
+		return []byte{cmdIAC, refuse, opt}, false
+	case enable && state == optionOff:
+		states[opt] = optionOn
+		return []byte{cmdIAC, accept, opt}, true
+	case enable:
+		// The client agreed to the server's request.
+		states[opt] = optionOn
+		return nil, state == optionRequested
+	case state == optionOn:
+		states[opt] = optionOff
+		return []byte{cmdIAC, refuse, opt}, false
+	default:
+		// The client refused the server's request.
+		states[opt] = optionOff
+		return nil, false
+	}
+}
+
+// subnegotiate handles the window size and terminal type sent by the client.
+func (c *Conn) subnegotiate(opt byte, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.record(cmdSB, opt)
+
+	switch {
+	case opt == optNAWS && len(data) >= 4:
+		c.window = Window{
+			Width:  int(binary.BigEndian.Uint16(data[0:2])),
+			Height: int(binary.BigEndian.Uint16(data[2:4])),
+		}
+		c.hasWindow = true
+		if !c.closed {
+			// Only the latest size matters.
+			select {
+			case <-c.windowChanges:
+			default:
+			}
+			c.windowChanges <- c.window
+		}
+	case opt == optTTYPE && len(data) >= 1 && data[0] == ttypeIS:
+		c.terminalType = string(data[1:])
+	}
+}
+
+// record adds a command from the client to the negotiation, c.mu must be
+// held.
+func (c *Conn) record(cmd, opt byte) {
+	if len(c.negotiation) >= maxNegotiation {
+		return
+	}
+
+	optName, ok := optionNames[opt]
+	if !ok {
+		optName = fmt.Sprint(opt)
+	}
+	c.negotiation = append(c.negotiation, commandNames[cmd]+" "+optName)
+}
@@ -0,0 +1,109 @@
+package telnet
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn reads the client's data from in and collects the server's writes.
+type fakeConn struct {
+	net.Conn
+
+	in  io.Reader
+	out bytes.Buffer
+}
+
+func (f *fakeConn) Read(b []byte) (int, error)  { return f.in.Read(b) }
+func (f *fakeConn) Write(b []byte) (int, error) { return f.out.Write(b) }
+func (f *fakeConn) Close() error                { return nil }
+
+func TestConn_Read(t *testing.T) {
+	cases := map[string]struct {
+		in   string
+		want string
+	}{
+		"plain":             {in: "ls -la", want: "ls -la"},
+		"crlf":              {in: "ls\r\npwd\r\n", want: "ls\rpwd\r"},
+		"cr nul":            {in: "ls\r\x00pwd\r\x00", want: "ls\rpwd\r"},
+		"bare lf":           {in: "ls\n", want: "ls\n"},
+		"escaped iac":       {in: "a\xff\xffb", want: "a\xffb"},
+		"interrupt":         {in: "sleep 10\r\xff\xf4", want: "sleep 10\r\x03"},
+		"commands stripped": {in: "a\xff\xf1b\xff\xfa\x05\x00\xff\xf0c", want: "abc"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			conn := NewConn(&fakeConn{in: strings.NewReader(tc.in)})
+			got, err := io.ReadAll(conn)
+			require.Nil(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestConn_Negotiate(t *testing.T) {
+	client := []byte{
+		cmdIAC, cmdWILL, optTTYPE,
+		cmdIAC, cmdWILL, optNAWS,
+		cmdIAC, cmdSB, optNAWS, 0, 132, 0, 43, cmdIAC, cmdSE,
+		cmdIAC, cmdDO, optEcho,
+		cmdIAC, cmdWILL, 34, // LINEMODE
+		cmdIAC, cmdDO, 5, // STATUS
+		cmdIAC, cmdSB, optTTYPE, ttypeIS, 'X', 'T', 'E', 'R', 'M', cmdIAC, cmdSE,
+	}
+	fake := &fakeConn{in: bytes.NewReader(append(client, "root\r\n"...))}
+	conn := NewConn(fake)
+
+	require.Nil(t, conn.Negotiate())
+	data, err := io.ReadAll(conn)
+	require.Nil(t, err)
+	assert.Equal(t, "root\r", string(data))
+
+	assert.Equal(t, "XTERM", conn.TerminalType())
+	window, ok := conn.Window()
+	assert.True(t, ok)
+	assert.Equal(t, Window{Width: 132, Height: 43}, window)
+	assert.Equal(t, Window{Width: 132, Height: 43}, <-conn.WindowChanges())
+	assert.Equal(t, []string{
+		"WILL TTYPE",
+		"WILL NAWS",
+		"SB NAWS",
+		"DO ECHO",
+		"WILL LINEMODE",
+		"DO STATUS",
+		"SB TTYPE",
+	}, conn.Negotiation())
+
+	assert.Equal(t, []byte{
+		// Negotiate
+		cmdIAC, cmdWILL, optEcho,
+		cmdIAC, cmdWILL, optSGA,
+		cmdIAC, cmdDO, optSGA,
+		cmdIAC, cmdDO, optTTYPE,
+		cmdIAC, cmdDO, optNAWS,
+		// Replies
+		cmdIAC, cmdSB, optTTYPE, ttypeSend, cmdIAC, cmdSE,
+		cmdIAC, cmdDONT, 34,
+		cmdIAC, cmdWONT, 5,
+	}, fake.out.Bytes())
+
+	require.Nil(t, conn.Close())
+	_, ok = <-conn.WindowChanges()
+	assert.False(t, ok, "window changes should be closed")
+}
+
+func TestConn_Write(t *testing.T) {
+	fake := &fakeConn{}
+	conn := NewConn(fake)
+
+	n, err := conn.Write([]byte("a\xffb"))
+	require.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "a\xff\xffb", fake.out.String())
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runTelnetSession sends the client's input to the first server's telnet
+// handler and returns everything the server wrote once it's done.
+func runTelnetSession(honeypot *Honeypot, input string) string {
+	client, serverConn := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		honeypot.servers[0].handleTelnet(serverConn)
+	}()
+
+	output := make(chan []byte)
+	go func() {
+		out, _ := io.ReadAll(client)
+		output <- out
+	}()
+
+	client.Write([]byte(input))
+	<-done
+	client.Close()
+	return string(<-output)
+}
+
+func newTelnetHoneypot(t *testing.T, configure func(*config.Configuration)) (*Honeypot, *bytes.Buffer) {
+	tempDir := t.TempDir()
+	_, err := config.Initialize(tempDir, log.New(ioutil.Discard, "", 0))
+	require.Nil(t, err)
+	cfg, err := config.Load(tempDir)
+	require.Nil(t, err)
+	cfg.GlobalPasswords = []string{"hunter2"}
+	cfg.Limits.FailedLoginDelay = 0
+	cfg.Telnet = config.Telnet{Port: 2323, Banner: "Ubuntu 18.04.5 LTS\n"}
+	configure(cfg)
+
+	eventLog := &bytes.Buffer{}
+	honeypot, err := NewHoneypot(cfg, eventLog)
+	require.Nil(t, err)
+	t.Cleanup(func() { honeypot.Close() })
+	return honeypot, eventLog
+}
+
+// loginAttempts returns the login attempts in the log.
+func loginAttempts(t *testing.T, eventLog *bytes.Buffer) []*logger.LoginAttempt {
+	var attempts []*logger.LoginAttempt
+	err := logger.ReadJSONLinesLog(bytes.NewReader(eventLog.Bytes()), func(le *logger.LogEntry) {
+		if attempt := le.GetLoginAttempt(); attempt != nil {
+			attempts = append(attempts, attempt)
+		}
+	})
+	require.Nil(t, err)
+	return attempts
+}
+
+func TestHoneypot_telnet(t *testing.T) {
+	honeypot, eventLog := newTelnetHoneypot(t, func(*config.Configuration) {})
+
+	out := runTelnetSession(honeypot,
+		"\xff\xfb\x18"+ // IAC WILL TTYPE
+			"\xff\xfa\x18\x00XTERM\xff\xf0"+ // IAC SB TTYPE IS XTERM IAC SE
+			"root\r\nwrong\r\n"+
+			"root\r\nhunter2\r\n"+
+			"echo $TERM\r\nexit\r\n")
+
+	assert.Contains(t, out, "Ubuntu 18.04.5 LTS\r\nlocalhost login: ")
+	assert.Contains(t, out, "Password: \r\n\r\nLogin incorrect\r\nlocalhost login: root\r\nPassword: \r\n")
+	assert.Contains(t, out, "echo $TERM\nxterm\n")
+
+	attempts := loginAttempts(t, eventLog)
+	require.Len(t, attempts, 2)
+
+	assert.Equal(t, logger.OperationResult_FAILURE, attempts[0].GetResult())
+	assert.Equal(t, "wrong", attempts[0].GetPassword())
+	assert.Equal(t, logger.OperationResult_SUCCESS, attempts[1].GetResult())
+	assert.Equal(t, "hunter2", attempts[1].GetPassword())
+	assert.Equal(t, authMethodPassword, attempts[1].GetAuthMethod())
+	assert.Equal(t, "XTERM", attempts[1].GetTelnetClient().GetTerminalType())
+	assert.Equal(t, []string{"WILL TTYPE", "SB TTYPE"}, attempts[1].GetTelnetClient().GetNegotiation())
+}
+
+func TestHoneypot_telnetRateLimited(t *testing.T) {
+	honeypot, eventLog := newTelnetHoneypot(t, func(cfg *config.Configuration) {
+		cfg.Limits.AuthAttemptsPerMinute = 1
+	})
+
+	out := runTelnetSession(honeypot, strings.Repeat("root\r\nwrong\r\n", telnetLoginRetries-1)+"root\r\nhunter2\r\n")
+	assert.NotContains(t, out, "root@localhost")
+
+	attempts := loginAttempts(t, eventLog)
+	require.Len(t, attempts, telnetLoginRetries)
+	assert.Equal(t, logger.OperationResult_FAILURE, attempts[0].GetResult())
+	for _, attempt := range attempts[1:] {
+		assert.Equal(t, logger.OperationResult_RATE_LIMITED, attempt.GetResult())
+	}
+	attempt := attempts[telnetLoginRetries-1]
+	assert.Equal(t, "root", attempt.GetUsername())
+	assert.Equal(t, "hunter2", attempt.GetPassword())
+	assert.Equal(t, authMethodPassword, attempt.GetAuthMethod())
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/logger"
+)
+
+// autoLogoutMessage is what bash prints when TMOUT expires.
+const autoLogoutMessage = "\r\ntimed out waiting for input: auto-logout\r\n"
+
+// activityReader records the last time input was received.
+type activityReader struct {
+	io.ReadCloser
+
+	lastRead atomic.Int64
+}
+
+func newActivityReader(r io.ReadCloser, now time.Time) *activityReader {
+	ar := &activityReader{ReadCloser: r}
+	ar.lastRead.Store(now.UnixNano())
+	return ar
+}
+
+// Read implements io.Reader.
+func (a *activityReader) Read(b []byte) (int, error) {
+	n, err := a.ReadCloser.Read(b)
+	if n > 0 {
+		a.lastRead.Store(time.Now().UnixNano())
+	}
+	return n, err
+}
+
+// LastRead returns the time input was last received.
+func (a *activityReader) LastRead() time.Time {
+	return time.Unix(0, a.lastRead.Load())
+}
+
+// waitForExit waits for the program to exit, for the session to hit one of
+// its limits or for shutdown to be closed. A limit of zero is unlimited.
+func waitForExit(exited <-chan int, activity *activityReader, idleTimeout, maxDuration time.Duration, shutdown <-chan struct{}) (logger.SessionEnded_Reason, int) {
+	var lifetime <-chan time.Time
+	if maxDuration > 0 {
+		timer := time.NewTimer(maxDuration)
+		defer timer.Stop()
+		lifetime = timer.C
+	}
+
+	var idle <-chan time.Time
+	var idleTimer *time.Timer
+	if idleTimeout > 0 {
+		idleTimer = time.NewTimer(idleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
+	for {
+		select {
+		case status := <-exited:
+			return logger.SessionEnded_EXIT, status
+
+		case <-lifetime:
+			return logger.SessionEnded_MAX_DURATION, 0
+
+		case <-shutdown:
+			return logger.SessionEnded_SHUTDOWN, 0
+
+		case <-idle:
+			idleFor := time.Since(activity.LastRead())
+			if idleFor >= idleTimeout {
+				return logger.SessionEnded_IDLE_TIMEOUT, 0
+			}
+			idleTimer.Reset(idleTimeout - idleFor)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForExit(t *testing.T) {
+	newActivity := func() *activityReader {
+		return newActivityReader(io.NopCloser(strings.NewReader("")), time.Now())
+	}
+
+	t.Run("exit", func(t *testing.T) {
+		exited := make(chan int, 1)
+		exited <- 3
+		reason, status := waitForExit(exited, newActivity(), time.Hour, time.Hour, nil)
+		assert.Equal(t, logger.SessionEnded_EXIT, reason)
+		assert.Equal(t, 3, status)
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		reason, _ := waitForExit(make(chan int), newActivity(), 10*time.Millisecond, time.Hour, nil)
+		assert.Equal(t, logger.SessionEnded_IDLE_TIMEOUT, reason)
+	})
+
+	t.Run("max duration", func(t *testing.T) {
+		activity := newActivity()
+		stop := make(chan bool)
+		defer close(stop)
+		go func() {
+			// Keep the session active.
+			for {
+				select {
+				case <-stop:
+					return
+				case <-time.After(time.Millisecond):
+					activity.lastRead.Store(time.Now().UnixNano())
+				}
+			}
+		}()
+
+		reason, _ := waitForExit(make(chan int), activity, 20*time.Millisecond, 50*time.Millisecond, nil)
+		assert.Equal(t, logger.SessionEnded_MAX_DURATION, reason)
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		exited := make(chan int)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			exited <- 0
+		}()
+		reason, _ := waitForExit(exited, newActivity(), 0, 0, nil)
+		assert.Equal(t, logger.SessionEnded_EXIT, reason)
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		shutdown := make(chan struct{})
+		close(shutdown)
+		reason, _ := waitForExit(make(chan int), newActivity(), time.Hour, time.Hour, shutdown)
+		assert.Equal(t, logger.SessionEnded_SHUTDOWN, reason)
+	})
+}
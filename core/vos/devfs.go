@@ -0,0 +1,318 @@
+package vos
+
+import (
+	"crypto/rand"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/third_party/memmapfs/mem"
+	"github.com/spf13/afero"
+)
+
+// Device is a character device in DevFS, it's returned by the Sys method of
+// the device's FileInfo.
+type Device struct {
+	Name  string
+	Major uint32
+	Minor uint32
+
+	// open returns an error if the device can't be opened.
+	open  func(t *TenantOS) error
+	read  func(t *TenantOS, p []byte) (int, error)
+	write func(t *TenantOS, p []byte) (int, error)
+}
+
+func readEOF(_ *TenantOS, _ []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func readZeros(_ *TenantOS, p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func readRandom(_ *TenantOS, p []byte) (int, error) {
+	return rand.Read(p)
+}
+
+func writeDiscard(_ *TenantOS, p []byte) (int, error) {
+	return len(p), nil
+}
+
+var devices = []*Device{
+	{Name: "null", Major: 1, Minor: 3, read: readEOF, write: writeDiscard},
+	{Name: "random", Major: 1, Minor: 8, read: readRandom, write: writeDiscard},
+	{Name: "tty", Major: 5, Minor: 0,
+		open: func(t *TenantOS) error {
+			// Sessions without a terminal have no controlling TTY.
+			if !t.GetPTY().IsPTY {
+				return syscall.ENXIO
+			}
+			return nil
+		},
+		// Input goes to the shell so there's nothing to read.
+		read: readEOF,
+		write: func(t *TenantOS, p []byte) (int, error) {
+			return t.SSHStdout().Write(p)
+		},
+	},
+	{Name: "urandom", Major: 1, Minor: 9, read: readRandom, write: writeDiscard},
+	{Name: "zero", Major: 1, Minor: 5, read: readZeros, write: writeDiscard},
+}
+
+// devDirs are empty directories in /dev, their contents are kept in the
+// tenant's overlay.
+var devDirs = []struct {
+	Name string
+	Mode fs.FileMode
+}{
+	{Name: "pts", Mode: fs.ModeDir | 0755},
+	{Name: "shm", Mode: fs.ModeDir | fs.ModeSticky | 0777},
+}
+
+// NewDevFS creates a /dev filesystem with the standard character devices.
+func NewDevFS(tenantOS *TenantOS) *DevFS {
+	return &DevFS{tenantOS: tenantOS}
+}
+
+type DevFS struct {
+	tenantOS *TenantOS
+	VirtualFS
+}
+
+var _ VFS = (*DevFS)(nil)
+
+func (dfs *DevFS) resolve(name string) (afero.File, error) {
+	t := dfs.tenantOS
+	name = path.Clean(name)
+
+	if name == "/" {
+		var entries []os.FileInfo
+		for _, device := range devices {
+			entries = append(entries, t.deviceInfo(device))
+		}
+		for _, dir := range devDirs {
+			entries = append(entries, t.devDirInfo(dir.Name, dir.Mode))
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name() < entries[j].Name()
+		})
+		return t.newDevDir(name, fs.ModeDir|0755, entries), nil
+	}
+
+	for _, dir := range devDirs {
+		if name == "/"+dir.Name {
+			return t.newDevDir(name, dir.Mode, nil), nil
+		}
+	}
+
+	for _, device := range devices {
+		if name != "/"+device.Name {
+			continue
+		}
+		if device.open != nil {
+			if err := device.open(t); err != nil {
+				return nil, &os.PathError{Op: "open", Path: path.Join("/dev", name), Err: err}
+			}
+		}
+		return &devFile{
+			File:     mem.NewReadOnlyFileHandle(mem.CreateFile(name, t.Now)),
+			device:   device,
+			tenantOS: t,
+		}, nil
+	}
+
+	return nil, &os.PathError{Op: "open", Path: path.Join("/dev", name), Err: fs.ErrNotExist}
+}
+
+func (dfs *DevFS) OpenFile(name string, flag int, perm fs.FileMode) (afero.File, error) {
+	return dfs.resolve(name)
+}
+
+func (dfs *DevFS) Open(name string) (afero.File, error) {
+	return dfs.resolve(name)
+}
+
+func (*DevFS) Name() string {
+	return "/dev"
+}
+
+func (dfs *DevFS) Stat(name string) (fs.FileInfo, error) {
+	fd, err := dfs.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return fd.Stat()
+}
+
+func (t *TenantOS) deviceInfo(device *Device) os.FileInfo {
+	return &devFileInfo{
+		name:    device.Name,
+		mode:    fs.ModeDevice | fs.ModeCharDevice | 0666,
+		modTime: t.BootTime(),
+		device:  device,
+	}
+}
+
+func (t *TenantOS) devDirInfo(name string, mode fs.FileMode) os.FileInfo {
+	return &devFileInfo{
+		name:    path.Base(name),
+		mode:    mode,
+		modTime: t.BootTime(),
+	}
+}
+
+// devFileInfo implements os.FileInfo for DevFS.
+type devFileInfo struct {
+	name    string
+	mode    fs.FileMode
+	modTime time.Time
+	device  *Device
+}
+
+var _ os.FileInfo = (*devFileInfo)(nil)
+
+func (d *devFileInfo) Name() string       { return d.name }
+func (d *devFileInfo) Size() int64        { return 0 }
+func (d *devFileInfo) Mode() fs.FileMode  { return d.mode }
+func (d *devFileInfo) ModTime() time.Time { return d.modTime }
+func (d *devFileInfo) IsDir() bool        { return d.mode.IsDir() }
+
+// Sys returns the *Device for device files.
+func (d *devFileInfo) Sys() interface{} {
+	if d.device == nil {
+		return nil
+	}
+	return d.device
+}
+
+// devFile is an open device, the embedded handle only provides methods
+// devices don't override.
+type devFile struct {
+	*mem.File
+	device   *Device
+	tenantOS *TenantOS
+}
+
+var _ afero.File = (*devFile)(nil)
+
+func (d *devFile) Name() string {
+	return d.device.Name
+}
+
+func (d *devFile) Close() error {
+	return nil
+}
+
+func (d *devFile) Read(p []byte) (int, error) {
+	return d.device.read(d.tenantOS, p)
+}
+
+func (d *devFile) ReadAt(p []byte, _ int64) (int, error) {
+	return d.Read(p)
+}
+
+func (d *devFile) Write(p []byte) (int, error) {
+	return d.device.write(d.tenantOS, p)
+}
+
+func (d *devFile) WriteAt(p []byte, _ int64) (int, error) {
+	return d.Write(p)
+}
+
+func (d *devFile) WriteString(s string) (int, error) {
+	return d.Write([]byte(s))
+}
+
+// Seek succeeds without moving because devices have no position.
+func (d *devFile) Seek(_ int64, _ int) (int64, error) {
+	return 0, nil
+}
+
+func (d *devFile) Stat() (os.FileInfo, error) {
+	return d.tenantOS.deviceInfo(d.device), nil
+}
+
+func (d *devFile) Sync() error {
+	return nil
+}
+
+// Truncate succeeds so devices can be opened with O_TRUNC.
+func (d *devFile) Truncate(_ int64) error {
+	return nil
+}
+
+func (d *devFile) Readdir(_ int) ([]os.FileInfo, error) {
+	return nil, &os.PathError{Op: "readdir", Path: d.device.Name, Err: syscall.ENOTDIR}
+}
+
+func (d *devFile) Readdirnames(_ int) ([]string, error) {
+	return nil, &os.PathError{Op: "readdir", Path: d.device.Name, Err: syscall.ENOTDIR}
+}
+
+func (t *TenantOS) newDevDir(name string, mode fs.FileMode, entries []os.FileInfo) *devDir {
+	return &devDir{
+		File:    mem.NewReadOnlyFileHandle(mem.CreateDir(name, t.Now)),
+		info:    t.devDirInfo(name, mode),
+		entries: entries,
+	}
+}
+
+// devDir is an open directory, the embedded handle only provides methods
+// directories don't override.
+type devDir struct {
+	*mem.File
+	info    os.FileInfo
+	entries []os.FileInfo
+	read    int
+}
+
+var _ afero.File = (*devDir)(nil)
+
+func (d *devDir) Name() string {
+	return d.info.Name()
+}
+
+func (d *devDir) Close() error {
+	return nil
+}
+
+func (d *devDir) Stat() (os.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *devDir) Read(_ []byte) (int, error) {
+	return 0, &os.PathError{Op: "read", Path: d.info.Name(), Err: syscall.EISDIR}
+}
+
+func (d *devDir) Readdir(count int) ([]os.FileInfo, error) {
+	remaining := d.entries[d.read:]
+	if count <= 0 {
+		d.read = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(remaining) {
+		count = len(remaining)
+	}
+	d.read += count
+	return remaining[:count], nil
+}
+
+func (d *devDir) Readdirnames(count int) ([]string, error) {
+	entries, err := d.Readdir(count)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, err
+}
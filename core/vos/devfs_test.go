@@ -0,0 +1,67 @@
+package vos
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevFS(t *testing.T) {
+	tenantOS, proc := newTestTenantOS(t, &config.Configuration{}, &fakeEventRecorder{})
+
+	fd, err := proc.Create("/dev/null")
+	require.Nil(t, err)
+	n, err := fd.Write([]byte("discarded"))
+	assert.Equal(t, 9, n)
+	assert.Nil(t, err)
+	require.Nil(t, fd.Close())
+	contents, err := afero.ReadFile(proc, "/dev/null")
+	require.Nil(t, err)
+	assert.Empty(t, contents)
+
+	fd, err = proc.Open("/dev/zero")
+	require.Nil(t, err)
+	zeros := make([]byte, 4)
+	_, err = io.ReadFull(fd, zeros)
+	require.Nil(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0}, zeros)
+
+	fd, err = proc.Open("/dev/urandom")
+	require.Nil(t, err)
+	random := make([]byte, 16)
+	_, err = io.ReadFull(fd, random)
+	require.Nil(t, err)
+	assert.NotEqual(t, make([]byte, 16), random)
+
+	_, err = proc.Open("/dev/tty")
+	assert.ErrorIs(t, err, syscall.ENXIO, "no terminal")
+	tenantOS.SetPTY(PTY{IsPTY: true})
+	_, err = proc.Open("/dev/tty")
+	assert.Nil(t, err)
+
+	info, err := proc.Stat("/dev/null")
+	require.Nil(t, err)
+	assert.Equal(t, os.ModeDevice|os.ModeCharDevice|0666, info.Mode())
+	device := info.Sys().(*Device)
+	assert.Equal(t, uint32(1), device.Major)
+	assert.Equal(t, uint32(3), device.Minor)
+
+	names, err := afero.ReadDir(proc, "/dev")
+	require.Nil(t, err)
+	var got []string
+	for _, info := range names {
+		got = append(got, info.Name())
+	}
+	assert.Equal(t, []string{"null", "pts", "random", "shm", "tty", "urandom", "zero"}, got)
+
+	require.Nil(t, afero.WriteFile(proc, "/dev/shm/payload", []byte("stage2"), 0755))
+	changes, err := tenantOS.FilesystemChanges()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"/dev/shm/payload"}, changes.Created, "devices aren't copied into the overlay")
+}
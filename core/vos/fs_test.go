@@ -239,6 +239,27 @@ func RunFsTest(t *testing.T, suite FSTestSuite) {
 
 		// TODO: create under a link
 	})
+
+	t.Run("MkdirAll", func(t *testing.T) {
+		mkdirAllCallback := func(fs VFS, name string) error {
+			return fs.MkdirAll(name, 0700)
+		}
+
+		t.Run("nominal", func(t *testing.T) {
+			FSTestCase(t, suite, "/path/to/dir").
+				AssertAfter(mkdirAllCallback).
+				NoError().
+				TestPathIsDir()
+		})
+		t.Run("exists", func(t *testing.T) {
+			FSTestCase(t, suite, "/path/to/dir").
+				MkdirAllParentsTestPath(0700).
+				MkdirTestPath(0700).
+				AssertAfter(mkdirAllCallback).
+				NoError().
+				TestPathIsDir()
+		})
+	})
 }
 
 func TestLinkingFs(t *testing.T) {
@@ -278,6 +299,21 @@ func TestMountFS(t *testing.T) {
 	RunFsTest(t, suite)
 }
 
+func TestSymlinkResolvingRelativeFs(t *testing.T) {
+	suite := FSTestSuite{
+		MakeFS: func(t *testing.T) (VFS, VFS) {
+			mfs := NewLinkingFs(memmapfs.NewMemMapFs(time.Now))
+			if err := mfs.Mkdir("/home", 0777); err != nil {
+				t.Fatal(err)
+			}
+			fs := NewSymlinkResolvingRelativeFs(mfs, func() string { return "/home" })
+			return fs, mfs
+		},
+	}
+
+	RunFsTest(t, suite)
+}
+
 func TestOSFs(t *testing.T) {
 	suite := FSTestSuite{
 		MakeFS: func(t *testing.T) (VFS, VFS) {
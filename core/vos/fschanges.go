@@ -0,0 +1,500 @@
+package vos
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	// FilesystemChangesManifest is the name of the manifest in a filesystem
+	// changes archive.
+	FilesystemChangesManifest = "manifest.json"
+	// FilesystemChangesDir is the directory created and modified files are
+	// stored under in a filesystem changes archive.
+	FilesystemChangesDir = "files"
+)
+
+// overlayFs is the copy-on-write layer of a tenant's filesystem, it remembers
+// removed files because the layer has no other record of them. The layer is
+// held in memory so the size of its files is capped.
+type overlayFs struct {
+	*LinkingFsWrapper
+
+	mu      sync.Mutex
+	removed map[string]bool
+	// used is the size of the regular files in the layer, it can't grow past
+	// limit.
+	used  int64
+	limit int64
+}
+
+func newOverlayFs(base VFS, limit int64) *overlayFs {
+	return &overlayFs{
+		LinkingFsWrapper: &LinkingFsWrapper{base},
+		removed:          make(map[string]bool),
+		limit:            limit,
+	}
+}
+
+func (o *overlayFs) markRemoved(name string, err error) {
+	if err != nil {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.removed[path.Clean(name)] = true
+}
+
+// grow reserves space for the layer's files to grow by delta bytes, the disk
+// is full if that would go past the limit. Shrinking always succeeds.
+func (o *overlayFs) grow(delta int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if delta > 0 && o.used+delta > o.limit {
+		return syscall.ENOSPC
+	}
+	o.used += delta
+	if o.used < 0 {
+		o.used = 0
+	}
+	return nil
+}
+
+// sizeOf returns the total size of the regular files at or under name.
+func (o *overlayFs) sizeOf(name string) int64 {
+	var size int64
+	afero.Walk(o.LinkingFsWrapper, name, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func (o *overlayFs) Create(name string) (afero.File, error) {
+	size := o.sizeOf(name)
+	fd, err := o.LinkingFsWrapper.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	o.grow(-size)
+	return &overlayFile{File: fd, fs: o}, nil
+}
+
+func (o *overlayFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	var size int64
+	if flag&os.O_TRUNC != 0 {
+		size = o.sizeOf(name)
+	}
+	fd, err := o.LinkingFsWrapper.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	o.grow(-size)
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return fd, nil
+	}
+	return &overlayFile{File: fd, fs: o}, nil
+}
+
+func (o *overlayFs) Remove(name string) error {
+	size := o.sizeOf(name)
+	err := o.LinkingFsWrapper.Remove(name)
+	if err == nil {
+		o.grow(-size)
+	}
+	o.markRemoved(name, err)
+	return err
+}
+
+func (o *overlayFs) RemoveAll(name string) error {
+	size := o.sizeOf(name)
+	err := o.LinkingFsWrapper.RemoveAll(name)
+	if err == nil {
+		o.grow(-size)
+	}
+	o.markRemoved(name, err)
+	return err
+}
+
+func (o *overlayFs) Rename(oldname, newname string) error {
+	var size int64
+	if path.Clean(oldname) != path.Clean(newname) {
+		size = o.sizeOf(newname)
+	}
+	err := o.LinkingFsWrapper.Rename(oldname, newname)
+	if err == nil {
+		o.grow(-size)
+	}
+	o.markRemoved(oldname, err)
+	return err
+}
+
+// overlayFile reserves space in the overlay before it's written to.
+type overlayFile struct {
+	afero.File
+	fs *overlayFs
+}
+
+// reserve reserves the space needed to write n bytes at off.
+func (f *overlayFile) reserve(off int64, n int) error {
+	info, err := f.File.Stat()
+	if err != nil {
+		return err
+	}
+	if end := off + int64(n); end > info.Size() {
+		return f.fs.grow(end - info.Size())
+	}
+	return nil
+}
+
+func (f *overlayFile) Write(b []byte) (int, error) {
+	off, err := f.File.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if err := f.reserve(off, len(b)); err != nil {
+		return 0, &os.PathError{Op: "write", Path: f.Name(), Err: err}
+	}
+	return f.File.Write(b)
+}
+
+func (f *overlayFile) WriteAt(b []byte, off int64) (int, error) {
+	if err := f.reserve(off, len(b)); err != nil {
+		return 0, &os.PathError{Op: "write", Path: f.Name(), Err: err}
+	}
+	return f.File.WriteAt(b, off)
+}
+
+func (f *overlayFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+func (f *overlayFile) Truncate(size int64) error {
+	info, err := f.File.Stat()
+	if err != nil {
+		return err
+	}
+	if err := f.fs.grow(size - info.Size()); err != nil {
+		return &os.PathError{Op: "truncate", Path: f.Name(), Err: err}
+	}
+	return f.File.Truncate(size)
+}
+
+// removedPaths returns the paths that have been removed in sorted order.
+func (o *overlayFs) removedPaths() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var out []string
+	for name := range o.removed {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ModeChange is a file with changed permissions.
+type ModeChange struct {
+	Path string `json:"path"`
+	// Modes are octal e.g. 0755.
+	OldMode string `json:"old_mode"`
+	NewMode string `json:"new_mode"`
+}
+
+// FilesystemChanges describes how a tenant changed the filesystem.
+type FilesystemChanges struct {
+	// Created contains files, directories and links that aren't in the base.
+	Created []string `json:"created"`
+	// Modified contains files and links with different contents than the base.
+	Modified []string `json:"modified"`
+	// Deleted contains removed or renamed paths that no longer exist. Files
+	// in the base can't be removed so these were created during the session.
+	Deleted []string `json:"deleted"`
+	// ModeChanges contains files in the base with different permissions,
+	// directories aren't included.
+	ModeChanges []ModeChange `json:"mode_changes"`
+}
+
+// Empty returns true if there are no changes.
+func (f *FilesystemChanges) Empty() bool {
+	return len(f.Created) == 0 &&
+		len(f.Modified) == 0 &&
+		len(f.Deleted) == 0 &&
+		len(f.ModeChanges) == 0
+}
+
+// FilesystemChanges compares the tenant's overlay to the base filesystem.
+func (t *TenantOS) FilesystemChanges() (*FilesystemChanges, error) {
+	// Lists are never nil so they're written as empty lists in the manifest.
+	changes := &FilesystemChanges{
+		Created:     []string{},
+		Modified:    []string{},
+		Deleted:     []string{},
+		ModeChanges: []ModeChange{},
+	}
+	err := afero.Walk(t.overlay, "/", func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "/" {
+			return nil
+		}
+		if restored, ok := t.restored[name]; ok && restored.matches(info) {
+			return nil
+		}
+
+		baseInfo, _, err := lstatIfPossible(t.base, name)
+		if err != nil {
+			changes.Created = append(changes.Created, name)
+			return nil
+		}
+
+		// Directories are skipped because the layer creates the parents of
+		// copied files with default permissions.
+		if info.IsDir() {
+			return nil
+		}
+
+		if baseInfo.Mode().Perm() != info.Mode().Perm() {
+			changes.ModeChanges = append(changes.ModeChanges, ModeChange{
+				Path:    name,
+				OldMode: fmt.Sprintf("%04o", unixPermissions(baseInfo.Mode())),
+				NewMode: fmt.Sprintf("%04o", unixPermissions(info.Mode())),
+			})
+		}
+
+		same, err := sameContents(t.base, t.overlay, name, baseInfo, info)
+		if err != nil {
+			return err
+		}
+		if !same {
+			changes.Modified = append(changes.Modified, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range t.overlay.removedPaths() {
+		if _, _, err := lstatIfPossible(t.fs, name); os.IsNotExist(err) {
+			changes.Deleted = append(changes.Deleted, name)
+		}
+	}
+
+	return changes, nil
+}
+
+// WriteFilesystemChanges writes a tar.gz containing the manifest and the
+// created and modified files.
+func (t *TenantOS) WriteFilesystemChanges(w io.Writer, changes *FilesystemChanges) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	manifest, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    FilesystemChangesManifest,
+		Mode:    0644,
+		Size:    int64(len(manifest)),
+		ModTime: t.timeSource(),
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+
+	names := append(append([]string{}, changes.Created...), changes.Modified...)
+	sort.Strings(names)
+	for _, name := range names {
+		if err := t.writeOverlayFile(tw, name, path.Join(FilesystemChangesDir, name)); err != nil {
+			return fmt.Errorf("archiving %q: %v", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// writeOverlayFile adds the file at name in the overlay to the archive as
+// archiveName.
+func (t *TenantOS) writeOverlayFile(tw *tar.Writer, name, archiveName string) error {
+	info, _, err := t.overlay.LstatIfPossible(name)
+	if err != nil {
+		return err
+	}
+
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = t.overlay.ReadlinkIfPossible(name); err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = archiveName
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	fd, err := t.overlay.Open(name)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	_, err = io.CopyN(tw, fd, hdr.Size)
+	return err
+}
+
+// restoredFile is a file restored into the overlay by RestoreOverlay.
+type restoredFile struct {
+	mode    os.FileMode
+	size    int64
+	modTime time.Time
+}
+
+// matches returns true if the file is unchanged since it was restored.
+func (r restoredFile) matches(info os.FileInfo) bool {
+	return r.mode == info.Mode() &&
+		r.size == info.Size() &&
+		r.modTime.Equal(info.ModTime())
+}
+
+// WriteOverlay writes a tar.gz of every file in the overlay that can be
+// restored in another session with RestoreOverlay.
+func (t *TenantOS) WriteOverlay(w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	err := afero.Walk(t.overlay, "/", func(name string, info os.FileInfo, err error) error {
+		if err != nil || name == "/" {
+			return err
+		}
+		return t.writeOverlayFile(tw, name, strings.TrimPrefix(name, "/"))
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// OverlaySize returns the total size of the files in the overlay.
+func (t *TenantOS) OverlaySize() (int64, error) {
+	var size int64
+	err := afero.Walk(t.overlay, "/", func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// RestoreOverlay extracts a tar.gz written by WriteOverlay into the overlay.
+// Restored files aren't included in FilesystemChanges unless they're changed
+// again.
+func (t *TenantOS) RestoreOverlay(r io.Reader) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	if err := ExtractTarToVFS(t.overlay, tar.NewReader(gr)); err != nil {
+		return err
+	}
+
+	t.restored = make(map[string]restoredFile)
+	return afero.Walk(t.overlay, "/", func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		t.restored[name] = restoredFile{
+			mode:    info.Mode(),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		}
+		return nil
+	})
+}
+
+func lstatIfPossible(fs VFS, name string) (os.FileInfo, bool, error) {
+	if lstater, ok := fs.(afero.Lstater); ok {
+		return lstater.LstatIfPossible(name)
+	}
+	fi, err := fs.Stat(name)
+	return fi, false, err
+}
+
+// sameContents returns true if both filesystems have the same file or link
+// at name.
+func sameContents(a, b VFS, name string, aInfo, bInfo os.FileInfo) (bool, error) {
+	if aInfo.Mode().Type() != bInfo.Mode().Type() {
+		return false, nil
+	}
+
+	if aInfo.Mode()&os.ModeSymlink != 0 {
+		aLink, aErr := readlinkIfPossible(a, name)
+		bLink, bErr := readlinkIfPossible(b, name)
+		return aErr == nil && bErr == nil && aLink == bLink, nil
+	}
+
+	if aInfo.Size() != bInfo.Size() {
+		return false, nil
+	}
+	aContents, err := afero.ReadFile(a, name)
+	if err != nil {
+		// Unreadable base files e.g. in /proc are treated as changed.
+		return false, nil
+	}
+	bContents, err := afero.ReadFile(b, name)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aContents, bContents), nil
+}
+
+func readlinkIfPossible(fs VFS, name string) (string, error) {
+	if reader, ok := fs.(afero.LinkReader); ok {
+		return reader.ReadlinkIfPossible(name)
+	}
+	return "", afero.ErrNoReadlink
+}
@@ -0,0 +1,145 @@
+package vos
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantOS_FilesystemChanges(t *testing.T) {
+	tenantOS, proc := newTestTenantOS(t, &config.Configuration{}, &fakeEventRecorder{})
+
+	changes, err := tenantOS.FilesystemChanges()
+	require.Nil(t, err)
+	assert.True(t, changes.Empty())
+
+	require.Nil(t, afero.WriteFile(proc, "/etc/motd", []byte("pwned"), 0644))
+	require.Nil(t, proc.Chmod("/etc/motd", 0600))
+	require.Nil(t, proc.MkdirAll("/root/.ssh", 0700))
+	require.Nil(t, afero.WriteFile(proc, "/root/.ssh/authorized_keys", []byte("ssh-rsa AAAA"), 0600))
+	require.Nil(t, afero.WriteFile(proc, "/root/dropper.sh", []byte("#!/bin/sh"), 0755))
+	require.Nil(t, proc.Remove("/root/dropper.sh"))
+	require.Nil(t, afero.WriteFile(proc, "/root/miner", []byte("\x7fELF"), 0755))
+	require.Nil(t, proc.Rename("/root/miner", "/root/.miner"))
+
+	changes, err = tenantOS.FilesystemChanges()
+	require.Nil(t, err)
+	assert.Equal(t, &FilesystemChanges{
+		Created: []string{
+			"/root/.miner",
+			"/root/.ssh",
+			"/root/.ssh/authorized_keys",
+		},
+		Modified: []string{"/etc/motd"},
+		Deleted:  []string{"/root/dropper.sh", "/root/miner"},
+		ModeChanges: []ModeChange{
+			{Path: "/etc/motd", OldMode: "0644", NewMode: "0600"},
+		},
+	}, changes)
+
+	buf := &bytes.Buffer{}
+	require.Nil(t, tenantOS.WriteFilesystemChanges(buf, changes))
+
+	gr, err := gzip.NewReader(buf)
+	require.Nil(t, err)
+	tr := tar.NewReader(gr)
+	contents := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err)
+
+		data, err := io.ReadAll(tr)
+		require.Nil(t, err)
+		if hdr.Typeflag == tar.TypeDir {
+			contents[hdr.Name] = os.FileMode(hdr.Mode).String()
+		} else {
+			contents[hdr.Name] = string(data)
+		}
+	}
+
+	manifest := contents[FilesystemChangesManifest]
+	delete(contents, FilesystemChangesManifest)
+	gotChanges := &FilesystemChanges{}
+	require.Nil(t, json.Unmarshal([]byte(manifest), gotChanges))
+	assert.Equal(t, changes, gotChanges)
+
+	assert.Equal(t, map[string]string{
+		"files/etc/motd":                  "pwned",
+		"files/root/.miner":               "\x7fELF",
+		"files/root/.ssh/":                "-rwx------",
+		"files/root/.ssh/authorized_keys": "ssh-rsa AAAA",
+	}, contents)
+}
+
+func TestTenantOS_RestoreOverlay(t *testing.T) {
+	tenantOS, proc := newTestTenantOS(t, &config.Configuration{}, &fakeEventRecorder{})
+	require.Nil(t, proc.MkdirAll("/tmp/.x", 0700))
+	require.Nil(t, afero.WriteFile(proc, "/tmp/.x/payload", []byte("stage2"), 0755))
+	require.Nil(t, afero.WriteFile(proc, "/tmp/.x/config", []byte("pool"), 0644))
+
+	size, err := tenantOS.OverlaySize()
+	require.Nil(t, err)
+	assert.Equal(t, int64(10), size)
+
+	saved := &bytes.Buffer{}
+	require.Nil(t, tenantOS.WriteOverlay(saved))
+
+	restoredOS, restoredProc := newTestTenantOS(t, &config.Configuration{}, &fakeEventRecorder{})
+	require.Nil(t, restoredOS.RestoreOverlay(saved))
+
+	contents, err := afero.ReadFile(restoredProc, "/tmp/.x/payload")
+	require.Nil(t, err)
+	assert.Equal(t, "stage2", string(contents))
+	info, err := restoredProc.Stat("/tmp/.x/payload")
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode())
+
+	changes, err := restoredOS.FilesystemChanges()
+	require.Nil(t, err)
+	assert.True(t, changes.Empty(), "restored files aren't changes")
+
+	require.Nil(t, afero.WriteFile(restoredProc, "/tmp/.x/config", []byte("pool2"), 0644))
+	require.Nil(t, restoredProc.Remove("/tmp/.x/payload"))
+	changes, err = restoredOS.FilesystemChanges()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"/tmp/.x/config"}, changes.Created)
+	assert.Equal(t, []string{"/tmp/.x/payload"}, changes.Deleted)
+}
+
+func TestTenantOS_overlayLimit(t *testing.T) {
+	cfg := &config.Configuration{}
+	cfg.Limits.MaxFilesystemBytes = 16
+	_, proc := newTestTenantOS(t, cfg, &fakeEventRecorder{})
+
+	require.Nil(t, afero.WriteFile(proc, "/root/a", []byte("0123456789"), 0644))
+	err := afero.WriteFile(proc, "/root/b", []byte("0123456789"), 0644)
+	assert.ErrorIs(t, err, syscall.ENOSPC)
+
+	// Devices never run out, writes fail once the disk is full.
+	zero, err := proc.Open("/dev/zero")
+	require.Nil(t, err)
+	defer zero.Close()
+	fd, err := proc.Create("/root/zeros")
+	require.Nil(t, err)
+	_, err = io.Copy(fd, zero)
+	assert.ErrorIs(t, err, syscall.ENOSPC)
+	require.Nil(t, fd.Close())
+
+	// Truncating and removing files frees space.
+	require.Nil(t, afero.WriteFile(proc, "/root/a", []byte("0123"), 0644))
+	require.Nil(t, proc.Remove("/root/zeros"))
+	require.Nil(t, afero.WriteFile(proc, "/root/b", []byte("0123456789"), 0644))
+}
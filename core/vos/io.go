@@ -1,8 +1,11 @@
 package vos
 
 import (
+	"errors"
 	"io"
 	"os"
+
+	"github.com/spf13/afero"
 )
 
 type VIOAdapter struct {
@@ -86,6 +89,121 @@ func (*devNull) Write(b []byte) (int, error) {
 	return len(b), nil
 }
 
+// errKilled is returned to processes that write after they're killed.
+var errKilled = errors.New("process killed")
+
+// newKillableIO wraps the I/O of a process so reads end and writes fail once
+// the process is killed.
+func newKillableIO(vio VIO, killed <-chan struct{}) VIO {
+	return &VIOAdapter{
+		IStdin:  &killableReader{ReadCloser: vio.Stdin(), killed: killed},
+		IStdout: &killableWriter{WriteCloser: vio.Stdout(), killed: killed},
+		IStderr: &killableWriter{WriteCloser: vio.Stderr(), killed: killed},
+	}
+}
+
+// isClosed returns whether the channel is closed.
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+type killableReader struct {
+	io.ReadCloser
+	killed <-chan struct{}
+}
+
+func (k *killableReader) Read(b []byte) (int, error) {
+	if isClosed(k.killed) {
+		return 0, io.EOF
+	}
+	return k.ReadCloser.Read(b)
+}
+
+type killableWriter struct {
+	io.WriteCloser
+	killed <-chan struct{}
+}
+
+func (k *killableWriter) Write(b []byte) (int, error) {
+	if isClosed(k.killed) {
+		return 0, errKilled
+	}
+	return k.WriteCloser.Write(b)
+}
+
+// killableFile is a file opened by a process, it stops working once the
+// process is killed.
+type killableFile struct {
+	afero.File
+	killed <-chan struct{}
+}
+
+// check returns an error for the operation if the process was killed.
+func (k *killableFile) check(op string) error {
+	if isClosed(k.killed) {
+		return &os.PathError{Op: op, Path: k.Name(), Err: errKilled}
+	}
+	return nil
+}
+
+func (k *killableFile) Read(b []byte) (int, error) {
+	if err := k.check("read"); err != nil {
+		return 0, err
+	}
+	return k.File.Read(b)
+}
+
+func (k *killableFile) ReadAt(b []byte, off int64) (int, error) {
+	if err := k.check("read"); err != nil {
+		return 0, err
+	}
+	return k.File.ReadAt(b, off)
+}
+
+func (k *killableFile) Write(b []byte) (int, error) {
+	if err := k.check("write"); err != nil {
+		return 0, err
+	}
+	return k.File.Write(b)
+}
+
+func (k *killableFile) WriteAt(b []byte, off int64) (int, error) {
+	if err := k.check("write"); err != nil {
+		return 0, err
+	}
+	return k.File.WriteAt(b, off)
+}
+
+func (k *killableFile) WriteString(s string) (int, error) {
+	return k.Write([]byte(s))
+}
+
+func (k *killableFile) Readdir(count int) ([]os.FileInfo, error) {
+	if err := k.check("readdir"); err != nil {
+		return nil, err
+	}
+	return k.File.Readdir(count)
+}
+
+func (k *killableFile) Readdirnames(n int) ([]string, error) {
+	if err := k.check("readdir"); err != nil {
+		return nil, err
+	}
+	return k.File.Readdirnames(n)
+}
+
+func (k *killableFile) Truncate(size int64) error {
+	if err := k.check("truncate"); err != nil {
+		return err
+	}
+	return k.File.Truncate(size)
+}
+
 // Counter counts the number of matching and total bytes through a reader.
 type Counter struct {
 	test    func(byte) bool
@@ -1,9 +1,9 @@
 package vos
 
 import (
+	"errors"
 	"io/fs"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -91,7 +91,8 @@ func (b *PathMappingFs) Rename(oldname, newname string) (err error) {
 	if oldname, err = b.Mapper(FsOpRename, oldname); err != nil {
 		return &os.PathError{Op: FsOpRename, Path: oldname, Err: err}
 	}
-	if newname, err = b.Mapper(FsOpRename, newname); err != nil {
+	// The destination may not exist yet, so resolve it like a new file.
+	if newname, err = b.Mapper(FsOpCreate, newname); err != nil {
 		return &os.PathError{Op: FsOpRename, Path: newname, Err: err}
 	}
 	return b.BaseFs.Rename(oldname, newname)
@@ -112,8 +113,12 @@ func (b *PathMappingFs) Remove(name string) (err error) {
 }
 
 func (b *PathMappingFs) OpenFile(name string, flag int, mode os.FileMode) (f afero.File, err error) {
-	if name, err = b.Mapper(FsOpOpen, name); err != nil {
-		return nil, &os.PathError{Op: FsOpOpen, Path: name, Err: err}
+	op := FsOpOpen
+	if flag&os.O_CREATE != 0 {
+		op = FsOpCreate
+	}
+	if name, err = b.Mapper(op, name); err != nil {
+		return nil, &os.PathError{Op: op, Path: name, Err: err}
 	}
 	sourcef, err := b.BaseFs.OpenFile(name, flag, mode)
 	if err != nil {
@@ -141,12 +146,16 @@ func (b *PathMappingFs) Mkdir(name string, mode os.FileMode) (err error) {
 }
 
 func (b *PathMappingFs) MkdirAll(name string, mode os.FileMode) (err error) {
-	var soFar []string
-	for _, part := range strings.Split(name, "/") {
-		soFar = append(soFar, part)
+	parts := strings.Split(name, "/")
+	for i := range parts {
+		// Join rather than path.Join to keep the leading slash of absolute paths.
+		soFar := strings.Join(parts[:i+1], "/")
+		if soFar == "" {
+			continue
+		}
 
-		err := b.Mkdir(path.Join(soFar...), mode)
-		if err == nil || err == fs.ErrExist {
+		err := b.Mkdir(soFar, mode)
+		if err == nil || errors.Is(err, fs.ErrExist) {
 			continue
 		} else {
 			return err
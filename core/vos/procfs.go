@@ -3,88 +3,305 @@ package vos
 import (
 	"fmt"
 	"io/fs"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/josephlewis42/honeyssh/third_party/memmapfs/mem"
 	"github.com/spf13/afero"
 )
 
+// procSelf is the directory of the process reading /proc, see
+// newProcSelfFs.
+const procSelf = "self"
+
 type procFile struct {
 	Name      string
-	Generator func(vos *SharedOS) string
+	Generator func(t *TenantOS) string
 }
 
 var procFiles = []procFile{
-	{Name: "/cpuinfo", Generator: func(vos *SharedOS) string {
-		// Copied from gVisor:
+	{Name: "/cpuinfo", Generator: func(t *TenantOS) string {
+		// Adapted from gVisor:
 		// https://github.com/google/gvisor/blob/master/pkg/sentry/fs/proc/README.md
-		return `processor   : 0
+		hardware := t.Hardware()
+		out := &strings.Builder{}
+		for cpu := 0; cpu < hardware.CPUs; cpu++ {
+			fmt.Fprintf(out, `processor   : %d
 vendor_id   : GenuineIntel
 cpu family  : 6
 model       : 45
 model name  : unknown
 stepping    : unknown
 cpu MHz     : 1234.588
+physical id : 0
+siblings    : %d
+core id     : %d
+cpu cores   : %d
+apicid      : %d
 fpu     : yes
 fpu_exception   : yes
 cpuid level : 13
 wp      : yes
-flags       : fpu vme de pse tsc msr pae mce cx8 apic sep mtrr pge mca cmov pat pse36 clflush dts acpi mmx fxsr sse sse2 ss ht tm pbe syscall nx pdpe1gb rdtscp lm pni pclmulqdq dtes64 monitor ds_cpl vmx smx est tm2 ssse3 cx16 xtpr pdcm pcid dca sse4_1 sse4_2 x2apic popcnt tsc_deadline_timer aes xsave avx xsaveopt
+flags       : %s
 bogomips    : 1234.59
 clflush size    : 64
 cache_alignment : 64
 address sizes   : 46 bits physical, 48 bits virtual
+
+`, cpu, hardware.CPUs, cpu, hardware.CPUs, cpu, strings.Join(hardware.CPUFlags, " "))
+		}
+		return out.String()
+	}},
+	{Name: "/loadavg", Generator: func(t *TenantOS) string {
+		load := t.LoadAverage()
+		processes := t.Processes()
+		lastPID := 0
+		if len(processes) > 0 {
+			lastPID = processes[len(processes)-1].PID
+		}
+		// [1, 5 and 15 minute load] [runnable]/[total tasks] [last PID]
+		return fmt.Sprintf("%0.2f %0.2f %0.2f 1/%d %d\n", load[0], load[1], load[2], len(processes), lastPID)
+	}},
+	{Name: "/meminfo", Generator: func(t *TenantOS) string {
+		mem := t.MemInfo()
+		out := &strings.Builder{}
+		for _, field := range []struct {
+			name string
+			kb   int64
+		}{
+			{"MemTotal", mem.MemTotal},
+			{"MemFree", mem.MemFree},
+			{"MemAvailable", mem.MemAvailable},
+			{"Buffers", mem.Buffers},
+			{"Cached", mem.Cached},
+			{"SwapCached", 0},
+			{"SwapTotal", mem.SwapTotal},
+			{"SwapFree", mem.SwapFree},
+			{"Shmem", mem.Shmem},
+			{"SReclaimable", mem.SReclaimable},
+		} {
+			fmt.Fprintf(out, "%-16s%8d kB\n", field.name+":", field.kb)
+		}
+		return out.String()
+	}},
+	{Name: "/mounts", Generator: func(t *TenantOS) string {
+		return `/dev/sda1 / ext4 rw,relatime 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+udev /dev devtmpfs rw,nosuid,relatime,mode=755 0 0
+devpts /dev/pts devpts rw,nosuid,noexec,relatime,gid=5,mode=620,ptmxmode=000 0 0
+tmpfs /run tmpfs rw,nosuid,noexec,relatime,mode=755 0 0
+tmpfs /dev/shm tmpfs rw,nosuid,nodev 0 0
 `
 	}},
-	{Name: "/uptime", Generator: func(vos *SharedOS) string {
-		uptime := vos.Now().Sub(vos.BootTime()).Seconds()
+	{Name: "/net/tcp", Generator: func(t *TenantOS) string {
+		out := &strings.Builder{}
+		fmt.Fprintln(out, "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode")
+		// The SSH server listening and the session's connection to it.
+		fmt.Fprintf(out, "%4d: %s %s 0A 00000000:00000000 00:00000000 00000000     0        0 15873 1 0000000000000000 100 0 0 10 0\n", 0, procNetAddr(net.IPv4zero, 22), procNetAddr(net.IPv4zero, 0))
+		if remote, ok := t.SSHRemoteAddr().(*net.TCPAddr); ok && remote.IP.To4() != nil {
+			fmt.Fprintf(out, "%4d: %s %s 01 00000000:00000000 02:0005A0D4 00000000     0        0 24871 4 0000000000000000 20 4 31 10 -1\n", 1, procNetAddr(localIP(t), 22), procNetAddr(remote.IP, remote.Port))
+		}
+		return out.String()
+	}},
+	{Name: "/sys/kernel/domainname", Generator: func(t *TenantOS) string {
+		if domain := t.Uname().Domainname; domain != "" {
+			return domain + "\n"
+		}
+		return "(none)\n"
+	}},
+	{Name: "/sys/kernel/hostname", Generator: func(t *TenantOS) string {
+		return t.Uname().Nodename + "\n"
+	}},
+	{Name: "/sys/kernel/osrelease", Generator: func(t *TenantOS) string {
+		return t.Uname().Release + "\n"
+	}},
+	{Name: "/sys/kernel/ostype", Generator: func(t *TenantOS) string {
+		return t.Uname().Sysname + "\n"
+	}},
+	{Name: "/sys/kernel/pid_max", Generator: func(t *TenantOS) string {
+		return "4194304\n"
+	}},
+	{Name: "/sys/kernel/version", Generator: func(t *TenantOS) string {
+		return t.Uname().Version + "\n"
+	}},
+	{Name: "/uptime", Generator: func(t *TenantOS) string {
+		uptime := t.Now().Sub(t.BootTime()).Seconds()
 		// [seconds running] [seconds idle]
 		return fmt.Sprintf("%0.2f 0.00\n", uptime)
 	}},
-	{Name: "/version", Generator: func(vos *SharedOS) string {
-		uname := vos.Uname()
+	{Name: "/version", Generator: func(t *TenantOS) string {
+		uname := t.Uname()
 		return fmt.Sprintf("%s %s %s\n", uname.Sysname, uname.Release, uname.Version)
 	}},
 }
 
-func resolveProcFile(name string, vos *SharedOS) (afero.File, error) {
+type pidFile struct {
+	Name      string
+	Generator func(t *TenantOS, process ProcessInfo) string
+}
+
+// pidFiles are served in each process's directory.
+var pidFiles = []pidFile{
+	{Name: "/cmdline", Generator: func(t *TenantOS, process ProcessInfo) string {
+		if process.KernelThread() {
+			return ""
+		}
+		var out string
+		for _, arg := range process.Args {
+			out += arg + "\x00"
+		}
+		return out
+	}},
+	{Name: "/comm", Generator: func(t *TenantOS, process ProcessInfo) string {
+		return process.Name() + "\n"
+	}},
+	{Name: "/status", Generator: func(t *TenantOS, process ProcessInfo) string {
+		out := &strings.Builder{}
+		fmt.Fprintf(out, "Name:\t%s\n", process.Name())
+		fmt.Fprintf(out, "State:\t%s\n", processState(process))
+		fmt.Fprintf(out, "Tgid:\t%d\n", process.PID)
+		fmt.Fprintf(out, "Pid:\t%d\n", process.PID)
+		fmt.Fprintf(out, "PPid:\t%d\n", process.PPID)
+		fmt.Fprintf(out, "Uid:\t%d\t%d\t%d\t%d\n", process.UID, process.UID, process.UID, process.UID)
+		fmt.Fprintf(out, "Gid:\t%d\t%d\t%d\t%d\n", process.UID, process.UID, process.UID, process.UID)
+		fmt.Fprintf(out, "Threads:\t1\n")
+		return out.String()
+	}},
+}
+
+// processState describes the first letter of the process's ps state.
+func processState(process ProcessInfo) string {
+	switch {
+	case strings.HasPrefix(process.Stat, "R"):
+		return "R (running)"
+	case strings.HasPrefix(process.Stat, "I"):
+		return "I (idle)"
+	case strings.HasPrefix(process.Stat, "D"):
+		return "D (disk sleep)"
+	default:
+		return "S (sleeping)"
+	}
+}
+
+// localIP returns the address of the interface with the default route.
+func localIP(t *TenantOS) net.IP {
+	if ifaces := t.Hardware().NetworkInterfaces; len(ifaces) > 0 {
+		return ifaces[0].IP
+	}
+	return net.IPv4(127, 0, 0, 1)
+}
+
+// procNetAddr formats an IPv4 address and port like /proc/net/tcp.
+func procNetAddr(ip net.IP, port int) string {
+	ip4 := ip.To4()
+	// The address is in host (little endian) byte order.
+	return fmt.Sprintf("%02X%02X%02X%02X:%04X", ip4[3], ip4[2], ip4[1], ip4[0], port)
+}
+
+// procGenerators returns the generators for every file in the filesystem
+// keyed by path.
+func procGenerators(t *TenantOS) map[string]func() string {
+	out := make(map[string]func() string)
 	for _, procFile := range procFiles {
-		if procFile.Name == name {
-			file := mem.CreateFile(name, vos.Now)
-			mem.NewFileHandle(file).WriteString(procFile.Generator(vos))
-			return mem.NewReadOnlyFileHandle(file), nil
+		procFile := procFile
+		out[procFile.Name] = func() string {
+			return procFile.Generator(t)
 		}
 	}
 
-	if name == "/" {
-		dir := mem.CreateDir(name, vos.Now)
-		for _, procFile := range procFiles {
-			mem.AddToMemDir(dir, mem.CreateFile(procFile.Name, vos.Now))
+	for _, process := range t.Processes() {
+		process := process
+		for _, pidFile := range pidFiles {
+			pidFile := pidFile
+			out[fmt.Sprintf("/%d%s", process.PID, pidFile.Name)] = func() string {
+				return pidFile.Generator(t, process)
+			}
 		}
-		return mem.NewReadOnlyFileHandle(dir), nil
+	}
+	return out
+}
 
+// resolveGeneratedFile opens a file created by one of the generators, which
+// are keyed by path, or a directory containing them. emptyDirs are the paths
+// of directories without generated files.
+func resolveGeneratedFile(t *TenantOS, generators map[string]func() string, name string, emptyDirs ...string) (afero.File, error) {
+	name = path.Clean(name)
+
+	if generator, ok := generators[name]; ok {
+		file := mem.CreateFile(name, t.Now)
+		mem.SetMode(file, 0444)
+		mem.NewFileHandle(file).WriteString(generator())
+		return mem.NewReadOnlyFileHandle(file), nil
 	}
 
-	return nil, fs.ErrNotExist
+	var paths []string
+	for generatedName := range generators {
+		paths = append(paths, generatedName)
+	}
+	for _, dir := range emptyDirs {
+		paths = append(paths, dir+"/")
+	}
+
+	// Directories are the parents of generated files, the value of children
+	// is true for subdirectories.
+	found := false
+	children := make(map[string]bool)
+	prefix := strings.TrimSuffix(name, "/") + "/"
+	for _, childPath := range paths {
+		if rest := strings.TrimPrefix(childPath, prefix); rest != childPath {
+			found = true
+			if child := strings.SplitN(rest, "/", 2); child[0] != "" {
+				children[child[0]] = len(child) > 1
+			}
+		}
+	}
+	if !found {
+		return nil, &os.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	dir := mem.CreateDir(name, t.Now)
+	mem.SetMode(dir, fs.ModeDir|0555)
+	for child, isDir := range children {
+		var file *mem.FileData
+		if isDir {
+			file = mem.CreateDir(path.Join(name, child), t.Now)
+			mem.SetMode(file, fs.ModeDir|0555)
+		} else {
+			file = mem.CreateFile(path.Join(name, child), t.Now)
+			mem.SetMode(file, 0444)
+		}
+		mem.AddToMemDir(dir, file)
+	}
+	return mem.NewReadOnlyFileHandle(dir), nil
+}
+
+func resolveProcFile(name string, t *TenantOS) (afero.File, error) {
+	return resolveGeneratedFile(t, procGenerators(t), name, "/"+procSelf)
 }
 
-func NewProcFS(sharedOS *SharedOS) *ProcFS {
-	return &ProcFS{sharedOS: sharedOS}
+// NewProcFS creates a /proc filesystem showing the tenant's processes.
+func NewProcFS(tenantOS *TenantOS) *ProcFS {
+	return &ProcFS{tenantOS: tenantOS}
 }
 
 type ProcFS struct {
-	sharedOS *SharedOS
+	tenantOS *TenantOS
 	VirtualFS
 }
 
 var _ VFS = (*ProcFS)(nil)
 
 func (pfs *ProcFS) OpenFile(name string, flag int, perm fs.FileMode) (afero.File, error) {
-	return resolveProcFile(name, pfs.sharedOS)
+	return resolveProcFile(name, pfs.tenantOS)
 }
 
 func (pfs *ProcFS) Open(name string) (afero.File, error) {
-	return resolveProcFile(name, pfs.sharedOS)
+	return resolveProcFile(name, pfs.tenantOS)
 }
 
 func (*ProcFS) Name() string {
@@ -92,13 +309,25 @@ func (*ProcFS) Name() string {
 }
 
 func (pfs *ProcFS) Stat(name string) (fs.FileInfo, error) {
-	fd, err := resolveProcFile(name, pfs.sharedOS)
+	fd, err := resolveProcFile(name, pfs.tenantOS)
 	if err != nil {
 		return nil, err
 	}
 	return fd.Stat()
 }
 
+// newProcSelfFs maps /proc/self to the directory of the process with the
+// given PID.
+func newProcSelfFs(base VFS, pid int) VFS {
+	self := path.Join("/proc", procSelf)
+	return NewPathMappingFs(base, func(op FsOp, name string) (string, error) {
+		if name == self || strings.HasPrefix(name, self+"/") {
+			return path.Join("/proc", strconv.Itoa(pid), strings.TrimPrefix(name, self)), nil
+		}
+		return name, nil
+	})
+}
+
 // VirtualFS returns ErrNotExist for any write or modify operations.
 type VirtualFS struct{}
 
@@ -0,0 +1,71 @@
+package vos
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcFS(t *testing.T) {
+	cfg := &config.Configuration{}
+	cfg.Uname.Nodename = "web01"
+	cfg.Uname.KernelName = "Linux"
+	cfg.Uname.KernelRelease = "4.15.0-147-generic"
+	tenantOS, proc := newTestTenantOS(t, cfg, &fakeEventRecorder{})
+	tenantOS.addProcess(nil, ProcessInfo{PID: 300, Args: []string{"/usr/sbin/sshd", "-D"}})
+	tenantOS.addProcess(proc.(*TenantProcOS), ProcessInfo{PID: proc.Getpid(), PPID: 300, Args: []string{"sh", "-c", "true"}})
+	pid := strconv.Itoa(proc.Getpid())
+
+	readProc := func(name string) string {
+		t.Helper()
+		contents, err := afero.ReadFile(proc, name)
+		require.Nil(t, err)
+		return string(contents)
+	}
+
+	assert.Equal(t, "0.08 0.02 0.01 1/2 300\n", readProc("/proc/loadavg"))
+	assert.Contains(t, readProc("/proc/meminfo"), "MemTotal:        7596572 kB\n")
+	assert.Contains(t, readProc("/proc/mounts"), "proc /proc proc ")
+	assert.Contains(t, readProc("/proc/net/tcp"), "   0: 00000000:0016 00000000:0000 0A ")
+	assert.Equal(t, "web01\n", readProc("/proc/sys/kernel/hostname"))
+	assert.Equal(t, "(none)\n", readProc("/proc/sys/kernel/domainname"))
+	assert.Equal(t, "4.15.0-147-generic\n", readProc("/proc/sys/kernel/osrelease"))
+
+	assert.Equal(t, "/usr/sbin/sshd\x00-D\x00", readProc("/proc/300/cmdline"))
+	assert.Equal(t, "sh\x00-c\x00true\x00", readProc("/proc/self/cmdline"))
+	assert.Equal(t, "sh\n", readProc("/proc/self/comm"))
+	assert.Contains(t, readProc("/proc/self/status"), "Pid:\t"+pid+"\nPPid:\t300\n")
+
+	names, err := afero.ReadDir(proc, "/proc")
+	require.Nil(t, err)
+	var got []string
+	for _, info := range names {
+		got = append(got, info.Mode().String()+" "+info.Name())
+	}
+	assert.Equal(t, []string{
+		"dr-xr-xr-x " + pid,
+		"dr-xr-xr-x 300",
+		"-r--r--r-- cpuinfo",
+		"-r--r--r-- loadavg",
+		"-r--r--r-- meminfo",
+		"-r--r--r-- mounts",
+		"dr-xr-xr-x net",
+		"dr-xr-xr-x self",
+		"dr-xr-xr-x sys",
+		"-r--r--r-- uptime",
+		"-r--r--r-- version",
+	}, got)
+
+	info, err := proc.Stat("/proc/self")
+	require.Nil(t, err)
+	assert.True(t, info.IsDir())
+
+	tenantOS.removeProcess(300)
+	_, err = proc.Stat("/proc/300")
+	assert.ErrorIs(t, err, os.ErrNotExist, "exited processes are removed")
+}
@@ -0,0 +1,276 @@
+package vos
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/josephlewis42/honeyssh/third_party/realpath"
+	"github.com/spf13/afero"
+)
+
+// writeFlags are the open flags that cause a file to be copied to the
+// overlay.
+const writeFlags = os.O_WRONLY | os.O_RDWR | os.O_APPEND | os.O_CREATE | os.O_TRUNC
+
+// recordingFs logs the files a process opens and the changes it makes to the
+// filesystem.
+type recordingFs struct {
+	VFS
+
+	proc *TenantProcOS
+}
+
+var _ VFS = (*recordingFs)(nil)
+var _ afero.Symlinker = (*recordingFs)(nil)
+
+func newRecordingFs(base VFS, proc *TenantProcOS) *recordingFs {
+	return &recordingFs{VFS: base, proc: proc}
+}
+
+func (r *recordingFs) Open(name string) (afero.File, error) {
+	f, err := r.VFS.Open(name)
+	r.recordOpen(name, os.O_RDONLY, err)
+	return r.opened(f, err)
+}
+
+func (r *recordingFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	f, err := r.VFS.OpenFile(name, flag, perm)
+	r.recordOpen(name, flag, err)
+	return r.opened(f, err)
+}
+
+func (r *recordingFs) Create(name string) (afero.File, error) {
+	f, err := r.VFS.Create(name)
+	r.record(&logger.FilesystemOp{
+		Type: logger.FilesystemOp_CREATE,
+		Path: r.abs(name),
+	}, err)
+	return r.opened(f, err)
+}
+
+// opened ties a file the process opened to its lifetime, killed processes
+// can't keep reading devices or writing files.
+func (r *recordingFs) opened(f afero.File, err error) (afero.File, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &killableFile{File: f, killed: r.proc.killed}, nil
+}
+
+func (r *recordingFs) Mkdir(name string, perm os.FileMode) error {
+	err := r.VFS.Mkdir(name, perm)
+	r.record(&logger.FilesystemOp{
+		Type: logger.FilesystemOp_MKDIR,
+		Path: r.abs(name),
+		Mode: unixPermissions(perm),
+	}, err)
+	return err
+}
+
+func (r *recordingFs) MkdirAll(name string, perm os.FileMode) error {
+	err := r.VFS.MkdirAll(name, perm)
+	r.record(&logger.FilesystemOp{
+		Type: logger.FilesystemOp_MKDIR,
+		Path: r.abs(name),
+		Mode: unixPermissions(perm),
+	}, err)
+	return err
+}
+
+func (r *recordingFs) Remove(name string) error {
+	err := r.VFS.Remove(name)
+	r.record(&logger.FilesystemOp{
+		Type: logger.FilesystemOp_REMOVE,
+		Path: r.abs(name),
+	}, err)
+	return err
+}
+
+func (r *recordingFs) RemoveAll(name string) error {
+	err := r.VFS.RemoveAll(name)
+	r.record(&logger.FilesystemOp{
+		Type: logger.FilesystemOp_REMOVE,
+		Path: r.abs(name),
+	}, err)
+	return err
+}
+
+func (r *recordingFs) Rename(oldname, newname string) error {
+	err := r.VFS.Rename(oldname, newname)
+	r.record(&logger.FilesystemOp{
+		Type:    logger.FilesystemOp_RENAME,
+		Path:    r.abs(oldname),
+		NewPath: r.abs(newname),
+	}, err)
+	return err
+}
+
+func (r *recordingFs) Chmod(name string, mode os.FileMode) error {
+	err := r.VFS.Chmod(name, mode)
+	r.record(&logger.FilesystemOp{
+		Type: logger.FilesystemOp_CHMOD,
+		Path: r.abs(name),
+		Mode: unixPermissions(mode),
+	}, err)
+	return err
+}
+
+func (r *recordingFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	if lstater, ok := r.VFS.(afero.Lstater); ok {
+		return lstater.LstatIfPossible(name)
+	}
+	fi, err := r.VFS.Stat(name)
+	return fi, false, err
+}
+
+func (r *recordingFs) SymlinkIfPossible(oldname, newname string) error {
+	err := error(&os.LinkError{Op: FsOpSymlink, Old: oldname, New: newname, Err: afero.ErrNoSymlink})
+	if linker, ok := r.VFS.(afero.Linker); ok {
+		err = linker.SymlinkIfPossible(oldname, newname)
+	}
+
+	// The target is kept as is because relative targets are relative to the
+	// link.
+	r.record(&logger.FilesystemOp{
+		Type:    logger.FilesystemOp_SYMLINK,
+		Path:    r.abs(newname),
+		NewPath: oldname,
+	}, err)
+	return err
+}
+
+func (r *recordingFs) ReadlinkIfPossible(name string) (string, error) {
+	if reader, ok := r.VFS.(afero.LinkReader); ok {
+		return reader.ReadlinkIfPossible(name)
+	}
+	return "", &os.PathError{Op: FsOpReadlink, Path: name, Err: afero.ErrNoReadlink}
+}
+
+// abs returns the absolute path of a name the process used.
+func (r *recordingFs) abs(name string) string {
+	if !path.IsAbs(name) {
+		name = path.Join(r.proc.Getwd(), name)
+	}
+	return path.Clean(name)
+}
+
+func (r *recordingFs) skip(absPath string) bool {
+	return r.proc.SharedOS.config.FilesystemLog.Skip(absPath)
+}
+
+func (r *recordingFs) process() *logger.Process {
+	return &logger.Process{
+		Pid:     int32(r.proc.PID),
+		Command: r.proc.ProcArgs,
+	}
+}
+
+// record logs a change to the filesystem, changes are always made in the
+// overlay.
+func (r *recordingFs) record(op *logger.FilesystemOp, err error) {
+	if r.skip(op.Path) {
+		return
+	}
+
+	op.Process = r.process()
+	op.Result, op.Error = operationResult(err)
+	if err == nil {
+		op.Layer = logger.FilesystemOp_OVERLAY
+	}
+
+	r.proc.TenantOS.eventRecorder.Record(&logger.LogEntry_FilesystemOperation{
+		FilesystemOperation: op,
+	})
+}
+
+func (r *recordingFs) recordOpen(name string, flag int, err error) {
+	absPath := r.abs(name)
+	if r.skip(absPath) {
+		return
+	}
+
+	openFile := &logger.OpenFile{
+		Path:    absPath,
+		Flags:   openFlags(flag),
+		Process: r.process(),
+	}
+	openFile.Result, openFile.Error = operationResult(err)
+	switch {
+	case err != nil:
+		// The layer is unknown.
+	case flag&writeFlags != 0:
+		openFile.Layer = logger.FilesystemOp_OVERLAY
+	default:
+		openFile.Layer = r.readLayer(name)
+	}
+
+	r.proc.TenantOS.eventRecorder.Record(&logger.LogEntry_OpenFile{
+		OpenFile: openFile,
+	})
+}
+
+// readLayer returns the layer a file opened for reading came from.
+func (r *recordingFs) readLayer(name string) logger.FilesystemOp_Layer {
+	tenant := r.proc.TenantOS
+	resolved, err := realpath.Realpath(&realpathOs{r.proc.Getwd, tenant.fs}, name)
+	if err == nil {
+		if _, err := tenant.overlay.Stat(resolved); err == nil {
+			return logger.FilesystemOp_OVERLAY
+		}
+	}
+	return logger.FilesystemOp_BASE
+}
+
+func operationResult(err error) (logger.OperationResult, string) {
+	if err != nil {
+		return logger.OperationResult_FAILURE, err.Error()
+	}
+	return logger.OperationResult_SUCCESS, ""
+}
+
+// openFlags formats open flags like strace e.g. O_WRONLY|O_CREATE|O_TRUNC.
+func openFlags(flag int) string {
+	var flags []string
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_WRONLY:
+		flags = append(flags, "O_WRONLY")
+	case os.O_RDWR:
+		flags = append(flags, "O_RDWR")
+	default:
+		flags = append(flags, "O_RDONLY")
+	}
+
+	for _, f := range []struct {
+		flag int
+		name string
+	}{
+		{os.O_APPEND, "O_APPEND"},
+		{os.O_CREATE, "O_CREATE"},
+		{os.O_EXCL, "O_EXCL"},
+		{os.O_SYNC, "O_SYNC"},
+		{os.O_TRUNC, "O_TRUNC"},
+	} {
+		if flag&f.flag != 0 {
+			flags = append(flags, f.name)
+		}
+	}
+	return strings.Join(flags, "|")
+}
+
+// unixPermissions converts the permission bits of the mode to their Unix
+// values e.g. 04755 for a setuid executable.
+func unixPermissions(mode os.FileMode) uint32 {
+	out := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		out |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		out |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		out |= 01000
+	}
+	return out
+}
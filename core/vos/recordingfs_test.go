@@ -0,0 +1,108 @@
+package vos
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/josephlewis42/honeyssh/third_party/memmapfs"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEventRecorder struct {
+	events []logger.LogType
+}
+
+func (f *fakeEventRecorder) Record(event logger.LogType) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeEventRecorder) SessionID() string {
+	return "$SSH_SESSION_ID$"
+}
+
+type fakeSSHSession struct{}
+
+func (*fakeSSHSession) User() string                { return "root" }
+func (*fakeSSHSession) RemoteAddr() net.Addr        { return nil }
+func (*fakeSSHSession) Exit(code int) error         { return nil }
+func (*fakeSSHSession) Write(b []byte) (int, error) { return len(b), nil }
+
+// newTestTenantOS creates a tenant with /etc/motd and /root in its base
+// filesystem and starts a shell process in /root.
+func newTestTenantOS(t *testing.T, cfg *config.Configuration, recorder EventRecorder) (*TenantOS, VOS) {
+	t.Helper()
+
+	timeSource := func() time.Time {
+		return time.Date(2006, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	baseFS := memmapfs.NewMemMapFs(timeSource)
+	require.Nil(t, baseFS.MkdirAll("/etc", 0755))
+	require.Nil(t, afero.WriteFile(baseFS, "/etc/motd", []byte("hello"), 0644))
+	require.Nil(t, baseFS.MkdirAll("/root", 0700))
+
+	sharedOS := NewSharedOS(baseFS, func(string) ProcessFunc { return func(VOS) int { return 0 } }, cfg, timeSource)
+	tenantOS := NewTenantOS(sharedOS, recorder, &fakeSSHSession{})
+
+	proc, err := tenantOS.LoginProc().StartProcess("/bin/sh", []string{"sh", "-c", "true"}, &ProcAttr{Dir: "/root"})
+	require.Nil(t, err)
+	return tenantOS, proc
+}
+
+func TestRecordingFs(t *testing.T) {
+	cfg := &config.Configuration{}
+	cfg.FilesystemLog.SkipPaths = []string{"/proc"}
+	recorder := &fakeEventRecorder{}
+	_, proc := newTestTenantOS(t, cfg, recorder)
+
+	// Open a file from the base, then copy it into the overlay.
+	_, err := proc.Open("/etc/motd")
+	require.Nil(t, err)
+	_, err = proc.OpenFile("notes.txt", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	require.Nil(t, err)
+	_, err = proc.Open("notes.txt")
+	require.Nil(t, err)
+	require.Nil(t, proc.Chmod("notes.txt", 0755|os.ModeSetuid))
+	require.Nil(t, proc.MkdirAll("/tmp/a/b", 0755))
+	require.Nil(t, proc.Rename("notes.txt", "/tmp/a/notes.txt"))
+	assert.NotNil(t, proc.Remove("/etc/motd"))
+	_, err = proc.Open("/proc/uptime")
+	require.Nil(t, err)
+
+	process := &logger.Process{Pid: int32(proc.Getpid()), Command: []string{"sh", "-c", "true"}}
+	var got []string
+	for _, event := range recorder.events {
+		switch event := event.(type) {
+		case *logger.LogEntry_OpenFile:
+			openFile := event.OpenFile
+			assert.Equal(t, process.GetPid(), openFile.GetProcess().GetPid())
+			assert.Equal(t, process.GetCommand(), openFile.GetProcess().GetCommand())
+			got = append(got, "open "+openFile.GetPath()+" "+openFile.GetFlags()+" "+openFile.GetLayer().String())
+		case *logger.LogEntry_FilesystemOperation:
+			op := event.FilesystemOperation
+			assert.Equal(t, process.GetPid(), op.GetProcess().GetPid())
+			entry := op.GetType().String() + " " + op.GetPath() + " " + op.GetNewPath() + " " + op.GetResult().String()
+			if op.GetMode() != 0 {
+				entry += " " + strconv.FormatUint(uint64(op.GetMode()), 8)
+			}
+			got = append(got, entry)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"open /etc/motd O_RDONLY BASE",
+		"open /root/notes.txt O_WRONLY|O_CREATE|O_TRUNC OVERLAY",
+		"open /root/notes.txt O_RDONLY OVERLAY",
+		"CHMOD /root/notes.txt  SUCCESS 4755",
+		"MKDIR /tmp/a/b  SUCCESS 755",
+		"RENAME /root/notes.txt /tmp/a/notes.txt SUCCESS",
+		"REMOVE /etc/motd  FAILURE",
+	}, got)
+}
@@ -1,6 +1,8 @@
 package vos
 
 import (
+	"net"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -20,7 +22,7 @@ type TimeSource func() time.Time
 func NewSharedOS(baseFS VFS, procResolver ProcessResolver, config *config.Configuration, timeSource TimeSource) *SharedOS {
 	return &SharedOS{
 		mockFS:          baseFS,
-		mockPID:         0,
+		mockPID:         new(int32),
 		bootTime:        timeSource(),
 		processResolver: procResolver,
 		config:          config,
@@ -36,7 +38,7 @@ type SharedOS struct {
 	// mockFS holds the base filesystem that is shared between ALL programs.
 	mockFS VFS
 	// mockPID contains the next PID of the system.
-	mockPID int32
+	mockPID *int32
 	// The time the system booted.
 	bootTime time.Time
 	// The resolver for processes.
@@ -62,6 +64,70 @@ func (s *SharedOS) Uname() Utsname {
 	}
 }
 
+// MemInfo implements VKernel.MemInfo.
+func (s *SharedOS) MemInfo() MemInfo {
+	return MemInfo{
+		MemTotal:     7596572,
+		MemFree:      1215572,
+		MemAvailable: 2171992,
+		Buffers:      108232,
+		Cached:       1612520,
+		SReclaimable: 272436,
+		Shmem:        730612,
+		SwapTotal:    24587768,
+		SwapFree:     20286528,
+	}
+}
+
+// LoadAverage implements VKernel.LoadAverage.
+func (s *SharedOS) LoadAverage() [3]float64 {
+	return [3]float64{0.08, 0.02, 0.01}
+}
+
+// defaultHardware is used by configurations from before the hardware was
+// configurable.
+var defaultHardware = config.Hardware{
+	CPUs:        1,
+	SysVendor:   "Google",
+	ProductName: "Google Compute Engine",
+	Hypervisor:  "KVM",
+	NetworkInterfaces: []config.NetworkInterface{
+		{Name: "ens4", MAC: "42:01:0a:80:00:02", IP: "10.128.0.2"},
+	},
+}
+
+// cpuFlags are the flags of each CPU.
+var cpuFlags = strings.Fields("fpu vme de pse tsc msr pae mce cx8 apic sep mtrr pge mca cmov pat pse36 clflush dts acpi mmx fxsr sse sse2 ss ht tm pbe syscall nx pdpe1gb rdtscp lm pni pclmulqdq dtes64 monitor ds_cpl vmx smx est tm2 ssse3 cx16 xtpr pdcm pcid dca sse4_1 sse4_2 x2apic popcnt tsc_deadline_timer aes xsave avx xsaveopt")
+
+// Hardware implements VKernel.Hardware.
+func (s *SharedOS) Hardware() Hardware {
+	hw := s.config.Hardware
+	if hw.CPUs == 0 {
+		hw = defaultHardware
+	}
+
+	out := Hardware{
+		CPUs:        hw.CPUs,
+		CPUFlags:    cpuFlags,
+		SysVendor:   hw.SysVendor,
+		ProductName: hw.ProductName,
+		Hypervisor:  hw.Hypervisor,
+	}
+	if hw.Hypervisor != "" {
+		out.CPUFlags = append(append([]string{}, cpuFlags...), "hypervisor")
+	}
+	for _, iface := range hw.NetworkInterfaces {
+		// The configuration is validated so parsing can't fail.
+		mac, _ := net.ParseMAC(iface.MAC)
+		out.NetworkInterfaces = append(out.NetworkInterfaces, NetworkInterface{
+			Name: iface.Name,
+			MAC:  mac,
+			IP:   net.ParseIP(iface.IP).To4(),
+		})
+	}
+	return out
+}
+
 func (s *SharedOS) BootTime() time.Time {
 	return s.bootTime
 }
@@ -76,13 +142,38 @@ func (s *SharedOS) ReadOnlyFs() VFS {
 	return afero.NewReadOnlyFs(s.mockFS)
 }
 
-// NextPID gets a monotonically increasing PID.
+// NextPID gets a monotonically increasing PID that isn't used by a system
+// process.
 func (s *SharedOS) NextPID() int {
-	return int(atomic.AddInt32(&s.mockPID, 1))
+	for {
+		pid := int(atomic.AddInt32(s.mockPID, 1))
+		if !s.isSystemPID(pid) {
+			return pid
+		}
+	}
+}
+
+func (s *SharedOS) isSystemPID(pid int) bool {
+	for _, process := range s.config.OS.Processes {
+		if process.PID == pid {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *SharedOS) SetPID(pid int32) {
-	atomic.StoreInt32(&s.mockPID, pid)
+	atomic.StoreInt32(s.mockPID, pid)
+}
+
+// Reload creates a SharedOS with a new base filesystem and configuration that
+// shares the boot time and PIDs with this one so the system appears to have
+// kept running.
+func (s *SharedOS) Reload(baseFS VFS, config *config.Configuration) *SharedOS {
+	reloaded := *s
+	reloaded.mockFS = baseFS
+	reloaded.config = config
+	return &reloaded
 }
 
 func (s *SharedOS) GetUser(username string) (usr config.User, ok bool) {
@@ -0,0 +1,104 @@
+package vos
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Signal is a Linux signal number, they're defined here rather than taken
+// from syscall because the numbers differ between platforms.
+type Signal int
+
+const (
+	SIGHUP Signal = iota + 1
+	SIGINT
+	SIGQUIT
+	SIGILL
+	SIGTRAP
+	SIGABRT
+	SIGBUS
+	SIGFPE
+	SIGKILL
+	SIGUSR1
+	SIGSEGV
+	SIGUSR2
+	SIGPIPE
+	SIGALRM
+	SIGTERM
+	SIGSTKFLT
+	SIGCHLD
+	SIGCONT
+	SIGSTOP
+	SIGTSTP
+	SIGTTIN
+	SIGTTOU
+	SIGURG
+	SIGXCPU
+	SIGXFSZ
+	SIGVTALRM
+	SIGPROF
+	SIGWINCH
+	SIGIO
+	SIGPWR
+	SIGSYS
+)
+
+// signalNames holds signal names without the SIG prefix indexed by number.
+var signalNames = [...]string{
+	"", "HUP", "INT", "QUIT", "ILL", "TRAP", "ABRT", "BUS", "FPE", "KILL",
+	"USR1", "SEGV", "USR2", "PIPE", "ALRM", "TERM", "STKFLT", "CHLD", "CONT",
+	"STOP", "TSTP", "TTIN", "TTOU", "URG", "XCPU", "XFSZ", "VTALRM", "PROF",
+	"WINCH", "IO", "PWR", "SYS",
+}
+
+// Signals returns every signal in numeric order.
+func Signals() []Signal {
+	var out []Signal
+	for sig := SIGHUP; sig <= SIGSYS; sig++ {
+		out = append(out, sig)
+	}
+	return out
+}
+
+// String returns the signal's name without the SIG prefix e.g. "KILL".
+func (s Signal) String() string {
+	if s <= 0 || int(s) >= len(signalNames) {
+		return strconv.Itoa(int(s))
+	}
+	return signalNames[s]
+}
+
+// ParseSignal parses a signal number or a case-insensitive name with or
+// without the SIG prefix. The number 0 is valid and only checks whether a
+// process exists.
+func ParseSignal(spec string) (Signal, error) {
+	if num, err := strconv.Atoi(spec); err == nil {
+		if num < 0 || num >= len(signalNames) {
+			return 0, fmt.Errorf("%s: invalid signal specification", spec)
+		}
+		return Signal(num), nil
+	}
+
+	name := strings.TrimPrefix(strings.ToUpper(spec), "SIG")
+	if name == "POLL" {
+		return SIGIO, nil
+	}
+	for _, sig := range Signals() {
+		if sig.String() == name {
+			return sig, nil
+		}
+	}
+	return 0, fmt.Errorf("%s: invalid signal specification", spec)
+}
+
+// terminates returns whether the signal's default action ends the process.
+// Stopping isn't simulated so stop signals are treated as ignored.
+func (s Signal) terminates() bool {
+	switch s {
+	case 0, SIGCHLD, SIGCONT, SIGSTOP, SIGTSTP, SIGTTIN, SIGTTOU, SIGURG, SIGWINCH:
+		return false
+	default:
+		return true
+	}
+}
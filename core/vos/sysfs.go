@@ -0,0 +1,78 @@
+package vos
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// sysGenerators returns the generators for every file in the filesystem
+// keyed by path.
+func sysGenerators(t *TenantOS) map[string]func() string {
+	hardware := t.Hardware()
+	out := make(map[string]func() string)
+	static := func(contents string) func() string {
+		return func() string {
+			return contents + "\n"
+		}
+	}
+
+	for _, name := range []string{"online", "possible", "present"} {
+		out["/devices/system/cpu/"+name] = static(hardware.CPUList())
+	}
+	for cpu := 0; cpu < hardware.CPUs; cpu++ {
+		out[fmt.Sprintf("/devices/system/cpu/cpu%d/online", cpu)] = static("1")
+	}
+
+	out["/class/dmi/id/sys_vendor"] = static(hardware.SysVendor)
+	out["/class/dmi/id/product_name"] = static(hardware.ProductName)
+	if hardware.Hypervisor != "" {
+		out["/hypervisor/type"] = static(strings.ToLower(hardware.Hypervisor))
+	}
+
+	out["/class/net/lo/address"] = static("00:00:00:00:00:00")
+	out["/class/net/lo/operstate"] = static("unknown")
+	for _, iface := range hardware.NetworkInterfaces {
+		out["/class/net/"+iface.Name+"/address"] = static(iface.MAC.String())
+		out["/class/net/"+iface.Name+"/operstate"] = static("up")
+	}
+	return out
+}
+
+func resolveSysFile(name string, t *TenantOS) (afero.File, error) {
+	return resolveGeneratedFile(t, sysGenerators(t), name)
+}
+
+// NewSysFS creates a /sys filesystem describing the configured hardware.
+func NewSysFS(tenantOS *TenantOS) *SysFS {
+	return &SysFS{tenantOS: tenantOS}
+}
+
+type SysFS struct {
+	tenantOS *TenantOS
+	VirtualFS
+}
+
+var _ VFS = (*SysFS)(nil)
+
+func (sfs *SysFS) OpenFile(name string, flag int, perm fs.FileMode) (afero.File, error) {
+	return resolveSysFile(name, sfs.tenantOS)
+}
+
+func (sfs *SysFS) Open(name string) (afero.File, error) {
+	return resolveSysFile(name, sfs.tenantOS)
+}
+
+func (*SysFS) Name() string {
+	return "/sys"
+}
+
+func (sfs *SysFS) Stat(name string) (fs.FileInfo, error) {
+	fd, err := resolveSysFile(name, sfs.tenantOS)
+	if err != nil {
+		return nil, err
+	}
+	return fd.Stat()
+}
@@ -0,0 +1,62 @@
+package vos
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSysFS(t *testing.T) {
+	cfg := &config.Configuration{}
+	cfg.Hardware = config.Hardware{
+		CPUs:        4,
+		SysVendor:   "QEMU",
+		ProductName: "Standard PC (i440FX + PIIX, 1996)",
+		Hypervisor:  "KVM",
+		NetworkInterfaces: []config.NetworkInterface{
+			{Name: "eth0", MAC: "52:54:00:12:34:56", IP: "192.168.122.10"},
+		},
+	}
+	_, proc := newTestTenantOS(t, cfg, &fakeEventRecorder{})
+
+	readFile := func(name string) string {
+		t.Helper()
+		contents, err := afero.ReadFile(proc, name)
+		require.Nil(t, err)
+		return string(contents)
+	}
+
+	assert.Equal(t, "0-3\n", readFile("/sys/devices/system/cpu/online"))
+	assert.Equal(t, "52:54:00:12:34:56\n", readFile("/sys/class/net/eth0/address"))
+	assert.Equal(t, "QEMU\n", readFile("/sys/class/dmi/id/sys_vendor"))
+	assert.Equal(t, "Standard PC (i440FX + PIIX, 1996)\n", readFile("/sys/class/dmi/id/product_name"))
+	assert.Equal(t, "kvm\n", readFile("/sys/hypervisor/type"))
+
+	cpuinfo := readFile("/proc/cpuinfo")
+	assert.Equal(t, 4, strings.Count(cpuinfo, "processor   :"))
+	assert.Contains(t, cpuinfo, " hypervisor\n")
+
+	names, err := afero.ReadDir(proc, "/sys/class/net")
+	require.Nil(t, err)
+	var got []string
+	for _, info := range names {
+		got = append(got, info.Name())
+	}
+	assert.Equal(t, []string{"eth0", "lo"}, got)
+}
+
+func TestSharedOS_Hardware(t *testing.T) {
+	_, proc := newTestTenantOS(t, &config.Configuration{}, &fakeEventRecorder{})
+	hardware := proc.Hardware()
+
+	assert.Equal(t, 1, hardware.CPUs, "configurations without hardware use the defaults")
+	require.Len(t, hardware.NetworkInterfaces, 1)
+	iface := hardware.NetworkInterfaces[0]
+	assert.Equal(t, "42:01:0a:80:00:02", iface.MAC.String())
+	assert.Equal(t, "10.128.0.2", iface.IP.String())
+	assert.Equal(t, "fe80::4001:aff:fe80:2", iface.LinkLocal().String())
+}
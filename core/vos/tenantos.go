@@ -3,15 +3,28 @@ package vos
 import (
 	"io"
 	"net"
+	"path"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/josephlewis42/honeyssh/core/logger"
+	"github.com/josephlewis42/honeyssh/third_party/cowfs"
+	"github.com/josephlewis42/honeyssh/third_party/memmapfs"
 )
 
 type TenantOS struct {
 	*SharedOS
 	// fs contains a tenant's view of the shared OS.
 	fs VFS
+	// base is the filesystem the tenant's changes are layered on.
+	base VFS
+	// overlay holds the files the tenant created or modified.
+	overlay *overlayFs
+	// restored holds the files restored into the overlay from an earlier
+	// session.
+	restored map[string]restoredFile
 	// eventRecorder logs events.
 	eventRecorder EventRecorder
 	// Connected terminal information.
@@ -20,6 +33,59 @@ type TenantOS struct {
 	loginTime time.Time
 
 	session SSHSession
+
+	processMu sync.Mutex
+	// processes holds the running processes by PID.
+	processes map[int]ProcessInfo
+	// sessionProcesses holds the processes run by the session by PID, the
+	// rest of the process table is made up of system processes.
+	sessionProcesses map[int]*TenantProcOS
+}
+
+// ProcessInfo describes a running process.
+type ProcessInfo struct {
+	PID  int
+	PPID int
+	UID  int
+	// TTY is the controlling terminal e.g. "pts/0", blank if there's none.
+	TTY string
+	// Stat is the state shown by ps e.g. "Ss" or "S+".
+	Stat string
+	// VSZ and RSS are the virtual and resident memory sizes in KiB.
+	VSZ int64
+	RSS int64
+	// Started is the time the process started.
+	Started time.Time
+	// Args holds command line arguments, including the command as Args[0].
+	Args []string
+}
+
+// Name returns the name of the process's executable truncated like the
+// kernel does.
+func (p ProcessInfo) Name() string {
+	if len(p.Args) == 0 {
+		return ""
+	}
+	name := path.Base(p.Args[0])
+	if p.KernelThread() {
+		name = strings.Trim(strings.Join(p.Args, " "), "[]")
+	}
+	if len(name) > 15 {
+		name = name[:15]
+	}
+	return name
+}
+
+// KernelThread returns whether the process is a kernel thread, those are
+// kthreadd and its children.
+func (p ProcessInfo) KernelThread() bool {
+	return p.PID == 2 || p.PPID == 2
+}
+
+// ignoresSignals returns whether signals sent to the process have no effect,
+// init and kernel threads can't be killed from userspace.
+func (p ProcessInfo) ignoresSignals() bool {
+	return p.PID == 1 || p.KernelThread()
 }
 
 type EventRecorder interface {
@@ -36,19 +102,126 @@ type SSHSession interface {
 
 func NewTenantOS(sharedOS *SharedOS, eventRecorder EventRecorder, session SSHSession) *TenantOS {
 	mountFS := NewMountFS(sharedOS.ReadOnlyFs())
-	if err := mountFS.Mount("/proc", NewProcFS(sharedOS)); err != nil {
-		panic(err)
-	}
+	overlay := newOverlayFs(memmapfs.NewMemMapFs(sharedOS.timeSource), sharedOS.config.Limits.FilesystemSizeLimit())
+	ufs := cowfs.NewCopyOnWriteFs(mountFS, overlay)
 
-	ufs := NewMemCopyOnWriteFs(mountFS, sharedOS.timeSource)
-
-	return &TenantOS{
+	tenantOS := &TenantOS{
 		SharedOS:      sharedOS,
 		fs:            ufs,
+		base:          mountFS,
+		overlay:       overlay,
 		eventRecorder: eventRecorder,
 		loginTime:     sharedOS.timeSource(),
 		session:       session,
 	}
+
+	if err := mountFS.Mount("/proc", NewProcFS(tenantOS)); err != nil {
+		panic(err)
+	}
+	if err := mountFS.Mount("/dev", NewDevFS(tenantOS)); err != nil {
+		panic(err)
+	}
+	if err := mountFS.Mount("/sys", NewSysFS(tenantOS)); err != nil {
+		panic(err)
+	}
+
+	for _, process := range sharedOS.config.OS.Processes {
+		tenantOS.addProcess(nil, ProcessInfo{
+			PID:     process.PID,
+			PPID:    process.PPID,
+			UID:     process.UID,
+			TTY:     process.TTY,
+			Stat:    process.Stat,
+			VSZ:     process.VSZ,
+			RSS:     process.RSS,
+			Started: sharedOS.BootTime(),
+			Args:    strings.Fields(process.Command),
+		})
+	}
+
+	return tenantOS
+}
+
+// addProcess records a process as running, proc is nil for system processes.
+func (t *TenantOS) addProcess(proc *TenantProcOS, info ProcessInfo) {
+	t.processMu.Lock()
+	defer t.processMu.Unlock()
+
+	if t.processes == nil {
+		t.processes = make(map[int]ProcessInfo)
+	}
+	t.processes[info.PID] = info
+	if proc == nil {
+		return
+	}
+	if t.sessionProcesses == nil {
+		t.sessionProcesses = make(map[int]*TenantProcOS)
+	}
+	t.sessionProcesses[info.PID] = proc
+}
+
+// removeProcess records a process as exited.
+func (t *TenantOS) removeProcess(pid int) {
+	t.processMu.Lock()
+	defer t.processMu.Unlock()
+
+	delete(t.processes, pid)
+	delete(t.sessionProcesses, pid)
+}
+
+// signalLocked delivers a signal to the process. System processes only exist
+// in the process table so they're removed when terminated, session processes
+// are told to stop and are removed once they exit.
+func (t *TenantOS) signalLocked(process ProcessInfo, sig Signal) {
+	if !sig.terminates() || process.ignoresSignals() {
+		return
+	}
+
+	proc, ok := t.sessionProcesses[process.PID]
+	switch {
+	case !ok:
+		delete(t.processes, process.PID)
+	case proc.killedBy != 0, sig != SIGKILL && proc.ignored[sig]:
+		// Already terminated or ignored.
+	default:
+		proc.killedBy = sig
+		close(proc.killed)
+	}
+}
+
+// KillAll sends SIGKILL to every process the session started so none of them
+// outlive it.
+func (t *TenantOS) KillAll() {
+	t.processMu.Lock()
+	defer t.processMu.Unlock()
+
+	for pid := range t.sessionProcesses {
+		t.signalLocked(t.processes[pid], SIGKILL)
+	}
+}
+
+// Process returns the running process with the PID.
+func (t *TenantOS) Process(pid int) (ProcessInfo, bool) {
+	t.processMu.Lock()
+	defer t.processMu.Unlock()
+
+	info, ok := t.processes[pid]
+	return info, ok
+}
+
+// Processes returns the running processes ordered by PID.
+func (t *TenantOS) Processes() []ProcessInfo {
+	t.processMu.Lock()
+	defer t.processMu.Unlock()
+
+	var out []ProcessInfo
+	for _, info := range t.processes {
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].PID < out[j].PID
+	})
+	return out
 }
 
 func (t *TenantOS) SetPTY(pty PTY) {
@@ -84,6 +257,7 @@ func (t *TenantOS) LoginProc() *TenantProcOS {
 		Exec: func(_ VOS) int {
 			return 0
 		},
+		killed: make(chan struct{}),
 	}
 }
 
@@ -119,6 +293,13 @@ func (t *TenantOS) LogCreds(creds *logger.Credentials) {
 	})
 }
 
+// LogSFTPRequest records an operation requested through the SFTP subsystem.
+func (t *TenantOS) LogSFTPRequest(req *logger.SFTPRequest) {
+	t.eventRecorder.Record(&logger.LogEntry_SftpRequest{
+		SftpRequest: req,
+	})
+}
+
 func (t *TenantOS) loginEnv() []string {
 	mapEnv := NewMapEnv()
 
@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"runtime/debug"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/josephlewis42/honeyssh/core/logger"
@@ -30,12 +31,24 @@ type TenantProcOS struct {
 	ProcArgs []string
 	// The process ID of the process
 	PID int
+	// The process ID of the process's parent.
+	PPID int
 	// The user ID of the process.
 	UID int
 	// Dir specifies the working directory of the command.
 	Dir string
 	// Exec is the process executable that is run when the process starts.
 	Exec ProcessFunc
+
+	// done is closed when a process started with Start exits with exitCode.
+	done     chan struct{}
+	exitCode int
+
+	// killed is closed when the process is terminated by killedBy, both are
+	// guarded by the TenantOS process lock like ignored.
+	killed   chan struct{}
+	killedBy Signal
+	ignored  map[Signal]bool
 }
 
 var _ VOS = (*TenantProcOS)(nil)
@@ -83,7 +96,77 @@ func (ea *TenantProcOS) Chdir(dir string) (err error) {
 	}
 }
 
-func (ea *TenantProcOS) Run() (resultCode int) {
+// Default memory sizes in KiB of processes started in a session.
+const (
+	sessionProcessVSZ = 5752
+	sessionProcessRSS = 3584
+)
+
+// Run implements VOS.Run.
+func (ea *TenantProcOS) Run() int {
+	if ea.Exec == nil {
+		return 1
+	}
+
+	stat := "S+"
+	if ea.PPID == 0 {
+		// Processes started by the login lead the session.
+		stat = "Ss"
+	}
+	ea.TenantOS.addProcess(ea, ea.processInfo(stat))
+	return ea.run()
+}
+
+// Start implements VOS.Start.
+func (ea *TenantProcOS) Start() error {
+	if ea.Exec == nil {
+		return fmt.Errorf("%s: cannot execute", ea.ExecutablePath)
+	}
+
+	ea.TenantOS.addProcess(ea, ea.processInfo("S"))
+	ea.done = make(chan struct{})
+	go func() {
+		defer close(ea.done)
+		ea.exitCode = ea.run()
+	}()
+	return nil
+}
+
+// Wait implements VOS.Wait. Killed processes are waited for as soon as
+// they're signaled.
+func (ea *TenantProcOS) Wait() int {
+	if ea.done == nil {
+		return 1
+	}
+	select {
+	case <-ea.done:
+		return ea.exitCode
+	case <-ea.killed:
+		return ea.killedStatus()
+	}
+}
+
+func (ea *TenantProcOS) processInfo(stat string) ProcessInfo {
+	tty := ""
+	if ea.GetPTY().IsPTY {
+		tty = "pts/0"
+	}
+	return ProcessInfo{
+		PID:     ea.PID,
+		PPID:    ea.PPID,
+		UID:     ea.UID,
+		TTY:     tty,
+		Stat:    stat,
+		VSZ:     sessionProcessVSZ,
+		RSS:     sessionProcessRSS,
+		Started: ea.Now(),
+		Args:    ea.ProcArgs,
+	}
+}
+
+// run executes the registered process and removes it from the process table
+// when it exits.
+func (ea *TenantProcOS) run() (resultCode int) {
 	defer func() {
 		if r := recover(); r != nil {
 			// Log the panic
@@ -99,11 +182,82 @@ func (ea *TenantProcOS) Run() (resultCode int) {
 			resultCode = 2
 		}
 	}()
+	defer ea.TenantOS.removeProcess(ea.PID)
 
-	if ea.Exec == nil {
-		return 1
+	resultCode = ea.Exec(ea)
+	if status := ea.killedStatus(); status != 0 {
+		return status
+	}
+	return resultCode
+}
+
+// killedStatus returns the exit status of a process terminated by a signal,
+// or 0 if it wasn't.
+func (ea *TenantProcOS) killedStatus() int {
+	ea.TenantOS.processMu.Lock()
+	defer ea.TenantOS.processMu.Unlock()
+
+	if ea.killedBy == 0 {
+		return 0
+	}
+	return 128 + int(ea.killedBy)
+}
+
+// Killed implements VOS.Killed.
+func (ea *TenantProcOS) Killed() <-chan struct{} {
+	return ea.killed
+}
+
+// IgnoreSignals implements VOS.IgnoreSignals.
+func (ea *TenantProcOS) IgnoreSignals(sigs ...Signal) {
+	ea.TenantOS.processMu.Lock()
+	defer ea.TenantOS.processMu.Unlock()
+
+	if ea.ignored == nil {
+		ea.ignored = make(map[Signal]bool)
+	}
+	for _, sig := range sigs {
+		ea.ignored[sig] = true
 	}
-	return ea.Exec(ea)
+}
+
+// Kill implements VOS.Kill like kill(2). Session processes that are
+// terminated stop reading and writing, and leave the process table once they
+// exit.
+func (ea *TenantProcOS) Kill(pid int, sig Signal) error {
+	t := ea.TenantOS
+	t.processMu.Lock()
+	defer t.processMu.Unlock()
+
+	if pid == -1 {
+		signaled := false
+		for _, process := range t.processes {
+			if process.PID == ea.PID || process.PID == 1 || !ea.canSignal(process) {
+				continue
+			}
+			signaled = true
+			t.signalLocked(process, sig)
+		}
+		if !signaled {
+			return syscall.ESRCH
+		}
+		return nil
+	}
+
+	process, ok := t.processes[pid]
+	switch {
+	case !ok:
+		return syscall.ESRCH
+	case !ea.canSignal(process):
+		return syscall.EPERM
+	}
+	t.signalLocked(process, sig)
+	return nil
+}
+
+// canSignal returns whether the caller has permission to signal the process.
+func (ea *TenantProcOS) canSignal(process ProcessInfo) bool {
+	return ea.UID == 0 || ea.UID == process.UID
 }
 
 type ProcAttr struct {
@@ -150,16 +304,18 @@ func (ea *TenantProcOS) StartProcess(name string, argv []string, attr *ProcAttr)
 		ExecutablePath: name,
 		ProcArgs:       argv,
 		PID:            ea.TenantOS.NextPID(),
+		PPID:           ea.PID,
 		UID:            ea.UID,
 		Dir:            ea.Dir,
+		killed:         make(chan struct{}),
 	}
 
-	out.VFS = NewSymlinkResolvingRelativeFs(ea.TenantOS.fs, out.Getwd)
+	out.VFS = newRecordingFs(NewSymlinkResolvingRelativeFs(newProcSelfFs(ea.TenantOS.fs, out.PID), out.Getwd), out)
 
 	if attr.Files == nil {
 		out.VIO = NewNullIO()
 	} else {
-		out.VIO = attr.Files
+		out.VIO = newKillableIO(attr.Files, out.killed)
 	}
 
 	if attr.Dir != "" {
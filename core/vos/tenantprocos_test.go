@@ -0,0 +1,232 @@
+package vos
+
+import (
+	"bytes"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/josephlewis42/honeyssh/core/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testProcessConfig() *config.Configuration {
+	cfg := &config.Configuration{}
+	cfg.OS.Processes = []config.Process{
+		{PID: 1, Stat: "Ss", VSZ: 21952, RSS: 9868, Command: "/sbin/init"},
+		{PID: 2, Stat: "S", Command: "[kthreadd]"},
+		{PID: 3, PPID: 2, Stat: "I<", Command: "[rcu_gp]"},
+		{PID: 343, PPID: 1, UID: 104, Stat: "Ss", Command: "/usr/bin/dbus-daemon"},
+		{PID: 501, PPID: 1, Stat: "Ss", Command: "/usr/sbin/sshd -D"},
+	}
+	return cfg
+}
+
+func pids(processes []ProcessInfo) []int {
+	var out []int
+	for _, process := range processes {
+		out = append(out, process.PID)
+	}
+	return out
+}
+
+func TestTenantOS_systemProcesses(t *testing.T) {
+	tenantOS, proc := newTestTenantOS(t, testProcessConfig(), &fakeEventRecorder{})
+
+	sshd, ok := tenantOS.Process(501)
+	require.True(t, ok)
+	assert.Equal(t, []string{"/usr/sbin/sshd", "-D"}, sshd.Args)
+	assert.Equal(t, tenantOS.BootTime(), sshd.Started)
+	assert.Equal(t, "sshd", sshd.Name())
+
+	rcu, ok := tenantOS.Process(3)
+	require.True(t, ok)
+	assert.True(t, rcu.KernelThread())
+	assert.Equal(t, "rcu_gp", rcu.Name())
+
+	cmdline, err := afero.ReadFile(proc, "/proc/3/cmdline")
+	require.Nil(t, err)
+	assert.Empty(t, cmdline)
+	status, err := afero.ReadFile(proc, "/proc/3/status")
+	require.Nil(t, err)
+	assert.Contains(t, string(status), "Name:\trcu_gp\nState:\tI (idle)\n")
+
+	tenantOS.SetPID(342)
+	assert.Equal(t, 344, tenantOS.NextPID(), "system PIDs aren't reused")
+}
+
+func TestTenantProcOS_Kill(t *testing.T) {
+	tenantOS, proc := newTestTenantOS(t, testProcessConfig(), &fakeEventRecorder{})
+
+	assert.Nil(t, proc.Kill(501, 0))
+	assert.Nil(t, proc.Kill(501, SIGCONT))
+	assert.Nil(t, proc.Kill(1, SIGKILL))
+	assert.Nil(t, proc.Kill(3, SIGKILL))
+	assert.Equal(t, []int{1, 2, 3, 343, 501}, pids(tenantOS.Processes()), "signals without effect")
+
+	assert.Nil(t, proc.Kill(501, SIGTERM))
+	assert.ErrorIs(t, proc.Kill(501, SIGTERM), syscall.ESRCH)
+
+	proc.Setuid(1000)
+	assert.ErrorIs(t, proc.Kill(343, SIGKILL), syscall.EPERM)
+	assert.ErrorIs(t, proc.Kill(-1, SIGKILL), syscall.ESRCH)
+
+	proc.Setuid(0)
+	assert.Nil(t, proc.Kill(-1, SIGKILL))
+	assert.Equal(t, []int{1, 2, 3}, pids(tenantOS.Processes()))
+}
+
+func TestTenantProcOS_Start(t *testing.T) {
+	tenantOS, started := newTestTenantOS(t, &config.Configuration{}, &fakeEventRecorder{})
+	proc := started.(*TenantProcOS)
+
+	release := make(chan struct{})
+	proc.Exec = func(VOS) int {
+		<-release
+		return 3
+	}
+	require.Nil(t, proc.Start())
+
+	process, ok := tenantOS.Process(proc.PID)
+	require.True(t, ok)
+	assert.Equal(t, "S", process.Stat)
+	assert.Equal(t, []string{"sh", "-c", "true"}, process.Args)
+
+	close(release)
+	assert.Equal(t, 3, proc.Wait())
+	_, ok = tenantOS.Process(proc.PID)
+	assert.False(t, ok, "exited processes are removed")
+}
+
+func TestTenantProcOS_Kill_sessionProcess(t *testing.T) {
+	tenantOS, parent := newTestTenantOS(t, &config.Configuration{}, &fakeEventRecorder{})
+	// Start after init and kthreadd so the processes can be signaled.
+	tenantOS.SetPID(100)
+
+	out := &bytes.Buffer{}
+	child, err := parent.StartProcess("sh", []string{"sh", "-c", "yes"}, &ProcAttr{
+		Files: NewVIOAdapter(nil, out, nil),
+	})
+	require.Nil(t, err)
+	stopped := make(chan struct{})
+	release := make(chan struct{})
+	child.(*TenantProcOS).Exec = func(proc VOS) int {
+		for {
+			if _, err := io.WriteString(proc.Stdout(), "y\n"); err != nil {
+				break
+			}
+		}
+		close(stopped)
+		<-release
+		return 0
+	}
+	require.Nil(t, child.Start())
+
+	assert.Nil(t, parent.Kill(child.Getpid(), SIGTERM))
+	assert.Equal(t, 128+int(SIGTERM), child.Wait())
+	<-stopped
+	written := out.Len()
+	_, err = io.WriteString(child.Stdout(), "y\n")
+	assert.NotNil(t, err, "killed processes can't write")
+	assert.Equal(t, written, out.Len())
+
+	_, ok := tenantOS.Process(child.Getpid())
+	assert.True(t, ok, "processes are listed until they exit")
+	assert.Nil(t, parent.Kill(child.Getpid(), SIGKILL), "signals can be sent until they exit")
+
+	close(release)
+	require.Eventually(t, func() bool {
+		_, ok := tenantOS.Process(child.Getpid())
+		return !ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestTenantOS_KillAll(t *testing.T) {
+	tenantOS, parent := newTestTenantOS(t, &config.Configuration{}, &fakeEventRecorder{})
+	tenantOS.SetPID(100)
+
+	child, err := parent.StartProcess("cat", []string{"cat", "/dev/zero"}, nil)
+	require.Nil(t, err)
+	copied := make(chan error, 1)
+	child.(*TenantProcOS).Exec = func(proc VOS) int {
+		zero, err := proc.Open("/dev/zero")
+		if err != nil {
+			copied <- err
+			return 1
+		}
+		defer zero.Close()
+		out, err := proc.Create("/root/zeros")
+		if err != nil {
+			copied <- err
+			return 1
+		}
+		defer out.Close()
+		_, err = io.Copy(out, zero)
+		copied <- err
+		return 1
+	}
+	// Background jobs ignore SIGTERM but can't survive the session.
+	child.IgnoreSignals(SIGTERM)
+	require.Nil(t, child.Start())
+
+	tenantOS.KillAll()
+	assert.Equal(t, 128+int(SIGKILL), child.Wait())
+	select {
+	case err := <-copied:
+		assert.ErrorIs(t, err, errKilled, "killed processes can't use their files")
+	case <-time.After(5 * time.Second):
+		t.Fatal("killed process kept copying")
+	}
+	require.Eventually(t, func() bool {
+		_, ok := tenantOS.Process(child.Getpid())
+		return !ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestTenantProcOS_IgnoreSignals(t *testing.T) {
+	tenantOS, parent := newTestTenantOS(t, &config.Configuration{}, &fakeEventRecorder{})
+	// Start after init and kthreadd so the processes can be signaled.
+	tenantOS.SetPID(100)
+
+	child, err := parent.StartProcess("sh", nil, nil)
+	require.Nil(t, err)
+	child.(*TenantProcOS).Exec = func(proc VOS) int {
+		<-proc.Killed()
+		return 0
+	}
+	child.IgnoreSignals(SIGTERM)
+	require.Nil(t, child.Start())
+
+	assert.Nil(t, parent.Kill(child.Getpid(), SIGTERM))
+	select {
+	case <-child.Killed():
+		t.Fatal("ignored signal killed the process")
+	default:
+	}
+
+	assert.Nil(t, parent.Kill(child.Getpid(), SIGKILL))
+	assert.Equal(t, 128+int(SIGKILL), child.Wait(), "SIGKILL can't be ignored")
+}
+
+func TestParseSignal(t *testing.T) {
+	for spec, want := range map[string]Signal{
+		"9":       SIGKILL,
+		"0":       0,
+		"KILL":    SIGKILL,
+		"sigterm": SIGTERM,
+		"SIGHUP":  SIGHUP,
+		"POLL":    SIGIO,
+	} {
+		got, err := ParseSignal(spec)
+		assert.Nil(t, err, spec)
+		assert.Equal(t, want, got, spec)
+	}
+
+	for _, spec := range []string{"", "-1", "32", "FOO"} {
+		_, err := ParseSignal(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
@@ -1,6 +1,7 @@
 package vos
 
 import (
+	"fmt"
 	"io"
 	"net"
 	"os"
@@ -21,10 +22,70 @@ type Utsname struct {
 	Domainname string // NIS or YP domain name
 }
 
+// Hardware describes the machine's processors and devices.
+type Hardware struct {
+	CPUs     int
+	CPUFlags []string
+	// SysVendor and ProductName are the DMI vendor and product.
+	SysVendor   string
+	ProductName string
+	// Hypervisor is the hypervisor vendor e.g. "KVM", blank for bare metal.
+	Hypervisor        string
+	NetworkInterfaces []NetworkInterface
+}
+
+// CPUList returns the CPUs in Linux's list format e.g. "0-3".
+func (h Hardware) CPUList() string {
+	if h.CPUs <= 1 {
+		return "0"
+	}
+	return fmt.Sprintf("0-%d", h.CPUs-1)
+}
+
+// NetworkInterface is an Ethernet interface.
+type NetworkInterface struct {
+	Name string
+	MAC  net.HardwareAddr
+	IP   net.IP
+}
+
+// LinkLocal returns the interface's IPv6 link-local address, which is
+// derived from the MAC.
+func (n NetworkInterface) LinkLocal() net.IP {
+	ip := make(net.IP, net.IPv6len)
+	ip[0], ip[1] = 0xfe, 0x80
+	if len(n.MAC) != 6 {
+		return ip
+	}
+	// Modified EUI-64: flip the universal/local bit and insert ff:fe.
+	copy(ip[8:], []byte{n.MAC[0] ^ 0x02, n.MAC[1], n.MAC[2], 0xff, 0xfe, n.MAC[3], n.MAC[4], n.MAC[5]})
+	return ip
+}
+
+// MemInfo holds memory statistics in kB like /proc/meminfo.
+type MemInfo struct {
+	MemTotal     int64
+	MemFree      int64
+	MemAvailable int64
+	Buffers      int64
+	Cached       int64
+	// SReclaimable is reclaimable slab memory, free counts it as cache.
+	SReclaimable int64
+	Shmem        int64
+	SwapTotal    int64
+	SwapFree     int64
+}
+
 type VKernel interface {
 	Hostname() string
 	// Uname mimics the uname syscall.
 	Uname() Utsname
+	// MemInfo returns the system's memory usage.
+	MemInfo() MemInfo
+	// LoadAverage returns the 1, 5 and 15 minute load averages.
+	LoadAverage() [3]float64
+	// Hardware returns the machine's processors and devices.
+	Hardware() Hardware
 }
 
 type PTY struct {
@@ -97,6 +158,29 @@ type VProc interface {
 	// Run executes the command, waits for it to finish and returns the status
 	// code.
 	Run() int
+
+	// Start executes the command in the background without waiting for it to
+	// finish.
+	Start() error
+
+	// Wait waits for a command started with Start to finish and returns the
+	// status code.
+	Wait() int
+
+	// Processes returns the running processes ordered by PID.
+	Processes() []ProcessInfo
+
+	// Kill sends the signal to the process with the PID, or every process
+	// the caller can signal if the PID is -1.
+	Kill(pid int, sig Signal) error
+
+	// Killed is closed when a signal terminates the process, the process
+	// should stop as soon as it can.
+	Killed() <-chan struct{}
+
+	// IgnoreSignals makes the process ignore the signals, SIGKILL can't be
+	// ignored.
+	IgnoreSignals(sigs ...Signal)
 }
 
 // VFS implements a virtual filesystem and is the second layer of the virtual OS.
@@ -173,6 +257,9 @@ type Honeypot interface {
 	// Record when credentials are used by the attacker.
 	LogCreds(*logger.Credentials)
 
+	// Record an operation requested through the SFTP subsystem.
+	LogSFTPRequest(*logger.SFTPRequest)
+
 	// Get a unique path in the downloads folder that the session can write a
 	// file to.
 	DownloadPath(source string) (afero.File, error)
@@ -180,5 +267,3 @@ type Honeypot interface {
 	// Now is the current honeypot time.
 	Now() time.Time
 }
-
-// /proc/sys/kernel/{ostype, hostname, osrelease, version, domainname}.
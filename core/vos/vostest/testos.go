@@ -48,6 +48,8 @@ func NewDeterministicOS(resolver vos.ProcessResolver) vos.VOS {
 	}
 
 	sharedOS := vos.NewSharedOS(memmapfs.NewMemMapFs(timeSource), resolver, &config.Configuration{}, timeSource)
+	// Start after init so processes can be signaled.
+	sharedOS.SetPID(100)
 
 	tenantOS := vos.NewTenantOS(sharedOS, &NopEventRecorder{}, &FakeSSHSession{})
 	tenantOS.SetPTY(vos.PTY{})
@@ -12,6 +12,7 @@ require (
 	github.com/google/go-containerregistry v0.6.0
 	github.com/juju/ratelimit v1.0.1
 	github.com/pborman/getopt/v2 v2.1.0
+	github.com/pkg/sftp v1.13.5
 	github.com/sebdah/goldie/v2 v2.5.3
 	github.com/spf13/afero v1.6.0
 	github.com/spf13/cobra v1.2.1
@@ -31,6 +32,7 @@ require (
 	github.com/golang/snappy v0.0.3 // indirect
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/klauspost/compress v1.13.0 // indirect
+	github.com/kr/fs v0.1.0 // indirect
 	github.com/leodido/go-urn v1.2.1 // indirect
 	github.com/mattn/go-colorable v0.1.9 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
@@ -97,6 +97,10 @@ func copyToLayer(base afero.Fs, layer afero.Fs, name string) error {
 		lfh.Close()
 		return err
 	}
+	// Keep the permissions, otherwise modifying a file would change them.
+	if err := layer.Chmod(name, bfi.Mode()); err != nil {
+		return err
+	}
 	return layer.Chtimes(name, bfi.ModTime(), bfi.ModTime())
 }
 
@@ -278,6 +282,10 @@ func (u *CopyOnWriteFs) OpenFile(name string, flag int, perm os.FileMode) (afero
 
 	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
 		if b {
+			// Devices like /dev/null are written in place rather than copied.
+			if bfi, err := u.base.Stat(name); err == nil && bfi.Mode()&os.ModeDevice != 0 {
+				return u.base.OpenFile(name, flag, perm)
+			}
 			if err = u.copyToLayer(name); err != nil {
 				return nil, err
 			}